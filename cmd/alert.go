@@ -0,0 +1,395 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// alertConfigVersion is the version of the alert config written by pb
+const alertConfigVersion = "v1"
+
+// Rule operators supported by Parseable, by name followed by the aliases the
+// server accepts for them. Numeric values support comparisons, string values
+// equality, substring and regular expression matches.
+var (
+	numericOperators = []string{
+		"equalTo", "notEqualTo", "greaterThan", "greaterThanEquals", "lessThan", "lessThanEquals",
+		"=", "!=", ">", ">=", "<", "<=",
+	}
+	stringOperators = []string{
+		"exact", "notExact", "contains", "notContains", "regex",
+		"=", "!=", "=%", "!%", "~",
+	}
+)
+
+// alertTargetTypes are the target types supported by Parseable
+var alertTargetTypes = []string{"webhook", "slack", "alertManager"}
+
+// AddAlertCmd adds an alert to a stream
+var AddAlertCmd = &cobra.Command{
+	Use:   "add stream-name alert-name",
+	Short: "Add an alert to a stream",
+	Example: `  pb stream alert add backend high-latency --column latency --operator '>' --value 500 --repeats 3 \
+    --target-type webhook --target-endpoint https://hooks.example.com/parseable
+  pb stream alert add backend high-latency --file alert.json`,
+	Long: `
+Add an alert to a stream. Define the alert with flags, or pass a JSON file
+with a complete alert, including several targets, using --file. The name
+given as argument replaces the name in the file.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		stream, name := args[0], args[1]
+		alert, err := alertFromFlags(cmd)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		alert.Name = name
+		if err := validateAlert(&alert); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		alerts, err := fetchAlertsOrEmpty(&client, stream)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		if alertIndex(alerts.Alerts, name) >= 0 {
+			err := fmt.Errorf("alert %s already exists on stream %s", name, stream)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		alerts.Alerts = append(alerts.Alerts, alert)
+		if err := putAlerts(&client, stream, alerts); err != nil {
+			err = fmt.Errorf("failed to add alert %s to stream %s: %w", name, stream, err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Added alert %s to stream %s\n", StyleBold.Render(name), StyleBold.Render(stream))
+		return nil
+	},
+}
+
+// ListAlertCmd lists the alerts of a stream
+var ListAlertCmd = &cobra.Command{
+	Use:     "list stream-name",
+	Short:   "List the alerts of a stream",
+	Example: "  pb stream alert list backend\n  pb stream alert list backend --output json",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		alerts, err := fetchAlertsOrEmpty(&client, args[0])
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		if output == outputJSON {
+			if alerts.Alerts == nil {
+				alerts.Alerts = []Alert{}
+			}
+			jsonData, err := json.MarshalIndent(alerts.Alerts, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(alerts.Alerts) == 0 {
+			fmt.Printf("No alerts set on stream %s\n", args[0])
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Rule", "Targets", "Message"})
+		table.SetAutoWrapText(false)
+		for _, alert := range alerts.Alerts {
+			targets := make([]string, len(alert.Targets))
+			for idx, target := range alert.Targets {
+				targets[idx] = targetSummary(target)
+			}
+			table.Append([]string{alert.Name, ruleSummary(alert.Rule), strings.Join(targets, "\n"), alert.Message})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// RemoveAlertCmd removes an alert from a stream
+var RemoveAlertCmd = &cobra.Command{
+	Use:     "remove stream-name alert-name",
+	Aliases: []string{"rm"},
+	Short:   "Remove an alert from a stream",
+	Example: "  pb stream alert remove backend high-latency",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		stream, name := args[0], args[1]
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		alerts, err := fetchAlertsOrEmpty(&client, stream)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		idx := alertIndex(alerts.Alerts, name)
+		if idx < 0 {
+			err := fmt.Errorf("alert %s does not exist on stream %s", name, stream)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		alerts.Alerts = append(alerts.Alerts[:idx], alerts.Alerts[idx+1:]...)
+
+		if err := putAlerts(&client, stream, alerts); err != nil {
+			err = fmt.Errorf("failed to remove alert %s from stream %s: %w", name, stream, err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Removed alert %s from stream %s\n", StyleBold.Render(name), StyleBold.Render(stream))
+		return nil
+	},
+}
+
+func init() {
+	AddAlertCmd.Flags().StringP("file", "f", "", "JSON file with the alert definition")
+	AddAlertCmd.Flags().String("column", "", "Column the rule is evaluated on")
+	AddAlertCmd.Flags().String("operator", "", "Operator comparing the column to the value, e.g. '=', '>' or 'contains'")
+	AddAlertCmd.Flags().String("value", "", "Value the column is compared to, numbers are sent as numbers")
+	AddAlertCmd.Flags().Int("repeats", 1, "Number of consecutive matching events that trigger the alert")
+	AddAlertCmd.Flags().Bool("ignore-case", false, "Compare string values case insensitively")
+	AddAlertCmd.Flags().String("message", "", "Message sent with the alert")
+	AddAlertCmd.Flags().String("target-type", "webhook", "Type of the alert target, e.g. webhook or slack")
+	AddAlertCmd.Flags().String("target-endpoint", "", "URL the alert is sent to")
+	AddAlertCmd.Flags().Bool("skip-tls-check", false, "Skip TLS certificate verification of the target")
+	AddAlertCmd.Flags().String("repeat-interval", "", "Interval between repeated notifications, e.g. 1m")
+	AddAlertCmd.Flags().Int("repeat-times", 0, "Number of repeated notifications")
+	AddAlertCmd.MarkFlagsMutuallyExclusive("file", "column")
+}
+
+// alertFromFlags builds the alert from the --file flag or the rule and
+// target flags
+func alertFromFlags(cmd *cobra.Command) (Alert, error) {
+	var alert Alert
+	if path, _ := cmd.Flags().GetString("file"); path != "" {
+		path, err := common.ExpandPath(path)
+		if err != nil {
+			return alert, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return alert, err
+		}
+		if err := json.Unmarshal(data, &alert); err != nil {
+			return alert, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return alert, nil
+	}
+
+	column, _ := cmd.Flags().GetString("column")
+	operator, _ := cmd.Flags().GetString("operator")
+	value, _ := cmd.Flags().GetString("value")
+	endpoint, _ := cmd.Flags().GetString("target-endpoint")
+	if column == "" || operator == "" || endpoint == "" {
+		return alert, errors.New("either --file or --column, --operator and --target-endpoint are required")
+	}
+
+	alert.Message, _ = cmd.Flags().GetString("message")
+	if alert.Message == "" {
+		alert.Message = fmt.Sprintf("%s %s %s", column, operator, value)
+	}
+	alert.Rule = Rule{
+		Type: "column",
+		Config: RuleConfig{
+			Column:   column,
+			Operator: operator,
+			Value:    alertValue(value),
+		},
+	}
+	alert.Rule.Config.Repeats, _ = cmd.Flags().GetInt("repeats")
+	alert.Rule.Config.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+
+	target := Target{Endpoint: endpoint, Headers: map[string]string{}}
+	target.Type, _ = cmd.Flags().GetString("target-type")
+	target.SkipTLSCheck, _ = cmd.Flags().GetBool("skip-tls-check")
+	target.Repeat.Interval, _ = cmd.Flags().GetString("repeat-interval")
+	target.Repeat.Times, _ = cmd.Flags().GetInt("repeat-times")
+	alert.Targets = []Target{target}
+	return alert, nil
+}
+
+// validateAlert checks the rule and targets of alert against what Parseable
+// supports, so mistakes are reported before anything is sent. Target types
+// are normalised to their canonical spelling.
+func validateAlert(alert *Alert) error {
+	if alert.Rule.Type != "column" {
+		// composite rules are a free form expression checked by the server
+		if alert.Rule.Type == "composite" {
+			return validateTargets(alert.Targets)
+		}
+		return fmt.Errorf("invalid rule type %q, valid types are: column, composite", alert.Rule.Type)
+	}
+
+	rule := alert.Rule.Config
+	if rule.Column == "" {
+		return errors.New("the rule has no column")
+	}
+	switch rule.Value.(type) {
+	case float64:
+		if !slices.Contains(numericOperators, rule.Operator) {
+			return fmt.Errorf("invalid operator %q for numeric value %v, valid operators are: %s",
+				rule.Operator, rule.Value, strings.Join(numericOperators, ", "))
+		}
+	case string:
+		if !slices.Contains(stringOperators, rule.Operator) {
+			return fmt.Errorf("invalid operator %q for string value %q, valid operators are: %s",
+				rule.Operator, rule.Value, strings.Join(stringOperators, ", "))
+		}
+	default:
+		return fmt.Errorf("invalid rule value %v, expected a number or a string", rule.Value)
+	}
+	if rule.Repeats < 1 {
+		return fmt.Errorf("invalid repeats %d, an alert needs at least one matching event", rule.Repeats)
+	}
+	return validateTargets(alert.Targets)
+}
+
+func validateTargets(targets []Target) error {
+	if len(targets) == 0 {
+		return errors.New("the alert has no targets")
+	}
+	for idx := range targets {
+		target := &targets[idx]
+		valid := false
+		for _, targetType := range alertTargetTypes {
+			if strings.EqualFold(target.Type, targetType) {
+				target.Type = targetType
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid target type %q, valid types are: %s", target.Type, strings.Join(alertTargetTypes, ", "))
+		}
+		endpoint, err := url.Parse(target.Endpoint)
+		if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			return fmt.Errorf("invalid endpoint %q of %s target, expected an http or https URL", target.Endpoint, target.Type)
+		}
+	}
+	return nil
+}
+
+// alertValue sends numeric values as numbers, so numeric columns compare
+// correctly
+func alertValue(value string) interface{} {
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+// fetchAlertsOrEmpty returns the alerts of the stream. Servers answer 404
+// for streams without alerts, which is returned as an empty config.
+func fetchAlertsOrEmpty(client *internalHTTP.HTTPClient, stream string) (AlertConfig, error) {
+	alerts, err := fetchAlerts(client, stream)
+	var apiErr *internalHTTP.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return AlertConfig{Version: alertConfigVersion}, nil
+	}
+	if err != nil {
+		return alerts, fmt.Errorf("failed to fetch alerts of stream %s: %w", stream, err)
+	}
+	if alerts.Version == "" {
+		alerts.Version = alertConfigVersion
+	}
+	return alerts, nil
+}
+
+func alertIndex(alerts []Alert, name string) int {
+	for idx, alert := range alerts {
+		if alert.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// targetSummary describes a target in one line
+func targetSummary(target Target) string {
+	summary := target.Type
+	if target.Endpoint != "" {
+		summary += " " + target.Endpoint
+	}
+	if target.Repeat.Times > 0 || target.Repeat.Interval != "" {
+		summary += fmt.Sprintf(", repeats %d times every %s", target.Repeat.Times, target.Repeat.Interval)
+	}
+	return summary
+}
+
+// ruleSummary describes a rule in one line
+func ruleSummary(rule Rule) string {
+	return fmt.Sprintf(
+		"%s %s %s repeated %d times",
+		rule.Config.Column,
+		rule.Config.Operator,
+		fmt.Sprint(rule.Config.Value),
+		rule.Config.Repeats,
+	)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// useAlertServer points the default profile at a server holding the alerts
+// of the stream backend, which starts without any
+func useAlertServer(t *testing.T) {
+	t.Helper()
+	var stored *AlertConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logstream/backend/alert" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				http.Error(w, "no alerts set", http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(stored)
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			stored = &AlertConfig{}
+			if err := json.Unmarshal(body, stored); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL, Username: "admin", Password: "admin"}
+	t.Cleanup(func() { DefaultProfile = previous })
+}
+
+// setFlags sets flags of cmd for the duration of a test
+func setFlags(t *testing.T, cmd *cobra.Command, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		flag := cmd.Flags().Lookup(name)
+		previous := flag.Value.String()
+		if err := flag.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = flag.Value.Set(previous) })
+	}
+}
+
+func TestAddAndRemoveAlert(t *testing.T) {
+	useAlertServer(t)
+	setFlags(t, AddAlertCmd, map[string]string{
+		"column":          "status",
+		"operator":        "=",
+		"value":           "500",
+		"target-endpoint": "https://hooks.example.com",
+	})
+
+	if err := AddAlertCmd.RunE(AddAlertCmd, []string{"backend", "errors"}); err != nil {
+		t.Fatalf("add failed: %s", err)
+	}
+	if err := AddAlertCmd.RunE(AddAlertCmd, []string{"backend", "errors"}); err == nil {
+		t.Errorf("expected an error when adding an existing alert")
+	}
+
+	client := internalHTTP.DefaultClient(&DefaultProfile)
+	alerts, err := fetchAlertsOrEmpty(&client, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Alerts) != 1 || alerts.Version != alertConfigVersion {
+		t.Fatalf("expected one alert, got %+v", alerts)
+	}
+	alert := alerts.Alerts[0]
+	if alert.Name != "errors" || alert.Rule.Config.Value != float64(500) || alert.Targets[0].Type != "webhook" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	if err := RemoveAlertCmd.RunE(RemoveAlertCmd, []string{"backend", "errors"}); err != nil {
+		t.Fatalf("remove failed: %s", err)
+	}
+	if err := RemoveAlertCmd.RunE(RemoveAlertCmd, []string{"backend", "errors"}); err == nil {
+		t.Errorf("expected an error when removing a missing alert")
+	}
+}
+
+func TestValidateAlert(t *testing.T) {
+	valid := func() Alert {
+		return Alert{
+			Name:    "errors",
+			Rule:    Rule{Type: "column", Config: RuleConfig{Column: "status", Operator: ">=", Value: float64(500), Repeats: 1}},
+			Targets: []Target{{Type: "Slack", Endpoint: "https://hooks.slack.com/services/x"}},
+		}
+	}
+
+	alert := valid()
+	if err := validateAlert(&alert); err != nil {
+		t.Fatalf("valid alert rejected: %s", err)
+	}
+	if alert.Targets[0].Type != "slack" {
+		t.Errorf("target type was not normalised, got %s", alert.Targets[0].Type)
+	}
+
+	// every operator name and alias of the server is accepted
+	for _, operator := range numericOperators {
+		alert := valid()
+		alert.Rule.Config.Operator = operator
+		if err := validateAlert(&alert); err != nil {
+			t.Errorf("numeric operator %s rejected: %s", operator, err)
+		}
+	}
+	for _, operator := range []string{"exact", "notExact", "contains", "notContains", "regex", "=", "!=", "=%", "!%", "~"} {
+		alert := valid()
+		alert.Rule.Config.Operator, alert.Rule.Config.Value = operator, "error"
+		if err := validateAlert(&alert); err != nil {
+			t.Errorf("string operator %s rejected: %s", operator, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Alert)
+		want   string
+	}{
+		{"unknown operator", func(a *Alert) { a.Rule.Config.Operator = "=>" }, "greaterThanEquals, lessThan, lessThanEquals, =, !=, >, >=, <, <="},
+		{"string operator on number", func(a *Alert) { a.Rule.Config.Operator = "contains" }, "for numeric value"},
+		{"comparison on string", func(a *Alert) { a.Rule.Config.Value = "error" }, "valid operators are: exact, notExact, contains, notContains, regex, =, !=, =%, !%, ~"},
+		{"negated contains", func(a *Alert) { a.Rule.Config.Value = "error"; a.Rule.Config.Operator = "!contains" }, "invalid operator \"!contains\""},
+		{"no repeats", func(a *Alert) { a.Rule.Config.Repeats = 0 }, "invalid repeats"},
+		{"unknown target", func(a *Alert) { a.Targets[0].Type = "email" }, "valid types are: webhook, slack, alertManager"},
+		{"no targets", func(a *Alert) { a.Targets = nil }, "no targets"},
+		{"bad endpoint", func(a *Alert) { a.Targets[0].Endpoint = "hooks.slack.com" }, "invalid endpoint"},
+		{"unknown rule type", func(a *Alert) { a.Rule.Type = "threshold" }, "valid types are: column, composite"},
+	}
+	for _, tt := range tests {
+		alert := valid()
+		tt.modify(&alert)
+		err := validateAlert(&alert)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -18,34 +18,75 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// completionShells lists the shells completion scripts can be generated for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionGenerators write the completion script of each supported shell
+var completionGenerators = map[string]func(root *cobra.Command, w io.Writer) error{
+	"bash":       func(root *cobra.Command, w io.Writer) error { return root.GenBashCompletion(w) },
+	"zsh":        func(root *cobra.Command, w io.Writer) error { return root.GenZshCompletion(w) },
+	"fish":       func(root *cobra.Command, w io.Writer) error { return root.GenFishCompletion(w, true) },
+	"powershell": func(root *cobra.Command, w io.Writer) error { return root.GenPowerShellCompletionWithDesc(w) },
+}
+
 // AutocompleteCmd represents the autocomplete command
 var AutocompleteCmd = &cobra.Command{
-	Use:   "autocomplete [bash|zsh|powershell]",
-	Short: "Generate autocomplete script",
-	Long:  `Generate autocomplete script for bash, zsh, or powershell`,
-	Args:  cobra.ExactArgs(1),
+	Use:       "autocomplete [bash|zsh|fish|powershell]",
+	Short:     "Generate autocomplete script",
+	Long:      `Generate autocomplete script for bash, zsh, fish or powershell`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: completionShells,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		switch args[0] {
-		case "bash":
-			err = cmd.Root().GenBashCompletion(os.Stdout)
-		case "zsh":
-			err = cmd.Root().GenZshCompletion(os.Stdout)
-		case "powershell":
-			err = cmd.Root().GenPowerShellCompletionWithDesc(os.Stdout)
-		default:
-			err = fmt.Errorf("unsupported shell type: %s. Only bash, zsh, and powershell are supported", args[0])
+		generate, ok := completionGenerators[args[0]]
+		if !ok {
+			return fmt.Errorf("unsupported shell type: %s. Only %s are supported", args[0], strings.Join(completionShells, ", "))
 		}
 
-		if err != nil {
+		if err := generate(cmd.Root(), cmd.OutOrStdout()); err != nil {
 			return fmt.Errorf("error generating autocomplete script: %w", err)
 		}
 
 		return nil
 	},
 }
+
+// CompletionCmd has one subcommand per shell that prints its completion script
+var CompletionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "Generate the completion script for a shell",
+	Long: `Generate the completion script for bash, zsh, fish or powershell. The script
+is written to stdout, see the help of each shell for how to load it.`,
+	Args: cobra.NoArgs,
+}
+
+// completionHelp explains how to load the completion script of each shell
+var completionHelp = map[string]string{
+	"bash":       "  source <(pb completion bash)\n  pb completion bash > /etc/bash_completion.d/pb",
+	"zsh":        "  pb completion zsh > \"${fpath[1]}/_pb\"",
+	"fish":       "  pb completion fish > ~/.config/fish/completions/pb.fish",
+	"powershell": "  pb completion powershell | Out-String | Invoke-Expression",
+}
+
+func init() {
+	for _, shell := range completionShells {
+		CompletionCmd.AddCommand(&cobra.Command{
+			Use:                   shell,
+			Short:                 fmt.Sprintf("Generate the completion script for %s", shell),
+			Example:               completionHelp[shell],
+			Args:                  cobra.NoArgs,
+			DisableFlagsInUseLine: true,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				if err := completionGenerators[shell](cmd.Root(), cmd.OutOrStdout()); err != nil {
+					return fmt.Errorf("error generating %s completion script: %w", shell, err)
+				}
+				return nil
+			},
+		})
+	}
+}
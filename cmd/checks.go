@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"pb/pkg/common"
+)
+
+// Check statuses reported by diagnostic commands
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+)
+
+// CheckResult is the outcome of a single diagnostic check. Diagnostic
+// commands share it so their JSON output can be consumed uniformly.
+type CheckResult struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+}
+
+// ErrChecksFailed is returned when at least one diagnostic check failed, so
+// the command exits non-zero
+var ErrChecksFailed = errors.New("one or more checks failed")
+
+// printCheckResults renders the results as a JSON array or as one line per
+// check, and returns ErrChecksFailed if any of them failed
+func printCheckResults(results []CheckResult, output string) error {
+	if output == outputJSON {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		for _, result := range results {
+			status := common.Green + "PASS" + common.Reset
+			if result.Status != checkPass {
+				status = common.Red + "FAIL" + common.Reset
+			}
+			detail := result.Detail
+			if result.LatencyMS > 0 {
+				detail = fmt.Sprintf("%s (%dms)", detail, result.LatencyMS)
+			}
+			fmt.Printf("%s  %-20s %s\n", status, result.Name, detail)
+		}
+	}
+
+	for _, result := range results {
+		if result.Status != checkPass {
+			return ErrChecksFailed
+		}
+	}
+	return nil
+}
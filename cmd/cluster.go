@@ -18,53 +18,79 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"pb/pkg/common"
 	"pb/pkg/helm"
 	"pb/pkg/installer"
+	"pb/pkg/log"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-var verbose bool
+var (
+	verbose      bool
+	repoURL      string
+	chartVersion string
+	dryRun       bool
+	playground   bool
+
+	uninstallDryRun bool
+)
 
 var InstallOssCmd = &cobra.Command{
 	Use:     "install",
 	Short:   "Deploy Parseable",
-	Example: "pb cluster install",
-	Run: func(cmd *cobra.Command, _ []string) {
-		// Add verbose flag
-		cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
-		installer.Installer(verbose)
+	Example: "  pb cluster install\n  pb cluster install --playground",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		// --verbose shows the Helm output, which is logged at info level
+		if verbose && !log.Enabled(log.LevelInfo) {
+			log.SetLevel(log.LevelInfo)
+		}
+		return installer.Installer(installer.InstallOptions{
+			Verbose:      verbose,
+			RepoURL:      repoURL,
+			ChartVersion: chartVersion,
+			DryRun:       dryRun,
+			Playground:   playground,
+		})
 	},
 }
 
+func init() {
+	InstallOssCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	InstallOssCmd.Flags().StringVar(&repoURL, "repo-url", installer.DefaultRepoURL, "Helm chart repository URL. Credentials are prompted for non-default repositories")
+	InstallOssCmd.Flags().BoolVar(&playground, "playground", false, "Install a local store playground with default settings and generated credentials, without prompts. An existing release or secret is left alone")
+	InstallOssCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the rendered manifests instead of installing")
+	InstallOssCmd.Flags().StringVar(&chartVersion, "chart-version", installer.DefaultChartVersion, "Version of the Parseable Helm chart to install")
+}
+
 // ListOssCmd lists the Parseable OSS servers
 var ListOssCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "List available Parseable servers",
 	Example: "pb list",
-	Run: func(_ *cobra.Command, _ []string) {
+	RunE: func(_ *cobra.Command, _ []string) error {
 		_, err := common.PromptK8sContext()
 		if err != nil {
-			log.Fatalf("Failed to prompt for kubernetes context: %v", err)
+			return fmt.Errorf("failed to prompt for kubernetes context: %w", err)
 		}
 
 		// Read the installer data from the ConfigMap
 		entries, err := common.ReadInstallerConfigMap()
 		if err != nil {
-			log.Fatalf("Failed to list servers: %v", err)
+			return fmt.Errorf("failed to list servers: %w", err)
 		}
 
 		// Check if there are no entries
 		if len(entries) == 0 {
 			fmt.Println("No clusters found.")
-			return
+			return nil
 		}
 
 		// Display the entries in a table format
@@ -76,6 +102,7 @@ var ListOssCmd = &cobra.Command{
 		}
 
 		table.Render()
+		return nil
 	},
 }
 
@@ -84,47 +111,148 @@ var ShowValuesCmd = &cobra.Command{
 	Use:     "show values",
 	Short:   "Show values available in Parseable servers",
 	Example: "pb show values",
-	Run: func(_ *cobra.Command, _ []string) {
+	RunE: func(_ *cobra.Command, _ []string) error {
 		_, err := common.PromptK8sContext()
 		if err != nil {
-			log.Fatalf("Failed to prompt for Kubernetes context: %v", err)
+			return fmt.Errorf("failed to prompt for Kubernetes context: %w", err)
 		}
 
 		// Read the installer data from the ConfigMap
 		entries, err := common.ReadInstallerConfigMap()
 		if err != nil {
-			log.Fatalf("Failed to list OSS servers: %v", err)
+			return fmt.Errorf("failed to list OSS servers: %w", err)
 		}
 
 		// Check if there are no entries
 		if len(entries) == 0 {
 			fmt.Println("No OSS servers found.")
-			return
+			return nil
 		}
 
 		// Prompt user to select a cluster
 		selectedCluster, err := common.PromptClusterSelection(entries)
 		if err != nil {
-			log.Fatalf("Failed to select a cluster: %v", err)
+			return fmt.Errorf("failed to select a cluster: %w", err)
 		}
 
 		values, err := helm.GetReleaseValues(selectedCluster.Name, selectedCluster.Namespace)
 		if err != nil {
-			log.Fatalf("Failed to get values for release: %v", err)
+			return fmt.Errorf("failed to get values for release: %w", err)
 		}
 
 		// Marshal values to YAML for nice formatting
 		yamlOutput, err := yaml.Marshal(values)
 		if err != nil {
-			log.Fatalf("Failed to marshal values to YAML: %v", err)
+			return fmt.Errorf("failed to marshal values to YAML: %w", err)
 		}
 
 		// Print the YAML output
 		fmt.Println(string(yamlOutput))
 
-		// Print instructions for fetching secret values
-		fmt.Printf("\nTo get secret values of the Parseable cluster, run the following command:\n")
-		fmt.Printf("kubectl get secret -n %s parseable-env-secret -o jsonpath='{.data}' | jq -r 'to_entries[] | \"\\(.key): \\(.value | @base64d)\"'\n", selectedCluster.Namespace)
+		fmt.Println("\nTo get secret values of the Parseable cluster, run: pb cluster show-secret --reveal")
+		return nil
+	},
+}
+
+// StatusOssCmd shows the health of the pods and Helm release of a Parseable cluster
+var StatusOssCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Show pod and release health of a Parseable server",
+	Example: "pb cluster status",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		_, err := common.PromptK8sContext()
+		if err != nil {
+			return fmt.Errorf("failed to prompt for Kubernetes context: %w", err)
+		}
+
+		// Read the installer data from the ConfigMap
+		entries, err := common.ReadInstallerConfigMap()
+		if err != nil {
+			return fmt.Errorf("failed to list servers: %w", err)
+		}
+
+		// Check if there are no entries
+		if len(entries) == 0 {
+			fmt.Println("No clusters found.")
+			return nil
+		}
+
+		// Prompt user to select a cluster
+		selectedCluster, err := common.PromptClusterSelection(entries)
+		if err != nil {
+			return fmt.Errorf("failed to select a cluster: %w", err)
+		}
+
+		// Show the Helm release status
+		releases, err := helm.ListReleases(selectedCluster.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to list Helm releases: %w", err)
+		}
+		releaseFound := false
+		for _, rel := range releases {
+			if rel.Name != selectedCluster.Name {
+				continue
+			}
+			releaseFound = true
+			status := rel.Info.Status.String()
+			if rel.Info.Status != release.StatusDeployed {
+				status = common.Red + status + common.Reset
+			}
+			fmt.Printf("Release:  %s\nChart:    %s\nRevision: %d\nStatus:   %s\n\n",
+				rel.Name, rel.Chart.Metadata.Name+"-"+rel.Chart.Metadata.Version, rel.Version, status)
+		}
+		if !releaseFound {
+			fmt.Printf(common.Red+"Helm release '%s' not found in namespace '%s'.\n\n"+common.Reset, selectedCluster.Name, selectedCluster.Namespace)
+		}
+
+		// Show the pods running in the release namespace
+		config, err := common.LoadKubeConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		pods, err := clientset.CoreV1().Pods(selectedCluster.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+		if len(pods.Items) == 0 {
+			fmt.Println(common.Red + "No pods found." + common.Reset)
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Pod", "Phase", "Ready", "Restarts"})
+
+		for _, pod := range pods.Items {
+			ready, restarts := 0, int32(0)
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.Ready {
+					ready++
+				}
+				restarts += status.RestartCount
+			}
+			row := []string{
+				pod.Name,
+				string(pod.Status.Phase),
+				fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+				fmt.Sprint(restarts),
+			}
+
+			// Flag pods that are not running with all containers ready
+			if pod.Status.Phase != corev1.PodSucceeded &&
+				(pod.Status.Phase != corev1.PodRunning || ready != len(pod.Spec.Containers)) {
+				for i := range row {
+					row[i] = common.Red + row[i] + common.Reset
+				}
+			}
+			table.Append(row)
+		}
+
+		table.Render()
+		return nil
 	},
 }
 
@@ -132,29 +260,33 @@ var ShowValuesCmd = &cobra.Command{
 var UninstallOssCmd = &cobra.Command{
 	Use:     "uninstall",
 	Short:   "Uninstall Parseable servers",
-	Example: "pb uninstall",
-	Run: func(_ *cobra.Command, _ []string) {
+	Example: "  pb cluster uninstall\n  pb cluster uninstall --dry-run",
+	RunE: func(_ *cobra.Command, _ []string) error {
 		_, err := common.PromptK8sContext()
 		if err != nil {
-			log.Fatalf("Failed to prompt for Kubernetes context: %v", err)
+			return fmt.Errorf("failed to prompt for Kubernetes context: %w", err)
 		}
 
 		// Read the installer data from the ConfigMap
 		entries, err := common.ReadInstallerConfigMap()
 		if err != nil {
-			log.Fatalf("Failed to fetch OSS servers: %v", err)
+			return fmt.Errorf("failed to fetch OSS servers: %w", err)
 		}
 
 		// Check if there are no entries
 		if len(entries) == 0 {
 			fmt.Println(common.Yellow + "\nNo Parseable OSS servers found to uninstall.")
-			return
+			return nil
 		}
 
 		// Prompt user to select a cluster
 		selectedCluster, err := common.PromptClusterSelection(entries)
 		if err != nil {
-			log.Fatalf("Failed to select a cluster: %v", err)
+			return fmt.Errorf("failed to select a cluster: %w", err)
+		}
+
+		if uninstallDryRun {
+			return printUninstallDryRun(selectedCluster)
 		}
 
 		// Display a warning banner
@@ -167,32 +299,37 @@ var UninstallOssCmd = &cobra.Command{
 		fmt.Printf("\nYou have selected to uninstall the cluster '%s' in namespace '%s'.\n", selectedCluster.Name, selectedCluster.Namespace)
 		if !common.PromptConfirmation(fmt.Sprintf("Do you want to proceed with uninstalling '%s'?", selectedCluster.Name)) {
 			fmt.Println(common.Yellow + "Uninstall operation canceled.")
-			return
+			return nil
 		}
 
 		//Perform uninstallation
 		if err := uninstallCluster(selectedCluster); err != nil {
-			log.Fatalf("Failed to uninstall cluster: %v", err)
+			return fmt.Errorf("failed to uninstall cluster: %w", err)
 		}
 
 		// Remove entry from ConfigMap
 		if err := common.RemoveInstallerEntry(selectedCluster.Name); err != nil {
-			log.Fatalf("Failed to remove entry from ConfigMap: %v", err)
+			return fmt.Errorf("failed to remove entry from ConfigMap: %w", err)
 		}
 
 		// Delete secret
 		if err := deleteSecret(selectedCluster.Namespace, "parseable-env-secret"); err != nil {
-			log.Printf("Warning: Failed to delete secret 'parseable-env-secret': %v", err)
+			log.Warn("failed to delete secret parseable-env-secret", "error", err)
 		} else {
 			fmt.Println(common.Green + "Secret 'parseable-env-secret' deleted successfully." + common.Reset)
 		}
 
 		fmt.Println(common.Green + "Uninstallation completed successfully." + common.Reset)
+		return nil
 	},
 }
 
-func uninstallCluster(entry common.InstallerEntry) error {
-	helmApp := helm.Helm{
+func init() {
+	UninstallOssCmd.Flags().BoolVar(&uninstallDryRun, "dry-run", false, "Show what would be removed without uninstalling")
+}
+
+func clusterHelmApp(entry common.InstallerEntry) helm.Helm {
+	return helm.Helm{
 		ReleaseName: entry.Name,
 		Namespace:   entry.Namespace,
 		RepoName:    "parseable",
@@ -200,6 +337,30 @@ func uninstallCluster(entry common.InstallerEntry) error {
 		ChartName:   "parseable",
 		Version:     entry.Version,
 	}
+}
+
+// printUninstallDryRun lists what uninstalling the cluster removes
+func printUninstallDryRun(entry common.InstallerEntry) error {
+	resp, err := helm.UninstallDryRun(clusterHelmApp(entry), false)
+	if err != nil {
+		return fmt.Errorf("failed to look up release '%s': %w", entry.Name, err)
+	}
+
+	fmt.Printf("Uninstalling '%s' from namespace '%s' would remove:\n", entry.Name, entry.Namespace)
+	fmt.Printf("  Helm release %s\n", entry.Name)
+	if resp.Release != nil {
+		for _, resource := range helm.ReleaseResources(resp.Release.Manifest) {
+			fmt.Printf("  %s\n", resource)
+		}
+	}
+	fmt.Printf("  Secret/%s\n", clusterSecretName)
+	fmt.Println("  Installer entry " + entry.Name)
+	fmt.Println(common.Yellow + "Dry run, nothing was removed." + common.Reset)
+	return nil
+}
+
+func uninstallCluster(entry common.InstallerEntry) error {
+	helmApp := clusterHelmApp(entry)
 
 	fmt.Println(common.Yellow + "Starting uninstallation process..." + common.Reset)
 
@@ -0,0 +1,174 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"pb/pkg/common"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	logsSince     time.Duration
+	logsContainer string
+)
+
+// LogsOssCmd streams the logs of the pods of a Parseable cluster
+var LogsOssCmd = &cobra.Command{
+	Use:     "logs",
+	Short:   "Stream logs from the pods of a Parseable server",
+	Example: "  pb cluster logs\n  pb cluster logs --since 10m --container parseable",
+	Args:    cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		_, err := common.PromptK8sContext()
+		if err != nil {
+			return fmt.Errorf("failed to prompt for Kubernetes context: %w", err)
+		}
+
+		// Read the installer data from the ConfigMap
+		entries, err := common.ReadInstallerConfigMap()
+		if err != nil {
+			return fmt.Errorf("failed to list servers: %w", err)
+		}
+
+		// Check if there are no entries
+		if len(entries) == 0 {
+			fmt.Println("No clusters found.")
+			return nil
+		}
+
+		// Prompt user to select a cluster
+		selectedCluster, err := common.PromptClusterSelection(entries)
+		if err != nil {
+			return fmt.Errorf("failed to select a cluster: %w", err)
+		}
+
+		config, err := common.LoadKubeConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		pods, err := clientset.CoreV1().Pods(selectedCluster.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+		selected := parseablePods(pods.Items, selectedCluster.Name)
+		if len(selected) == 0 {
+			fmt.Println(common.Red + "No Parseable pods found." + common.Reset)
+			return nil
+		}
+
+		opts := corev1.PodLogOptions{Follow: true, Container: logsContainer}
+		if logsSince > 0 {
+			seconds := int64(logsSince.Seconds())
+			opts.SinceSeconds = &seconds
+		}
+
+		streamPodLogs(ctx, clientset, selected, opts)
+		return nil
+	},
+}
+
+func init() {
+	LogsOssCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show logs newer than a relative duration like 5m or 1h")
+	LogsOssCmd.Flags().StringVarP(&logsContainer, "container", "c", "", "Container to stream logs from, defaults to the first container of each pod")
+}
+
+// parseablePods returns the querier and ingestor pods of the release. Releases
+// without them, such as playground installs, return every pod of the release.
+func parseablePods(pods []corev1.Pod, releaseName string) []corev1.Pod {
+	var release, distributed []corev1.Pod
+	for _, pod := range pods {
+		if !strings.HasPrefix(pod.Name, releaseName+"-") {
+			continue
+		}
+		release = append(release, pod)
+		if strings.Contains(pod.Name, "querier") || strings.Contains(pod.Name, "ingestor") {
+			distributed = append(distributed, pod)
+		}
+	}
+	if len(distributed) > 0 {
+		return distributed
+	}
+	return release
+}
+
+// streamPodLogs follows the logs of every pod until they end or ctx is
+// cancelled, prefixing each line with the name of its pod
+func streamPodLogs(ctx context.Context, clientset kubernetes.Interface, pods []corev1.Pod, opts corev1.PodLogOptions) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	// cycle through colors so the lines of each pod stand out
+	colors := []string{common.Green, common.Yellow, common.Blue, common.Cyan}
+	for idx, pod := range pods {
+		prefix := colors[idx%len(colors)] + "[" + pod.Name + "]" + common.Reset
+
+		wg.Add(1)
+		go func(pod corev1.Pod) {
+			defer wg.Done()
+
+			podOpts := podLogOptions(pod, opts)
+			stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &podOpts).Stream(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "%s failed to stream logs: %s\n", prefix, err)
+				}
+				return
+			}
+			defer stream.Close()
+
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				mu.Lock()
+				fmt.Println(prefix + " " + scanner.Text())
+				mu.Unlock()
+			}
+		}(pod)
+	}
+	wg.Wait()
+}
+
+// podLogOptions returns the log options for pod. Without a container the
+// first container of the pod is read, the API requires one for pods with
+// several containers.
+func podLogOptions(pod corev1.Pod, opts corev1.PodLogOptions) corev1.PodLogOptions {
+	if opts.Container == "" && len(pod.Spec.Containers) > 0 {
+		opts.Container = pod.Spec.Containers[0].Name
+	}
+	return opts
+}
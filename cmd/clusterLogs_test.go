@@ -0,0 +1,90 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func podsNamed(names ...string) []corev1.Pod {
+	pods := make([]corev1.Pod, 0, len(names))
+	for _, name := range names {
+		pods = append(pods, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return pods
+}
+
+func podNames(pods []corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestParseablePods(t *testing.T) {
+	pods := podsNamed("parseable-querier-0", "parseable-ingestor-0", "parseable-ingestor-1", "parseable-fluent-bit-x2x9k", "other-querier-0")
+	got := podNames(parseablePods(pods, "parseable"))
+	if len(got) != 3 || got[0] != "parseable-querier-0" || got[2] != "parseable-ingestor-1" {
+		t.Errorf("expected the querier and ingestor pods, actual %v", got)
+	}
+
+	// playground releases run a single standalone pod
+	pods = podsNamed("parseable-6d8f7c9b5-abcde", "other-0")
+	got = podNames(parseablePods(pods, "parseable"))
+	if len(got) != 1 || got[0] != "parseable-6d8f7c9b5-abcde" {
+		t.Errorf("expected the standalone pod, actual %v", got)
+	}
+}
+
+func TestStreamPodLogsDefaultsToFirstContainer(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "parseable-querier-0", Namespace: "parseable"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "parseable"},
+			{Name: "fluent-bit"},
+		}},
+	}
+
+	containers := func(opts corev1.PodLogOptions) []string {
+		clientset := fake.NewSimpleClientset(&pod)
+		captureStdout(t, func() {
+			streamPodLogs(context.Background(), clientset, []corev1.Pod{pod}, opts)
+		})
+		var requested []string
+		for _, action := range clientset.Actions() {
+			if action.GetSubresource() != "log" {
+				continue
+			}
+			logOpts := action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+			requested = append(requested, logOpts.Container)
+		}
+		return requested
+	}
+
+	if got := containers(corev1.PodLogOptions{}); len(got) != 1 || got[0] != "parseable" {
+		t.Errorf("expected the logs of the first container, got %v", got)
+	}
+	if got := containers(corev1.PodLogOptions{Container: "fluent-bit"}); len(got) != 1 || got[0] != "fluent-bit" {
+		t.Errorf("expected the logs of the chosen container, got %v", got)
+	}
+}
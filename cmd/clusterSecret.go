@@ -0,0 +1,132 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"pb/pkg/common"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterSecretName is the secret the installer stores the server
+// configuration and credentials in
+const clusterSecretName = "parseable-env-secret"
+
+var revealSecret bool
+
+// ShowSecretCmd prints the values of the secret of a Parseable cluster
+var ShowSecretCmd = &cobra.Command{
+	Use:     "show-secret",
+	Short:   "Show the secret values of a Parseable server",
+	Example: "  pb cluster show-secret\n  pb cluster show-secret --reveal",
+	Args:    cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		_, err := common.PromptK8sContext()
+		if err != nil {
+			return fmt.Errorf("failed to prompt for Kubernetes context: %w", err)
+		}
+
+		// Read the installer data from the ConfigMap
+		entries, err := common.ReadInstallerConfigMap()
+		if err != nil {
+			return fmt.Errorf("failed to list servers: %w", err)
+		}
+
+		// Check if there are no entries
+		if len(entries) == 0 {
+			fmt.Println("No clusters found.")
+			return nil
+		}
+
+		// Prompt user to select a cluster
+		selectedCluster, err := common.PromptClusterSelection(entries)
+		if err != nil {
+			return fmt.Errorf("failed to select a cluster: %w", err)
+		}
+
+		config, err := common.LoadKubeConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+
+		values, err := readClusterSecret(context.TODO(), clientset, selectedCluster.Namespace)
+		if err != nil {
+			return err
+		}
+		printClusterSecret(os.Stdout, values, revealSecret)
+		if !revealSecret && len(values) > 0 {
+			fmt.Println("\nValues are hidden, pass --reveal to print them.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	ShowSecretCmd.Flags().BoolVar(&revealSecret, "reveal", false, "Print the secret values instead of hiding them")
+}
+
+// readClusterSecret returns the values of the cluster secret in namespace.
+// The API returns them base64 decoded already.
+func readClusterSecret(ctx context.Context, clientset kubernetes.Interface, namespace string) (map[string]string, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, clusterSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret '%s' in namespace '%s': %w", clusterSecretName, namespace, err)
+	}
+
+	values := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		values[key] = string(value)
+	}
+	for key, value := range secret.StringData {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// printClusterSecret prints the values sorted by key, masked unless reveal
+// is set
+func printClusterSecret(out io.Writer, values map[string]string, reveal bool) {
+	if len(values) == 0 {
+		fmt.Fprintf(out, "Secret '%s' has no values.\n", clusterSecretName)
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := maskedSecret
+		if reveal {
+			value = values[key]
+		}
+		fmt.Fprintf(out, "%s: %s\n", key, value)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClusterSecret(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterSecretName, Namespace: "parseable"},
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("s3cr3t"),
+		},
+	})
+
+	values, err := readClusterSecret(context.Background(), clientset, "parseable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	printClusterSecret(&out, values, false)
+	if want := "password: " + maskedSecret + "\nusername: " + maskedSecret + "\n"; out.String() != want {
+		t.Errorf("expected values to be hidden, got %q", out.String())
+	}
+
+	out.Reset()
+	printClusterSecret(&out, values, true)
+	if want := "password: s3cr3t\nusername: admin\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	if _, err := readClusterSecret(context.Background(), clientset, "other"); err == nil {
+		t.Error("expected an error for a namespace without the secret")
+	}
+}
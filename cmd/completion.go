@@ -0,0 +1,171 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// streamCacheTTL is how long stream names fetched for completion are reused.
+// Every <TAB> runs a new pb process, so the cache lives on disk.
+const streamCacheTTL = 30 * time.Second
+
+func init() {
+	StatStreamCmd.ValidArgsFunction = completeStreamNames
+	RemoveStreamCmd.ValidArgsFunction = completeStreamNames
+	SampleStreamCmd.ValidArgsFunction = completeStreamNames
+	PartitionInfoStreamCmd.ValidArgsFunction = completeStreamNames
+	GetRetentionCmd.ValidArgsFunction = completeStreamNames
+	SetRetentionCmd.ValidArgsFunction = completeStreamNames
+	ClearRetentionCmd.ValidArgsFunction = completeStreamNames
+	RemoveProfileCmd.ValidArgsFunction = completeProfileNames
+	DefaultProfileCmd.ValidArgsFunction = completeProfileNames
+}
+
+// completeProfileNames suggests the profiles from the config file
+func completeProfileNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	conf, err := config.ReadConfigFromFile()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(conf.Profiles))
+	for name := range conf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStreamNames suggests the streams on the server of the default profile
+func completeStreamNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// completion does not run the pre-run hooks, so load the profile here
+	if err := PreRun(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cachePath := streamCachePath(&DefaultProfile)
+	if names, ok := readStreamCache(cachePath); ok {
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	client := internalHTTP.DefaultClient(&DefaultProfile)
+	names, err := fetchStreamNames(&client)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	writeStreamCache(cachePath, names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fetchStreamNames returns the names of all streams on the server
+func fetchStreamNames(client *internalHTTP.HTTPClient) ([]string, error) {
+	req, err := client.NewRequest(http.MethodGet, "logstream", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status %s", resp.Status)
+	}
+
+	var streams []StreamListItem
+	if err := json.Unmarshal(bytes, &streams); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(streams))
+	for idx, stream := range streams {
+		names[idx] = stream.Name
+	}
+	return names, nil
+}
+
+// streamCachePath returns a cache file unique to the profile's server and user
+func streamCachePath(profile *config.Profile) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	key := sha256.Sum256([]byte(profile.URL + "\x00" + profile.Username))
+	return filepath.Join(cacheDir, "parseable", fmt.Sprintf("streams-%x.json", key[:8]))
+}
+
+func readStreamCache(path string) ([]string, bool) {
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > streamCacheTTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, false
+	}
+	return names, true
+}
+
+// writeStreamCache saves the names on a best effort basis, completion still
+// works without the cache
+func writeStreamCache(path string, names []string) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
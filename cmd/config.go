@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"pb/pkg/common"
+	"pb/pkg/config"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+const maskedSecret = "********"
+
+// ConfigPathCmd prints the location of the config file
+var ConfigPathCmd = &cobra.Command{
+	Use:     "path",
+	Short:   "Print the path of the config file",
+	Example: "  pb config path",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.Annotations = make(map[string]string)
+
+		filePath, err := config.Path()
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		fmt.Println(filePath)
+		return nil
+	},
+}
+
+// ConfigShowCmd prints the parsed config with secrets masked
+var ConfigShowCmd = &cobra.Command{
+	Use:     "show",
+	Short:   "Print the current config with passwords masked",
+	Example: "  pb config show\n  pb config show --output json",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		conf, err := config.ReadConfigFromFile()
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		masked := maskConfig(conf)
+
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(masked, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		tomlData, err := toml.Marshal(masked)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		fmt.Print(string(tomlData))
+		return nil
+	},
+}
+
+// maskConfig returns a copy of the config with profile passwords and header
+// values masked
+func maskConfig(conf *config.Config) config.Config {
+	masked := *conf
+	masked.Profiles = make(map[string]config.Profile, len(conf.Profiles))
+	for name, profile := range conf.Profiles {
+		if profile.Password != "" {
+			profile.Password = maskedSecret
+		}
+		// headers often carry API keys
+		if len(profile.Headers) > 0 {
+			headers := make(map[string]string, len(profile.Headers))
+			for name := range profile.Headers {
+				headers[name] = maskedSecret
+			}
+			profile.Headers = headers
+		}
+		masked.Profiles[name] = profile
+	}
+	return masked
+}
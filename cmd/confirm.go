@@ -0,0 +1,59 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"pb/pkg/common"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const yesFlag = "yes"
+
+// Prompts used by confirmDeletion, replaced in tests
+var (
+	promptConfirmation      = common.PromptConfirmation
+	promptTypedConfirmation = common.PromptTypedConfirmation
+	stdinIsTerminal         = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+)
+
+// addYesFlag adds the --yes flag that skips the confirmation of a destructive command
+func addYesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP(yesFlag, "y", false, "Skip the confirmation prompt")
+}
+
+// confirmDeletion asks the user to confirm deleting the named resource and
+// reports whether to go ahead. With typeName the name has to be typed out.
+// --yes skips the prompt; without it and without a terminal to prompt on the
+// deletion is refused, so scripts never delete by accident.
+func confirmDeletion(cmd *cobra.Command, kind, name string, typeName bool) (bool, error) {
+	if yes, _ := cmd.Flags().GetBool(yesFlag); yes {
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, fmt.Errorf("refusing to delete %s %s without confirmation, pass --%s to skip it", kind, name, yesFlag)
+	}
+
+	message := fmt.Sprintf("Delete %s %s?", kind, name)
+	if typeName {
+		return promptTypedConfirmation(message+" This can't be undone.", name), nil
+	}
+	return promptConfirmation(message), nil
+}
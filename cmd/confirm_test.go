@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+// stubConfirmation answers every prompt with answer and records the
+// expected text of typed confirmations
+func stubConfirmation(t *testing.T, terminal, answer bool) *string {
+	t.Helper()
+	var typed string
+	previousPrompt, previousTyped, previousTerminal := promptConfirmation, promptTypedConfirmation, stdinIsTerminal
+	promptConfirmation = func(string) bool { return answer }
+	promptTypedConfirmation = func(_, expected string) bool {
+		typed = expected
+		return answer
+	}
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() {
+		promptConfirmation, promptTypedConfirmation, stdinIsTerminal = previousPrompt, previousTyped, previousTerminal
+	})
+	return &typed
+}
+
+// useDeleteServer points the default profile at a server counting DELETE requests
+func useDeleteServer(t *testing.T) *int {
+	t.Helper()
+	deletes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes++
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	t.Cleanup(func() { DefaultProfile = previous })
+	return &deletes
+}
+
+func TestRemoveStreamRequiresTypedName(t *testing.T) {
+	deletes := useDeleteServer(t)
+	typed := stubConfirmation(t, true, false)
+
+	if err := RemoveStreamCmd.RunE(RemoveStreamCmd, []string{"backend"}); err != nil {
+		t.Fatalf("aborted removal returned an error: %v", err)
+	}
+	if *deletes != 0 {
+		t.Errorf("stream was deleted without confirmation")
+	}
+	if *typed != "backend" {
+		t.Errorf("expected the stream name to be typed, prompt expected %q", *typed)
+	}
+
+	stubConfirmation(t, true, true)
+	if err := RemoveStreamCmd.RunE(RemoveStreamCmd, []string{"backend"}); err != nil {
+		t.Fatalf("remove stream: %v", err)
+	}
+	if *deletes != 1 {
+		t.Errorf("expected the confirmed stream to be deleted, got %d deletes", *deletes)
+	}
+}
+
+func TestRemoveWithoutTerminalNeedsYes(t *testing.T) {
+	deletes := useDeleteServer(t)
+	stubConfirmation(t, false, true)
+
+	if err := RemoveUserCmd.RunE(RemoveUserCmd, []string{"bob"}); err == nil {
+		t.Errorf("expected removal without a terminal to be refused")
+	}
+	if *deletes != 0 {
+		t.Errorf("user was deleted without confirmation")
+	}
+
+	setFlags(t, RemoveRoleCmd, map[string]string{yesFlag: "true"})
+	if err := RemoveRoleCmd.RunE(RemoveRoleCmd, []string{"ingestor"}); err != nil {
+		t.Fatalf("remove role with --yes: %v", err)
+	}
+	if *deletes != 1 {
+		t.Errorf("expected --yes to skip the prompt, got %d deletes", *deletes)
+	}
+}
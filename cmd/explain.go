@@ -0,0 +1,343 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// ExplainQueryCmd checks a query against the schema of its stream without running it
+var ExplainQueryCmd = &cobra.Command{
+	Use:     "explain [query]",
+	Example: "  pb query explain \"select host, status from backend where status = 500\"",
+	Short:   "Validate a SQL query against the stream schema",
+	Long: `
+Validate a SQL query without running it. The stream in the FROM clause must
+exist and every column referenced by the query must be part of its schema.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: PreRunDefaultProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		stream, columns, err := parseQueryReferences(args[0])
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		streams, err := fetchStreamNames(&client)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		if !containsString(streams, stream) {
+			err := fmt.Errorf("stream %s not found", stream)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		fields, err := fetchSchemaFields(&client, stream)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		var problems []string
+		for _, column := range columns {
+			if !containsFold(fields, column) {
+				problems = append(problems, fmt.Sprintf("column %s not found in stream %s", column, stream))
+			}
+		}
+		if len(problems) > 0 {
+			err := errors.New(strings.Join(problems, "\n"))
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		fmt.Printf("Query is valid for stream %s\n", StyleBold.Render(stream))
+		if len(columns) > 0 {
+			fmt.Printf("Columns: %s\n", strings.Join(columns, ", "))
+		}
+		return nil
+	},
+}
+
+// fetchSchemaFields returns the field names of the stream schema
+func fetchSchemaFields(client *internalHTTP.HTTPClient, stream string) ([]string, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("logstream/%s/schema", stream), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch schema of stream %s: %s", stream, resp.Status)
+	}
+
+	var schema struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(bytes, &schema); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(schema.Fields))
+	for idx, field := range schema.Fields {
+		names[idx] = field.Name
+	}
+	return names, nil
+}
+
+// isExtractFrom reports whether the FROM at idx is part of
+// extract(hour from p_timestamp), which reads a column rather than a stream
+func isExtractFrom(tokens []sqlToken, idx int) bool {
+	return idx > 2 && tokens[idx-2].text == "(" && strings.EqualFold(tokens[idx-3].text, "extract")
+}
+
+// containsFold reports whether values holds value, ignoring case as SQL does
+// for unquoted names
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlKeywords are words that never refer to a column
+var sqlKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "and": true, "or": true, "not": true,
+	"as": true, "group": true, "by": true, "order": true, "limit": true, "offset": true,
+	"asc": true, "desc": true, "having": true, "distinct": true, "in": true, "is": true,
+	"null": true, "like": true, "ilike": true, "between": true, "case": true, "when": true,
+	"then": true, "else": true, "end": true, "true": true, "false": true, "join": true,
+	"inner": true, "left": true, "right": true, "full": true, "outer": true, "cross": true,
+	"on": true, "using": true, "interval": true, "union": true, "all": true, "with": true,
+	"over": true, "partition": true, "exists": true, "nulls": true, "first": true, "last": true,
+}
+
+// sqlToken is a word of a SQL statement
+type sqlToken struct {
+	text   string
+	quoted bool // double quoted identifier
+	ident  bool // identifier or keyword, as opposed to literals and punctuation
+	// start and end are the rune offsets of the token in the statement,
+	// including quotes
+	start, end int
+}
+
+// tokenizeSQL splits a statement into identifiers, literals and punctuation.
+// Unquoted identifiers may contain hyphens so stream names like app-logs are
+// kept whole.
+func tokenizeSQL(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			j := i + 1
+			var text strings.Builder
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					// a doubled quote is an escaped quote
+					if j+1 < len(runes) && runes[j+1] == r {
+						text.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				text.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote starting at position %d", i+1)
+			}
+			tokens = append(tokens, sqlToken{text: text.String(), quoted: r == '"', ident: r == '"', start: i, end: j + 1})
+			i = j + 1
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '-') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[i:j]), ident: true, start: i, end: j})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[i:j]), start: i, end: j})
+			i = j
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			tokens = append(tokens, sqlToken{text: "::", start: i, end: i + 2})
+			i += 2
+		default:
+			tokens = append(tokens, sqlToken{text: string(r), start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// parseQueryReferences returns the stream in the FROM clause and the columns
+// referenced by the query. Function names, aliases, type names and keywords
+// are not reported as columns.
+func parseQueryReferences(query string) (stream string, columns []string, err error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the FROM clause names the stream and optionally an alias for it
+	streamIdx, err := streamTokenIndex(tokens)
+	if err != nil {
+		return "", nil, err
+	}
+	stream = tokens[streamIdx].text
+
+	// names after FROM, here and in subqueries, are streams with an optional
+	// alias rather than columns
+	skip := map[int]bool{}
+	aliases := map[string]bool{}
+	for idx, token := range tokens {
+		if !token.ident || token.quoted || !strings.EqualFold(token.text, "from") || idx+1 >= len(tokens) || !tokens[idx+1].ident {
+			continue
+		}
+		if isExtractFrom(tokens, idx) {
+			continue
+		}
+		skip[idx+1] = true
+		aliases[strings.ToLower(tokens[idx+1].text)] = true
+		if next := idx + 2; next < len(tokens) && tokens[next].ident && !sqlKeywords[strings.ToLower(tokens[next].text)] {
+			aliases[strings.ToLower(tokens[next].text)] = true
+			skip[next] = true
+		}
+	}
+
+	for idx, token := range tokens {
+		if !token.ident || skip[idx] {
+			continue
+		}
+		if !token.quoted && sqlKeywords[strings.ToLower(token.text)] {
+			continue
+		}
+		if idx > 0 {
+			prev := tokens[idx-1]
+			// aliases and cast target types
+			if (prev.ident && !prev.quoted && strings.EqualFold(prev.text, "as")) || prev.text == "::" {
+				aliases[strings.ToLower(token.text)] = true
+				continue
+			}
+			// aliases of subqueries and expressions without AS
+			if prev.text == ")" && !prev.ident {
+				aliases[strings.ToLower(token.text)] = true
+				continue
+			}
+			// the date part of extract(hour from p_timestamp)
+			if prev.text == "(" && idx > 1 && strings.EqualFold(tokens[idx-2].text, "extract") {
+				continue
+			}
+		}
+		if idx+1 < len(tokens) {
+			next := tokens[idx+1]
+			// function calls
+			if next.text == "(" && !token.quoted {
+				continue
+			}
+			// table qualifiers such as backend.status
+			if next.text == "." {
+				continue
+			}
+		}
+		if !containsFold(columns, token.text) {
+			columns = append(columns, token.text)
+		}
+	}
+
+	// drop references to aliases defined in the select list
+	filtered := columns[:0]
+	for _, column := range columns {
+		if !aliases[strings.ToLower(column)] {
+			filtered = append(filtered, column)
+		}
+	}
+	return stream, filtered, nil
+}
+
+// streamTokenIndex returns the index of the stream named by the FROM clause.
+// FROM also appears inside function calls such as extract(hour from ...) and
+// in subqueries, so the outermost FROM followed by a name is taken.
+func streamTokenIndex(tokens []sqlToken) (int, error) {
+	streamIdx, streamDepth := -1, 0
+	depth := 0
+	for idx, token := range tokens {
+		switch {
+		case token.text == "(" && !token.ident:
+			depth++
+		case token.text == ")" && !token.ident:
+			depth--
+		case token.ident && !token.quoted && strings.EqualFold(token.text, "from"):
+			if idx+1 < len(tokens) && tokens[idx+1].ident && !isExtractFrom(tokens, idx) && (streamIdx == -1 || depth < streamDepth) {
+				streamIdx, streamDepth = idx+1, depth
+			}
+		}
+	}
+	if streamIdx == -1 {
+		return -1, errors.New("query has no FROM clause naming a stream")
+	}
+	return streamIdx, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []sqlToken
+	}{
+		{
+			query: `select status from app-logs`,
+			want: []sqlToken{
+				{text: "select", ident: true}, {text: "status", ident: true},
+				{text: "from", ident: true}, {text: "app-logs", ident: true},
+			},
+		},
+		{
+			query: `select "Host Name" from backend where method = 'it''s'`,
+			want: []sqlToken{
+				{text: "select", ident: true}, {text: "Host Name", quoted: true, ident: true},
+				{text: "from", ident: true}, {text: "backend", ident: true},
+				{text: "where", ident: true}, {text: "method", ident: true},
+				{text: "="}, {text: "it's"},
+			},
+		},
+		{
+			query: `select status::int, count(*) from backend`,
+			want: []sqlToken{
+				{text: "select", ident: true}, {text: "status", ident: true}, {text: "::"},
+				{text: "int", ident: true}, {text: ","}, {text: "count", ident: true},
+				{text: "("}, {text: "*"}, {text: ")"},
+				{text: "from", ident: true}, {text: "backend", ident: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := tokenizeSQL(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		// positions are checked by the stream substitution tests
+		for idx := range got {
+			got[idx].start, got[idx].end = 0, 0
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.query, got, test.want)
+		}
+	}
+
+	if _, err := tokenizeSQL(`select * from "backend`); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+}
+
+func TestParseQueryReferences(t *testing.T) {
+	tests := []struct {
+		query   string
+		stream  string
+		columns []string
+	}{
+		{
+			query:   `select host, status from backend where status = 500`,
+			stream:  "backend",
+			columns: []string{"host", "status"},
+		},
+		{
+			query:   `select extract(hour from p_timestamp) as h, count(*) from backend group by h`,
+			stream:  "backend",
+			columns: []string{"p_timestamp"},
+		},
+		{
+			query:   `select * from (select host from backend where status = 500) t`,
+			stream:  "backend",
+			columns: []string{"host", "status"},
+		},
+		{
+			query:   `select host from backend where host in (select host from blocked)`,
+			stream:  "backend",
+			columns: []string{"host"},
+		},
+		{
+			query:   `select "Host Name" from "app-logs" a where a.status = 500`,
+			stream:  "app-logs",
+			columns: []string{"Host Name", "status"},
+		},
+		{
+			query:   `select Status, status as S from backend order by s`,
+			stream:  "backend",
+			columns: []string{"Status"},
+		},
+	}
+	for _, test := range tests {
+		stream, columns, err := parseQueryReferences(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		if stream != test.stream || !reflect.DeepEqual(columns, test.columns) {
+			t.Errorf("%s: got stream %q columns %q, want %q %q", test.query, stream, columns, test.stream, test.columns)
+		}
+	}
+
+	for _, query := range []string{`select 1`, `select extract(hour from p_timestamp)`} {
+		if _, _, err := parseQueryReferences(query); err == nil {
+			t.Errorf("%s: expected an error for a query without a stream", query)
+		}
+	}
+}
+
+func TestContainsFold(t *testing.T) {
+	if !containsFold([]string{"p_timestamp", "Host"}, "host") {
+		t.Error("expected column names to match ignoring case")
+	}
+}
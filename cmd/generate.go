@@ -26,6 +26,7 @@ import (
 	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
@@ -47,6 +48,10 @@ var GenerateSchemaCmd = &cobra.Command{
 		if filePath == "" {
 			return fmt.Errorf(common.Red + "file flag is required" + common.Reset)
 		}
+		filePath, err = common.ExpandPath(filePath)
+		if err != nil {
+			return fmt.Errorf(common.Red+"%w"+common.Reset, err)
+		}
 
 		// Read the file content
 		fileContent, err := os.ReadFile(filePath)
@@ -120,52 +125,86 @@ var CreateSchemaCmd = &cobra.Command{
 		if filePath == "" {
 			return fmt.Errorf(common.Red + "file path flag is required" + common.Reset)
 		}
-
-		// Read the JSON schema file
-		schemaContent, err := os.ReadFile(filePath)
+		filePath, err = common.ExpandPath(filePath)
 		if err != nil {
-			return fmt.Errorf(common.Red+"failed to read schema file %s: %w"+common.Reset, filePath, err)
+			return fmt.Errorf(common.Red+"%w"+common.Reset, err)
 		}
 
 		// Initialize HTTP client
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 
-		// Construct the API path
-		apiPath := fmt.Sprintf("/logstream/%s", streamName)
-
-		// Create the HTTP PUT request
-		req, err := client.NewRequest(http.MethodPut, apiPath, bytes.NewBuffer(schemaContent))
+		respBody, err := uploadSchema(&client, streamName, filePath, os.Stderr)
 		if err != nil {
-			return fmt.Errorf(common.Red+"failed to create new request: %w"+common.Reset, err)
+			return fmt.Errorf(common.Red+"failed to create schema: %w"+common.Reset, err)
 		}
 
-		// Set custom headers
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-P-Static-Schema-Flag", "true")
+		fmt.Println(common.Green + string(respBody) + common.Reset)
+		return nil
+	},
+}
 
-		// Execute the request
-		resp, err := client.Client.Do(req)
+// largeSchemaSize is the size from which schema uploads report progress
+const largeSchemaSize = 1 << 20
+
+// uploadSchema creates the stream with the static schema in filePath. The
+// file is streamed rather than read into memory and reopened for every retry;
+// creating a stream with a schema is safe to repeat. Progress of large files
+// is written to progress.
+func uploadSchema(client *internalHTTP.HTTPClient, streamName, filePath string, progress io.Writer) ([]byte, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file %s: %w", filePath, err)
+	}
+
+	var file *os.File
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	return client.DoWithRetry(internalHTTP.DefaultRetryPolicy, func() (*http.Request, error) {
+		if file != nil {
+			file.Close()
+		}
+		file, err = os.Open(filePath)
 		if err != nil {
-			return fmt.Errorf(common.Red+"request execution failed: %w"+common.Reset, err)
+			return nil, fmt.Errorf("failed to read schema file %s: %w", filePath, err)
 		}
-		defer resp.Body.Close()
 
-		// Check for non-200 status codes
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			fmt.Printf(common.Red+"Error response: %s\n"+common.Reset, string(body))
-			return fmt.Errorf(common.Red+"non-200 status code received: %s"+common.Reset, resp.Status)
+		var body io.Reader = file
+		if info.Size() >= largeSchemaSize {
+			body = &progressReader{reader: file, total: info.Size(), out: progress}
 		}
 
-		// Parse and print the response
-		respBody, err := io.ReadAll(resp.Body)
+		req, err := client.NewRequest(http.MethodPut, fmt.Sprintf("/logstream/%s", streamName), body)
 		if err != nil {
-			return fmt.Errorf(common.Red+"failed to read response body: %w"+common.Reset, err)
+			return nil, fmt.Errorf("failed to create new request: %w", err)
 		}
+		req.ContentLength = info.Size()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-P-Static-Schema-Flag", "true")
+		return req, nil
+	})
+}
 
-		fmt.Println(common.Green + string(respBody) + common.Reset)
-		return nil
-	},
+// progressReader reports how much of a body was sent, every 10 percent
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	read     int64
+	reported int64
+	out      io.Writer
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if percent := r.read * 100 / r.total; percent/10 > r.reported/10 {
+		r.reported = percent
+		fmt.Fprintf(r.out, "Uploaded %d%% (%s of %s)\n", percent, humanize.Bytes(uint64(r.read)), humanize.Bytes(uint64(r.total)))
+	}
+	return n, err
 }
 
 func init() {
@@ -0,0 +1,76 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestUploadSchemaRetriesWithTheWholeFile(t *testing.T) {
+	// a schema large enough to report progress
+	schema := []byte(`{"fields":[` + strings.Repeat(`{"name":"field","data_type":"Utf8"},`, 40000) + `{"name":"last","data_type":"Utf8"}]}`)
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, schema, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var received [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, body)
+		if r.Header.Get("X-P-Static-Schema-Flag") != "true" {
+			http.Error(w, "missing schema flag", http.StatusBadRequest)
+			return
+		}
+		if len(received) == 1 {
+			http.Error(w, "upstream reset", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("stream created"))
+	}))
+	defer server.Close()
+
+	previous := internalHTTP.DefaultRetryPolicy
+	internalHTTP.DefaultRetryPolicy = internalHTTP.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	defer func() { internalHTTP.DefaultRetryPolicy = previous }()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	var progress bytes.Buffer
+	body, err := uploadSchema(&client, "backend", path, &progress)
+	if err != nil {
+		t.Fatalf("uploadSchema: %v", err)
+	}
+	if string(body) != "stream created" {
+		t.Errorf("unexpected response %q", body)
+	}
+	if len(received) != 2 || !bytes.Equal(received[1], schema) {
+		t.Fatalf("expected the whole schema to be sent again on retry, got %d requests", len(received))
+	}
+	if !strings.Contains(progress.String(), "Uploaded 100%") {
+		t.Errorf("expected progress to be reported, got %q", progress.String())
+	}
+}
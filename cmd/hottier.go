@@ -0,0 +1,249 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// StreamHotTier is the hot tier configuration and usage of a stream
+type StreamHotTier struct {
+	Size          hotTierSize `json:"size"`
+	UsedSize      hotTierSize `json:"used_size,omitempty"`
+	AvailableSize hotTierSize `json:"available_size,omitempty"`
+	OldestEntry   string      `json:"oldest_date_time_entry,omitempty"`
+}
+
+// hotTierSize is a size sent by the server either as a number of bytes or as
+// a human readable string
+type hotTierSize string
+
+func (s *hotTierSize) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case string:
+		*s = hotTierSize(value)
+	case float64:
+		*s = hotTierSize(fmt.Sprintf("%.0f", value))
+	case nil:
+		*s = ""
+	default:
+		return fmt.Errorf("invalid size %s", data)
+	}
+	return nil
+}
+
+// String renders the size in binary units when it can be parsed
+func (s hotTierSize) String() string {
+	size, err := parseSize(string(s))
+	if err != nil {
+		return string(s)
+	}
+	return humanize.IBytes(size)
+}
+
+// SetHotTierCmd sets the hot tier size of a stream
+var SetHotTierCmd = &cobra.Command{
+	Use:     "set stream-name",
+	Short:   "Set the hot tier size of a stream",
+	Example: "  pb stream hottier set backend --size 10GiB",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		sizeFlag, _ := cmd.Flags().GetString("size")
+		size, err := parseSize(sizeFlag)
+		if err == nil && size == 0 {
+			err = errors.New("the hot tier size must be greater than zero")
+		}
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := setHotTier(&client, args[0], size); err != nil {
+			err = fmt.Errorf("failed to set hot tier of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Set hot tier of stream %s to %s\n", StyleBold.Render(args[0]), humanize.IBytes(size))
+		return nil
+	},
+}
+
+// GetHotTierCmd shows the hot tier configuration and usage of a stream
+var GetHotTierCmd = &cobra.Command{
+	Use:     "get stream-name",
+	Short:   "Show the hot tier size and usage of a stream",
+	Example: "  pb stream hottier get backend\n  pb stream hottier get backend --output json",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		hotTier, err := fetchHotTier(&client, args[0])
+		if err != nil {
+			err = fmt.Errorf("failed to fetch hot tier of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(hotTier, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		printHotTier(hotTier)
+		return nil
+	},
+}
+
+// RemoveHotTierCmd disables the hot tier of a stream
+var RemoveHotTierCmd = &cobra.Command{
+	Use:     "remove stream-name",
+	Aliases: []string{"rm"},
+	Short:   "Remove the hot tier of a stream",
+	Example: "  pb stream hottier remove backend",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		req, err := client.NewRequest(http.MethodDelete, fmt.Sprintf("logstream/%s/hottier", args[0]), nil)
+		if err == nil {
+			_, err = client.Do(req)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to remove hot tier of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Removed hot tier of stream %s\n", StyleBold.Render(args[0]))
+		return nil
+	},
+}
+
+func init() {
+	SetHotTierCmd.Flags().String("size", "", "Size of the hot tier, e.g. 10GiB or 20GB")
+	_ = SetHotTierCmd.MarkFlagRequired("size")
+}
+
+func setHotTier(client *internalHTTP.HTTPClient, name string, size uint64) error {
+	payload, err := json.Marshal(map[string]string{"size": fmt.Sprintf("%d B", size)})
+	if err != nil {
+		return err
+	}
+	req, err := client.NewRequest(http.MethodPut, fmt.Sprintf("logstream/%s/hottier", name), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(req)
+	return err
+}
+
+func fetchHotTier(client *internalHTTP.HTTPClient, name string) (data StreamHotTier, err error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("logstream/%s/hottier", name), nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		err = json.Unmarshal(bytes, &data)
+	} else {
+		err = internalHTTP.NewAPIError(resp, bytes)
+	}
+	return
+}
+
+// fetchHotTierIfSet returns nil when the stream has no hot tier, which the
+// server reports with 400, or the server doesn't support one (404). Other
+// errors, such as missing permissions, are returned.
+func fetchHotTierIfSet(client *internalHTTP.HTTPClient, name string) (*StreamHotTier, error) {
+	hotTier, err := fetchHotTier(client, name)
+	var apiErr *internalHTTP.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hotTier, nil
+}
+
+func printHotTier(hotTier StreamHotTier) {
+	fmt.Printf("  %-18s %s\n", "Size:", hotTier.Size)
+	if hotTier.UsedSize != "" {
+		fmt.Printf("  %-18s %s\n", "Used:", hotTier.UsedSize)
+	}
+	if hotTier.AvailableSize != "" {
+		fmt.Printf("  %-18s %s\n", "Available:", hotTier.AvailableSize)
+	}
+	if hotTier.OldestEntry != "" {
+		fmt.Printf("  %-18s %s\n", "Oldest Entry:", hotTier.OldestEntry)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestFetchHotTierIfSet(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"size":"10 GiB","used_size":"1 GiB","available_size":"9 GiB"}`))
+	}))
+	defer server.Close()
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+
+	hotTier, err := fetchHotTierIfSet(&client, "backend")
+	if err != nil || hotTier == nil || hotTier.Size != "10 GiB" {
+		t.Fatalf("expected the hot tier, got %+v (%v)", hotTier, err)
+	}
+
+	for _, status = range []int{http.StatusBadRequest, http.StatusNotFound} {
+		if hotTier, err := fetchHotTierIfSet(&client, "backend"); err != nil || hotTier != nil {
+			t.Errorf("%d: expected no hot tier and no error, got %+v (%v)", status, hotTier, err)
+		}
+	}
+
+	for _, status = range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError} {
+		if _, err := fetchHotTierIfSet(&client, "backend"); err == nil {
+			t.Errorf("%d: expected the error to be returned", status)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultIngestBatchSize = 500
+
+// IngestCmd sends events from a file or stdin to a stream
+var IngestCmd = &cobra.Command{
+	Use:     "ingest [file]",
+	Example: "  pb ingest events.json --stream backend_logs\n  cat events.ndjson | pb ingest --stream backend_logs --batch-size 100",
+	Short:   "Send events to a stream",
+	Long: `
+Send events to a stream. Events are read from the given file, or from stdin when
+no file (or "-") is given, either as newline delimited JSON objects or as a
+single JSON array of objects.`,
+	Args:    cobra.MaximumNArgs(1),
+	PreRunE: PreRunDefaultProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Capture start time
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		stream, _ := cmd.Flags().GetString("stream")
+		if stream == "" {
+			err := errors.New("--stream is required")
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		if batchSize <= 0 {
+			err := fmt.Errorf("invalid batch size %d", batchSize)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		input := io.Reader(os.Stdin)
+		if len(args) == 1 && args[0] != "-" {
+			path, err := common.ExpandPath(args[0])
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		sent, failed, err := ingestEvents(&client, stream, input, batchSize)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Ingested %d events into stream %s\n", sent, StyleBold.Render(stream))
+		if failed > 0 {
+			err := fmt.Errorf("%d events failed to ingest", failed)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	IngestCmd.Flags().StringP("stream", "s", "", "Name of the stream to send events to")
+	IngestCmd.Flags().Int("batch-size", defaultIngestBatchSize, "Number of events sent per request")
+}
+
+// ingestEvents reads events from input and posts them to the stream in
+// batches. Failed batches are reported and counted, but do not stop the
+// remaining events from being sent.
+func ingestEvents(client *internalHTTP.HTTPClient, stream string, input io.Reader, batchSize int) (sent, failed int, err error) {
+	reader := bufio.NewReader(input)
+	decoder := json.NewDecoder(reader)
+
+	// A leading '[' means the input is a single JSON array
+	isArray, err := startsWithArray(reader)
+	if err != nil {
+		return 0, 0, err
+	}
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return 0, 0, fmt.Errorf("failed to read JSON array: %w", err)
+		}
+	}
+
+	batch := make([]json.RawMessage, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := postEvents(client, stream, batch); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to ingest %d events: %s\n", len(batch), err)
+			failed += len(batch)
+		} else {
+			sent += len(batch)
+		}
+		batch = batch[:0]
+	}
+
+	for decoder.More() {
+		var event json.RawMessage
+		if err := decoder.Decode(&event); err != nil {
+			flush()
+			return sent, failed, fmt.Errorf("invalid JSON after %d events: %w", sent+failed+len(batch), err)
+		}
+		batch = append(batch, event)
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return sent, failed, nil
+}
+
+// startsWithArray reports whether the first non-whitespace byte is '['
+func startsWithArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			_, _ = reader.ReadByte()
+			continue
+		}
+		return b[0] == '[', nil
+	}
+}
+
+func postEvents(client *internalHTTP.HTTPClient, stream string, events []json.RawMessage) error {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := client.NewRequest(http.MethodPost, "ingest", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-P-Stream", stream)
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
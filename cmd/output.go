@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"pb/pkg/config"
+	"pb/pkg/log"
+)
+
+// Output formats accepted by --output
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+	outputTable  = "table"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputNDJSON, outputCSV, outputTable}
+
+// normalizeOutput returns the canonical name of an output format. An empty
+// value is text.
+func normalizeOutput(value string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(value))
+	if format == "" {
+		return outputText, nil
+	}
+	if !slices.Contains(outputFormats, format) {
+		return "", fmt.Errorf("invalid output format %q, must be one of: %s", value, strings.Join(outputFormats, ", "))
+	}
+	return format, nil
+}
+
+// OutputFlag is the value of the global --output flag
+type OutputFlag struct {
+	format string
+	set    bool
+}
+
+func (o *OutputFlag) String() string {
+	return o.format
+}
+
+func (o *OutputFlag) Set(value string) error {
+	format, err := normalizeOutput(value)
+	if err != nil {
+		return err
+	}
+	o.format, o.set = format, true
+	return nil
+}
+
+func (o *OutputFlag) Type() string {
+	return "format"
+}
+
+// Output is the format chosen with --output, it overrides the output
+// setting of the config file
+var Output OutputFlag
+
+// chosenOutput returns the format chosen with --output or in the config
+// file, and false when neither chose one
+func chosenOutput() (string, bool) {
+	if Output.set {
+		return Output.format, true
+	}
+	conf, err := config.ReadConfigFromFile()
+	if err != nil || conf.Output == "" {
+		return "", false
+	}
+	format, err := normalizeOutput(conf.Output)
+	if err != nil {
+		log.Warn("ignoring output in config file", "error", err)
+		return "", false
+	}
+	return format, true
+}
+
+// resolveOutput returns the format a command that can print the supported
+// formats prints in, text unless another one was chosen. Asking for a format
+// the command can't print with --output is an error, while an unsupported
+// default from the config file falls back to text so it doesn't break every
+// other command.
+func resolveOutput(supported ...string) (string, error) {
+	format, chosen := chosenOutput()
+	if !chosen {
+		return outputText, nil
+	}
+	if slices.Contains(supported, format) {
+		return format, nil
+	}
+	if Output.set {
+		return "", fmt.Errorf("this command does not support --output %s, use one of: %s", format, strings.Join(supported, ", "))
+	}
+	return outputText, nil
+}
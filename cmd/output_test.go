@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+
+	"pb/pkg/config"
+)
+
+// useOutput sets --output for the duration of the test
+func useOutput(t *testing.T, format string) {
+	t.Helper()
+	previous := Output
+	if err := Output.Set(format); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Output = previous })
+}
+
+func TestOutputFlagNormalizes(t *testing.T) {
+	var flag OutputFlag
+	for value, want := range map[string]string{"JSON": outputJSON, " table ": outputTable, "": outputText, "csv": outputCSV} {
+		if err := flag.Set(value); err != nil {
+			t.Errorf("Set(%q) failed: %v", value, err)
+			continue
+		}
+		if flag.String() != want {
+			t.Errorf("Set(%q) = %q, want %q", value, flag.String(), want)
+		}
+	}
+	if err := flag.Set("yaml"); err == nil {
+		t.Error("expected yaml to be rejected")
+	}
+}
+
+func TestResolveOutput(t *testing.T) {
+	useConfigDir(t)
+
+	if got, err := resolveOutput(outputText, outputJSON); err != nil || got != outputText {
+		t.Errorf("without a choice got %q, %v, want text", got, err)
+	}
+
+	if err := config.WriteConfigToFile(&config.Config{Output: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := resolveOutput(outputText, outputJSON); got != outputJSON {
+		t.Errorf("config default not used, got %q", got)
+	}
+	// a default the command can't print falls back to text
+	if err := config.WriteConfigToFile(&config.Config{Output: "table"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := resolveOutput(outputText, outputJSON); err != nil || got != outputText {
+		t.Errorf("unsupported config default got %q, %v, want text", got, err)
+	}
+
+	useOutput(t, outputJSON)
+	if got, _ := resolveOutput(outputText, outputJSON, outputTable); got != outputJSON {
+		t.Errorf("--output does not override the config, got %q", got)
+	}
+	if _, err := resolveOutput(outputText, outputTable); err == nil {
+		t.Error("expected an unsupported --output to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+const defaultPager = "less"
+
+// pagerCommand returns the pager from $PAGER, falling back to less
+func pagerCommand() []string {
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 {
+		return pager
+	}
+	return []string{defaultPager}
+}
+
+// withPager runs write against the pager's stdin when enabled is set and
+// stdout is a terminal, and against stdout otherwise. If the pager cannot be
+// started the output goes to stdout. Quitting the pager before all output is
+// written is not an error.
+func withPager(enabled bool, write func(io.Writer) error) error {
+	if !enabled || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return write(os.Stdout)
+	}
+
+	args := pagerCommand()
+	pager := exec.Command(args[0], args[1:]...)
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	// let less exit on short output and keep colors, unless the user set LESS
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		pager.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	stdin, err := pager.StdinPipe()
+	if err != nil {
+		return write(os.Stdout)
+	}
+	if err := pager.Start(); err != nil {
+		return write(os.Stdout)
+	}
+
+	err = write(stdin)
+	stdin.Close()
+	// the pager's own exit status does not matter once it had the output
+	_ = pager.Wait()
+
+	if err != nil && !isBrokenPipe(err) {
+		return err
+	}
+	return nil
+}
+
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed)
+}
@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"pb/pkg/config"
 
@@ -26,6 +28,98 @@ import (
 
 var DefaultProfile config.Profile
 
+// DefaultProfileName is the name of the stored profile in DefaultProfile. It
+// is empty when the connection is given entirely by flags or environment.
+var DefaultProfileName string
+
+// JSONPretty is the configured default for indenting JSON output
+var JSONPretty = true
+
+// Connection settings given by the global --server, --username and
+// --password flags. They take precedence over the PB_URL, PB_USERNAME and
+// PB_PASSWORD environment variables, which take precedence over the stored
+// default profile.
+var (
+	ServerFlag   string
+	UsernameFlag string
+	PasswordFlag string
+)
+
+// EndpointFlag is set by the global --endpoint flag. It replaces the URL of
+// the resolved profile for a single command, e.g. to reach one ingestor or
+// querier of a distributed deployment, and keeps its credentials.
+var EndpointFlag string
+
+const (
+	serverEnv   = "PB_URL"
+	usernameEnv = "PB_USERNAME"
+	passwordEnv = "PB_PASSWORD"
+)
+
+// profileOverride returns the connection settings given by flags or
+// environment variables. Fields that are not given are left empty.
+func profileOverride() config.Profile {
+	pick := func(flag, env string) string {
+		if flag != "" {
+			return flag
+		}
+		return os.Getenv(env)
+	}
+	return config.Profile{
+		URL:      pick(ServerFlag, serverEnv),
+		Username: pick(UsernameFlag, usernameEnv),
+		Password: pick(PasswordFlag, passwordEnv),
+	}
+}
+
+// HasProfileOverride reports whether server, username and password are all
+// given by flags or environment variables. Commands then use an ephemeral
+// profile and never read or write the config file.
+func HasProfileOverride() bool {
+	override := profileOverride()
+	return override.URL != "" && override.Username != "" && override.Password != ""
+}
+
+// applyProfileOverride replaces the fields of profile given by override
+func applyProfileOverride(profile *config.Profile, override config.Profile) error {
+	if override.URL != "" {
+		serverURL, err := parseServerURL(override.URL)
+		if err != nil {
+			return err
+		}
+		profile.URL = serverURL
+	}
+	if override.Username != "" {
+		profile.Username = override.Username
+	}
+	if override.Password != "" {
+		profile.Password = override.Password
+	}
+	return nil
+}
+
+// parseServerURL validates the URL of a server given on the command line
+func parseServerURL(value string) (string, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", value)
+	}
+	return parsed.String(), nil
+}
+
+// applyEndpoint points profile at --endpoint when it is given
+func applyEndpoint(profile *config.Profile) error {
+	if EndpointFlag == "" {
+		return nil
+	}
+	endpoint, err := parseServerURL(EndpointFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --endpoint: %w", err)
+	}
+	profile.URL = endpoint
+	return nil
+}
+
 // PreRunDefaultProfile if a profile exists.
 // This is required by mostly all commands except profile
 func PreRunDefaultProfile(_ *cobra.Command, _ []string) error {
@@ -33,6 +127,16 @@ func PreRunDefaultProfile(_ *cobra.Command, _ []string) error {
 }
 
 func PreRun() error {
+	override := profileOverride()
+	if HasProfileOverride() {
+		DefaultProfile = config.Profile{}
+		DefaultProfileName = ""
+		if err := applyProfileOverride(&DefaultProfile, override); err != nil {
+			return err
+		}
+		return applyEndpoint(&DefaultProfile)
+	}
+
 	conf, err := config.ReadConfigFromFile()
 	if os.IsNotExist(err) {
 		return errors.New("no config found to run this command. add a profile using pb profile command")
@@ -45,5 +149,12 @@ func PreRun() error {
 	}
 
 	DefaultProfile = conf.Profiles[conf.DefaultProfile]
-	return nil
+	DefaultProfileName = conf.DefaultProfile
+	if conf.JSONPretty != nil {
+		JSONPretty = *conf.JSONPretty
+	}
+	if err := applyProfileOverride(&DefaultProfile, override); err != nil {
+		return err
+	}
+	return applyEndpoint(&DefaultProfile)
 }
@@ -0,0 +1,155 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+// setOverrideFlags sets the global connection flags for the duration of a test
+func setOverrideFlags(t *testing.T, server, username, password string) {
+	t.Helper()
+	ServerFlag, UsernameFlag, PasswordFlag = server, username, password
+	t.Cleanup(func() {
+		ServerFlag, UsernameFlag, PasswordFlag = "", "", ""
+	})
+}
+
+// useConfigDir points the config file at an empty directory
+func useConfigDir(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(serverEnv, "")
+	t.Setenv(usernameEnv, "")
+	t.Setenv(passwordEnv, "")
+}
+
+func TestPreRunWithoutConfigUsesOverride(t *testing.T) {
+	useConfigDir(t)
+	setOverrideFlags(t, "https://ci.example.com:8000", "ci", "secret")
+
+	if err := PreRun(); err != nil {
+		t.Fatalf("PreRun failed: %s", err)
+	}
+	want := config.Profile{URL: "https://ci.example.com:8000", Username: "ci", Password: "secret"}
+	if !reflect.DeepEqual(DefaultProfile, want) {
+		t.Errorf("got profile %+v, want %+v", DefaultProfile, want)
+	}
+
+	path, _ := config.Path()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config file was created at %s", path)
+	}
+}
+
+func TestPreRunEnvironment(t *testing.T) {
+	useConfigDir(t)
+	t.Setenv(serverEnv, "http://env.example.com")
+	t.Setenv(usernameEnv, "env-user")
+	t.Setenv(passwordEnv, "env-pass")
+	// flags take precedence over the environment
+	setOverrideFlags(t, "", "flag-user", "")
+
+	if err := PreRun(); err != nil {
+		t.Fatalf("PreRun failed: %s", err)
+	}
+	want := config.Profile{URL: "http://env.example.com", Username: "flag-user", Password: "env-pass"}
+	if !reflect.DeepEqual(DefaultProfile, want) {
+		t.Errorf("got profile %+v, want %+v", DefaultProfile, want)
+	}
+}
+
+func TestPreRunPartialOverride(t *testing.T) {
+	useConfigDir(t)
+	stored := config.Profile{URL: "http://stored.example.com", Username: "admin", Password: "admin"}
+	if err := config.WriteConfigToFile(&config.Config{
+		Profiles:       map[string]config.Profile{"local": stored},
+		DefaultProfile: "local",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	setOverrideFlags(t, "", "", "rotated")
+
+	if err := PreRun(); err != nil {
+		t.Fatalf("PreRun failed: %s", err)
+	}
+	want := stored
+	want.Password = "rotated"
+	if !reflect.DeepEqual(DefaultProfile, want) {
+		t.Errorf("got profile %+v, want %+v", DefaultProfile, want)
+	}
+}
+
+func TestPreRunPartialOverrideNeedsConfig(t *testing.T) {
+	useConfigDir(t)
+	setOverrideFlags(t, "http://ci.example.com", "ci", "")
+
+	if err := PreRun(); err == nil {
+		t.Errorf("expected an error without a password or a stored profile")
+	}
+}
+
+func TestPreRunInvalidServer(t *testing.T) {
+	useConfigDir(t)
+	setOverrideFlags(t, "ci.example.com", "ci", "secret")
+
+	if err := PreRun(); err == nil {
+		t.Errorf("expected an error for a server URL without a scheme")
+	}
+}
+
+func TestPreRunEndpointKeepsCredentials(t *testing.T) {
+	useConfigDir(t)
+	stored := config.Profile{URL: "http://parseable.example.com", Username: "admin", Password: "admin"}
+	if err := config.WriteConfigToFile(&config.Config{
+		Profiles:       map[string]config.Profile{"local": stored},
+		DefaultProfile: "local",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	EndpointFlag = "http://ingestor-0.parseable.svc:8000"
+	defer func() { EndpointFlag = "" }()
+
+	if err := PreRun(); err != nil {
+		t.Fatalf("PreRun failed: %s", err)
+	}
+	want := stored
+	want.URL = "http://ingestor-0.parseable.svc:8000"
+	if !reflect.DeepEqual(DefaultProfile, want) {
+		t.Errorf("got profile %+v, want %+v", DefaultProfile, want)
+	}
+
+	conf, err := config.ReadConfigFromFile()
+	if err != nil || conf.Profiles["local"].URL != stored.URL {
+		t.Errorf("--endpoint must not be persisted, stored profile is %+v (%v)", conf.Profiles["local"], err)
+	}
+}
+
+func TestPreRunInvalidEndpoint(t *testing.T) {
+	useConfigDir(t)
+	setOverrideFlags(t, "https://ci.example.com:8000", "ci", "secret")
+	EndpointFlag = "ingestor-0:8000"
+	defer func() { EndpointFlag = "" }()
+
+	if err := PreRun(); err == nil {
+		t.Errorf("expected an error for an endpoint without a scheme")
+	}
+}
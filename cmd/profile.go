@@ -17,9 +17,9 @@ package cmd
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
+	"pb/pkg/common"
 	"pb/pkg/config"
 	"pb/pkg/model/credential"
 	"pb/pkg/model/defaultprofile"
@@ -53,19 +53,14 @@ func (item *ProfileListItem) Render(highlight bool) string {
 	return ItemOuter.Render(render)
 }
 
-// Add an output flag to specify the output format.
-var outputFormat string
-
 // Initialize flags
 func init() {
-	AddProfileCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
-	RemoveProfileCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
-	DefaultProfileCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
-	ListProfileCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
+	AddProfileCmd.Flags().Bool("insecure", false, "Skip TLS certificate verification for this profile")
+	AddProfileCmd.Flags().String("ca-cert", "", "Path to a PEM encoded CA certificate used to verify the server")
 }
 
-func outputResult(v interface{}) error {
-	if outputFormat == "json" {
+func outputResult(output string, v interface{}) error {
+	if output == outputJSON {
 		jsonData, err := json.MarshalIndent(v, "", "  ")
 		if err != nil {
 			return err
@@ -93,14 +88,18 @@ var AddProfileCmd = &cobra.Command{
 			cmd.Annotations = make(map[string]string)
 		}
 		startTime := time.Now()
-		var commandError error
+		output, commandError := resolveOutput(outputText, outputJSON)
+		if commandError != nil {
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
+			return commandError
+		}
 
 		// Parsing input and handling errors
 		name := args[0]
 		url, err := url.Parse(args[1])
 		if err != nil {
 			commandError = fmt.Errorf("error parsing URL: %s", err)
-			cmd.Annotations["error"] = commandError.Error()
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 			return commandError
 		}
 
@@ -109,7 +108,7 @@ var AddProfileCmd = &cobra.Command{
 			_m, err := tea.NewProgram(credential.New()).Run()
 			if err != nil {
 				commandError = fmt.Errorf("error reading credentials: %s", err)
-				cmd.Annotations["error"] = commandError.Error()
+				cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 				return commandError
 			}
 			m := _m.(credential.Model)
@@ -120,15 +119,13 @@ var AddProfileCmd = &cobra.Command{
 		}
 
 		profile := config.Profile{URL: url.String(), Username: username, Password: password}
-		fileConfig, err := config.ReadConfigFromFile()
-		if err != nil {
-			newConfig := config.Config{
-				Profiles:       map[string]config.Profile{name: profile},
-				DefaultProfile: name,
-			}
-			err = config.WriteConfigToFile(&newConfig)
-			commandError = err
-		} else {
+		profile.Insecure, _ = cmd.Flags().GetBool("insecure")
+		caCert, _ := cmd.Flags().GetString("ca-cert")
+		if profile.CACertPath, commandError = common.ExpandPath(caCert); commandError != nil {
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
+			return commandError
+		}
+		commandError = config.UpdateConfig(func(fileConfig *config.Config) error {
 			if fileConfig.Profiles == nil {
 				fileConfig.Profiles = make(map[string]config.Profile)
 			}
@@ -136,17 +133,17 @@ var AddProfileCmd = &cobra.Command{
 			if fileConfig.DefaultProfile == "" {
 				fileConfig.DefaultProfile = name
 			}
-			commandError = config.WriteConfigToFile(fileConfig)
-		}
+			return nil
+		})
 
 		cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		if commandError != nil {
-			cmd.Annotations["error"] = commandError.Error()
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 			return commandError
 		}
 
-		if outputFormat == "json" {
-			return outputResult(profile)
+		if output == outputJSON {
+			return outputResult(output, profile)
 		}
 		fmt.Printf("Profile %s added successfully\n", name)
 		return nil
@@ -164,36 +161,38 @@ var RemoveProfileCmd = &cobra.Command{
 			cmd.Annotations = make(map[string]string)
 		}
 		startTime := time.Now()
-
-		name := args[0]
-		fileConfig, err := config.ReadConfigFromFile()
+		output, err := resolveOutput(outputText, outputJSON)
 		if err != nil {
-			cmd.Annotations["error"] = fmt.Sprintf("error reading config: %s", err)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		_, exists := fileConfig.Profiles[name]
-		if !exists {
+		name := args[0]
+		exists := true
+		commandError := config.UpdateConfig(func(fileConfig *config.Config) error {
+			if _, exists = fileConfig.Profiles[name]; !exists {
+				return nil
+			}
+			delete(fileConfig.Profiles, name)
+			if len(fileConfig.Profiles) == 0 {
+				fileConfig.DefaultProfile = ""
+			}
+			return nil
+		})
+		cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		if commandError == nil && !exists {
 			msg := fmt.Sprintf("No profile found with the name: %s", name)
-			cmd.Annotations["error"] = msg
+			cmd.Annotations[common.ErrorAnnotation] = msg
 			fmt.Println(msg)
 			return nil
 		}
-
-		delete(fileConfig.Profiles, name)
-		if len(fileConfig.Profiles) == 0 {
-			fileConfig.DefaultProfile = ""
-		}
-
-		commandError := config.WriteConfigToFile(fileConfig)
-		cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		if commandError != nil {
-			cmd.Annotations["error"] = commandError.Error()
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 			return commandError
 		}
 
-		if outputFormat == "json" {
-			return outputResult(fmt.Sprintf("Deleted profile %s", name))
+		if output == outputJSON {
+			return outputResult(output, fmt.Sprintf("Deleted profile %s", name))
 		}
 		fmt.Printf("Deleted profile %s\n", name)
 		return nil
@@ -210,10 +209,15 @@ var DefaultProfileCmd = &cobra.Command{
 			cmd.Annotations = make(map[string]string)
 		}
 		startTime := time.Now()
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
 
 		fileConfig, err := config.ReadConfigFromFile()
 		if err != nil {
-			cmd.Annotations["error"] = fmt.Sprintf("error reading config: %s", err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("error reading config: %s", err)
 			return err
 		}
 
@@ -224,7 +228,7 @@ var DefaultProfileCmd = &cobra.Command{
 			model := defaultprofile.New(fileConfig.Profiles)
 			_m, err := tea.NewProgram(model).Run()
 			if err != nil {
-				cmd.Annotations["error"] = fmt.Sprintf("error selecting default profile: %s", err)
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("error selecting default profile: %s", err)
 				return err
 			}
 			m := _m.(defaultprofile.Model)
@@ -234,23 +238,21 @@ var DefaultProfileCmd = &cobra.Command{
 			name = m.Choice
 		}
 
-		_, exists := fileConfig.Profiles[name]
-		if !exists {
-			commandError := fmt.Sprintf("profile %s does not exist", name)
-			cmd.Annotations["error"] = commandError
-			return errors.New(commandError)
-		}
-
-		fileConfig.DefaultProfile = name
-		commandError := config.WriteConfigToFile(fileConfig)
+		commandError := config.UpdateConfig(func(fileConfig *config.Config) error {
+			if _, exists := fileConfig.Profiles[name]; !exists {
+				return fmt.Errorf("profile %s does not exist", name)
+			}
+			fileConfig.DefaultProfile = name
+			return nil
+		})
 		cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		if commandError != nil {
-			cmd.Annotations["error"] = commandError.Error()
+			cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 			return commandError
 		}
 
-		if outputFormat == "json" {
-			return outputResult(fmt.Sprintf("%s is now set as default profile", name))
+		if output == outputJSON {
+			return outputResult(output, fmt.Sprintf("%s is now set as default profile", name))
 		}
 		fmt.Printf("%s is now set as default profile\n", name)
 		return nil
@@ -266,18 +268,23 @@ var ListProfileCmd = &cobra.Command{
 			cmd.Annotations = make(map[string]string)
 		}
 		startTime := time.Now()
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
 
 		fileConfig, err := config.ReadConfigFromFile()
 		if err != nil {
-			cmd.Annotations["error"] = fmt.Sprintf("error reading config: %s", err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("error reading config: %s", err)
 			return err
 		}
 
-		if outputFormat == "json" {
-			commandError := outputResult(fileConfig.Profiles)
+		if output == outputJSON {
+			commandError := outputResult(output, fileConfig.Profiles)
 			cmd.Annotations["executionTime"] = time.Since(startTime).String()
 			if commandError != nil {
-				cmd.Annotations["error"] = commandError.Error()
+				cmd.Annotations[common.ErrorAnnotation] = commandError.Error()
 				return commandError
 			}
 			return nil
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"pb/pkg/analytics"
+	"pb/pkg/common"
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// profileTestTimeout bounds every request of profile test so an unreachable
+// server fails fast
+const profileTestTimeout = 10 * time.Second
+
+var TestProfileCmd = &cobra.Command{
+	Use:     "test [profile-name]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Check that a profile can reach and authenticate to its server",
+	Example: "  pb profile test\n  pb profile test local_parseable -o json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		startTime := time.Now()
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		profile := DefaultProfile
+		if len(args) > 0 {
+			fileConfig, err := config.ReadConfigFromFile()
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("error reading config: %s", err)
+				return err
+			}
+			var exists bool
+			if profile, exists = fileConfig.Profiles[args[0]]; !exists {
+				err := fmt.Errorf("profile %s does not exist", args[0])
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
+		}
+
+		err = printCheckResults(testProfile(&profile), output)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+		}
+		return err
+	},
+}
+
+// testProfile checks that the server of the profile is reachable and that
+// its credentials are accepted. The authentication check is skipped when the
+// server can't be reached.
+func testProfile(profile *config.Profile) []CheckResult {
+	client := internalHTTP.DefaultClient(profile)
+	client.Client.Timeout = profileTestTimeout
+
+	reachability := CheckResult{Name: "reachability"}
+	status, body, latency, err := timedGet(&client, "about")
+	reachability.LatencyMS = latency.Milliseconds()
+	switch {
+	case err != nil:
+		reachability.Status = checkFail
+		reachability.Detail = err.Error()
+	case status == http.StatusOK:
+		reachability.Status = checkPass
+		reachability.Detail = profile.URL
+		var about analytics.About
+		if json.Unmarshal(body, &about) == nil && about.Version != "" {
+			reachability.Detail = fmt.Sprintf("%s, Parseable %s", profile.URL, about.Version)
+		}
+	default:
+		// any HTTP response means the server is up, credentials are checked next
+		reachability.Status = checkPass
+		reachability.Detail = fmt.Sprintf("%s responded with %d", profile.URL, status)
+	}
+
+	authentication := CheckResult{Name: "authentication"}
+	if reachability.Status != checkPass {
+		authentication.Status = checkFail
+		authentication.Detail = "skipped, server is unreachable"
+		return []CheckResult{reachability, authentication}
+	}
+
+	status, body, latency, err = timedGet(&client, "logstream")
+	authentication.LatencyMS = latency.Milliseconds()
+	authentication.Status = checkFail
+	switch {
+	case err != nil:
+		authentication.Detail = err.Error()
+	case status == http.StatusOK:
+		authentication.Status = checkPass
+		var streams []StreamListItem
+		_ = json.Unmarshal(body, &streams)
+		authentication.Detail = fmt.Sprintf("user %s can list %d streams", profile.Username, len(streams))
+	case status == http.StatusUnauthorized:
+		authentication.Detail = fmt.Sprintf("credentials of user %s were rejected", profile.Username)
+	case status == http.StatusForbidden:
+		authentication.Detail = fmt.Sprintf("user %s is not allowed to list streams", profile.Username)
+	default:
+		authentication.Detail = fmt.Sprintf("listing streams failed with status %d", status)
+	}
+	return []CheckResult{reachability, authentication}
+}
+
+// timedGet sends a GET request to path and returns the status code and body
+// of the response along with how long the request took
+func timedGet(client *internalHTTP.HTTPClient, path string) (int, []byte, time.Duration, error) {
+	req, err := client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	start := time.Now()
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return 0, nil, time.Since(start), err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, time.Since(start), err
+}
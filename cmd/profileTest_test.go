@@ -0,0 +1,63 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestTestProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/about":
+			_, _ = w.Write([]byte(`{"version":"v1.7.0"}`))
+		case "/api/v1/logstream":
+			if username, password, _ := r.BasicAuth(); username != "admin" || password != "admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"name":"backend"},{"name":"frontend"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	results := testProfile(&config.Profile{URL: server.URL, Username: "admin", Password: "admin"})
+	if len(results) != 2 || results[0].Status != checkPass || results[1].Status != checkPass {
+		t.Fatalf("expected both checks to pass, actual %+v", results)
+	}
+	if !strings.Contains(results[0].Detail, "v1.7.0") || !strings.Contains(results[1].Detail, "2 streams") {
+		t.Errorf("unexpected details %+v", results)
+	}
+
+	results = testProfile(&config.Profile{URL: server.URL, Username: "admin", Password: "wrong"})
+	if results[0].Status != checkPass || results[1].Status != checkFail {
+		t.Errorf("expected only authentication to fail, actual %+v", results)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	results = testProfile(&config.Profile{URL: closed.URL, Username: "admin", Password: "admin"})
+	if results[0].Status != checkFail || results[1].Status != checkFail {
+		t.Errorf("expected both checks to fail, actual %+v", results)
+	}
+}
@@ -17,10 +17,13 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
@@ -28,7 +31,9 @@ import (
 
 	//! This dependency is required by the interactive flag Do not remove
 	// tea "github.com/charmbracelet/bubbletea"
+	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
+	"pb/pkg/timerange"
 
 	"github.com/spf13/cobra"
 )
@@ -42,14 +47,43 @@ var (
 	endFlagShort = "t"
 	defaultEnd   = "now"
 
-	outputFlag = "output"
+	compactFlag = "compact"
+
+	rawFlag = "raw"
+
+	pagerFlag = "pager"
 )
 
 var query = &cobra.Command{
 	Use:     "run [query] [flags]",
-	Example: "  pb query run \"select * from frontend\" --from=10m --to=now",
+	Example: "  pb query run \"select * from frontend\" --from=10m --to=now\n  pb query run \"select * from frontend\" --from=yesterday --to=yesterday\n  pb query run \"select count(*) from frontend\" --streams frontend,backend\n  pb query run \"select * from frontend where status = 500\" --from=1h --count-only\n  pb query run \"select status, count(*) from frontend group by status\" --from=5m --watch --interval 10s",
 	Short:   "Run SQL query on a log stream",
-	Long:    "\nRun SQL query on a log stream. Default output format is text. Use --output flag to set output format to json.",
+	Long: `
+Run SQL query on a log stream. Default output format is text. Use --output flag to set output format to json.
+With --output ndjson every record is printed as a compact JSON object on its own
+line, as it is decoded. Every record must have a p_timestamp.
+
+JSON output is indented unless --compact is passed. The default can be set for all
+invocations with json_pretty = false in the config file; the --compact flag always
+takes precedence over the config value.
+
+With --streams the query runs concurrently on every listed stream. JSON output
+nests the records under their stream name; text output prints one record per
+line with the source stream in the p_stream field.
+
+With --count-only only the number of matching rows is printed. The query may be
+a bare stream name to count all of its rows.
+
+With --raw the response body of the server is copied to stdout unchanged, the
+fastest way to pipe large results into other tools. --output, --compact,
+--time-format and --pager are ignored.
+
+With --watch the query runs again every --interval over the window from --from
+to --to, resolved anew on every tick, until Ctrl-C is pressed. Text output
+redraws the screen; JSON output prints one document per tick, wrapping the
+records with the time of the tick and the window queried.
+
+Every query is recorded in a local history, see pb query history.`,
 	Args:    cobra.MaximumNArgs(1),
 	PreRunE: PreRunDefaultProfile,
 	RunE: func(command *cobra.Command, args []string) error {
@@ -72,7 +106,7 @@ var query = &cobra.Command{
 		query := args[0]
 		start, err := command.Flags().GetString(startFlag)
 		if err != nil {
-			command.Annotations["error"] = err.Error()
+			command.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 		if start == "" {
@@ -81,59 +115,151 @@ var query = &cobra.Command{
 
 		end, err := command.Flags().GetString(endFlag)
 		if err != nil {
-			command.Annotations["error"] = err.Error()
+			command.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 		if end == "" {
 			end = defaultEnd
 		}
 
-		outputFormat, err := command.Flags().GetString("output")
-		if err != nil {
-			command.Annotations["error"] = err.Error()
-			return fmt.Errorf("failed to get 'output' flag: %w", err)
+		streams, _ := command.Flags().GetStringSlice(streamsFlag)
+		countOnly, _ := command.Flags().GetBool(countOnlyFlag)
+		if countOnly && len(streams) > 0 {
+			err := fmt.Errorf("--%s can't be combined with --%s", countOnlyFlag, streamsFlag)
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		watch, _ := command.Flags().GetBool(watchFlag)
+		if watch && (countOnly || len(streams) > 0) {
+			err := fmt.Errorf("--%s can't be combined with --%s or --%s", watchFlag, countOnlyFlag, streamsFlag)
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		raw, _ := command.Flags().GetBool(rawFlag)
+		if raw && (countOnly || len(streams) > 0 || watch) {
+			err := fmt.Errorf("--%s can't be combined with --%s, --%s or --%s", rawFlag, countOnlyFlag, streamsFlag, watchFlag)
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
 		}
 
+		recordQuery(HistoryEntry{
+			Time:      time.Now().UTC(),
+			Profile:   DefaultProfileName,
+			Query:     query,
+			From:      start,
+			To:        end,
+			Streams:   streams,
+			CountOnly: countOnly,
+		})
+
+		// --watch resolves the window again on every tick
+		from, to := start, end
+
+		// named windows like "yesterday" and times without a zone are read in
+		// the --tz zone and become absolute UTC boundaries
+		now := time.Now()
+		start = timerange.ResolveIn(start, now, false, TimeZone.Location())
+		end = timerange.ResolveIn(end, now, true, TimeZone.Location())
+
 		client := internalHTTP.DefaultClient(&DefaultProfile)
-		err = fetchData(&client, query, start, end, outputFormat)
+
+		if raw {
+			err = fetchRaw(&client, query, start, end, os.Stdout)
+			if err != nil {
+				command.Annotations[common.ErrorAnnotation] = err.Error()
+			}
+			return err
+		}
+
+		outputFormat, err := resolveOutput(outputText, outputJSON, outputNDJSON)
 		if err != nil {
-			command.Annotations["error"] = err.Error()
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		if watch && outputFormat == outputNDJSON {
+			err := fmt.Errorf("--%s can't be combined with --output %s", watchFlag, outputNDJSON)
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		timeFormat, err := command.Flags().GetString(timeFormatFlag)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		timeFormat = outputTimeFormat(timeFormat)
+
+		// flag overrides the config default
+		pretty := JSONPretty
+		if command.Flags().Changed(compactFlag) {
+			compact, _ := command.Flags().GetBool(compactFlag)
+			pretty = !compact
+		}
+
+		usePager, _ := command.Flags().GetBool(pagerFlag)
+
+		if watch {
+			interval, _ := command.Flags().GetDuration(intervalFlag)
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			err = watchQuery(ctx, &client, query, from, to, interval, outputFormat, timeFormat, pretty)
+			if err != nil {
+				command.Annotations[common.ErrorAnnotation] = err.Error()
+			}
+			return err
+		}
+
+		if countOnly {
+			err = printCount(&client, query, start, end)
+			if err != nil {
+				command.Annotations[common.ErrorAnnotation] = err.Error()
+			}
+			return err
+		}
+
+		if len(streams) > 0 {
+			concurrency, _ := command.Flags().GetInt(concurrencyFlag)
+			err = runStreamsQuery(&client, query, streams, start, end, outputFormat, timeFormat, pretty, concurrency)
+			if err != nil {
+				command.Annotations[common.ErrorAnnotation] = err.Error()
+			}
+			return err
+		}
+
+		err = fetchData(&client, query, start, end, outputFormat, timeFormat, pretty, usePager)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
 		}
 		return err
 	},
 }
 
 func init() {
-	query.Flags().StringP(startFlag, startFlagShort, defaultStart, "Start time for query.")
-	query.Flags().StringP(endFlag, endFlagShort, defaultEnd, "End time for query.")
-	query.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
+	query.Flags().StringP(startFlag, startFlagShort, defaultStart, "Start time for query. Accepts a duration, RFC3339 time or today, yesterday, this-week, last-24h")
+	query.Flags().StringP(endFlag, endFlagShort, defaultEnd, "End time for query. Accepts now, RFC3339 time or today, yesterday, this-week, last-24h")
+	query.Flags().Bool(compactFlag, false, "Print JSON output without indentation (overrides json_pretty in config)")
+	query.Flags().Bool(pagerFlag, false, "Page text output through $PAGER (or less) when stdout is a terminal")
+	query.Flags().StringSlice(streamsFlag, nil, "Run the query on each of these streams, substituting the stream in the FROM clause")
+	query.Flags().Int(concurrencyFlag, defaultConcurrency, "Maximum number of streams queried at the same time with --streams")
+	query.Flags().Bool(countOnlyFlag, false, "Print only the number of rows matched by the query. The query may be just a stream name")
+	query.Flags().Bool(rawFlag, false, "Copy the response of the server to stdout byte for byte. Ignores --output, --compact, --time-format and --pager")
+	query.Flags().Bool(watchFlag, false, "Run the query again every --interval on a window sliding with the current time, until interrupted")
+	query.Flags().Duration(intervalFlag, defaultWatchInterval, "Refresh interval used by --watch")
+	query.Flags().String(timeFormatFlag, "", "Reformat timestamp fields using a Go time layout (e.g. '2006-01-02 15:04:05') or 'relative'")
 }
 
 var QueryCmd = query
 
-func fetchData(client *internalHTTP.HTTPClient, query string, startTime, endTime, outputFormat string) error {
-	queryTemplate := `{
-		"query": "%s",
-		"startTime": "%s",
-		"endTime": "%s"
-	}`
-	finalQuery := fmt.Sprintf(queryTemplate, query, startTime, endTime)
-
-	req, err := client.NewRequest("POST", "query", bytes.NewBuffer([]byte(finalQuery)))
-	if err != nil {
-		return fmt.Errorf("failed to create new request: %w", err)
-	}
-
-	resp, err := client.Client.Do(req)
+func fetchData(client *internalHTTP.HTTPClient, query string, startTime, endTime, outputFormat, timeFormat string, pretty, usePager bool) error {
+	resp, err := postQuery(client, query, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("request execution failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Println(string(body))
-		return fmt.Errorf("non-200 status code received: %s", resp.Status)
+		return internalHTTP.NewAPIError(resp, body)
 	}
 
 	if outputFormat == "json" {
@@ -141,14 +267,95 @@ func fetchData(client *internalHTTP.HTTPClient, query string, startTime, endTime
 		if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
 			return fmt.Errorf("error decoding JSON response: %w", err)
 		}
-		encodedResponse, _ := json.MarshalIndent(jsonResponse, "", "  ")
+		formatRecordTimestamps(jsonResponse, timeFormat)
+		var encodedResponse []byte
+		if pretty {
+			encodedResponse, _ = json.MarshalIndent(jsonResponse, "", "  ")
+		} else {
+			encodedResponse, _ = json.Marshal(jsonResponse)
+		}
+		fmt.Println(string(encodedResponse))
+	} else if outputFormat == outputNDJSON {
+		return withPager(usePager, func(out io.Writer) error {
+			return writeNDJSON(resp.Body, out, timeFormat)
+		})
+	} else if timeFormat != "" {
+		var jsonResponse []map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+			return fmt.Errorf("error decoding JSON response: %w", err)
+		}
+		formatRecordTimestamps(jsonResponse, timeFormat)
+		encodedResponse, _ := json.Marshal(jsonResponse)
 		fmt.Println(string(encodedResponse))
 	} else {
-		io.Copy(os.Stdout, resp.Body)
+		return withPager(usePager, func(out io.Writer) error {
+			_, err := io.Copy(out, resp.Body)
+			return err
+		})
 	}
 	return nil
 }
 
+// fetchRaw copies the response body of the query to out without decoding it
+func fetchRaw(client *internalHTTP.HTTPClient, query, startTime, endTime string, out io.Writer) error {
+	resp, err := postQuery(client, query, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return internalHTTP.NewAPIError(resp, body)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// postQuery sends the query to the server's query endpoint. The caller is
+// responsible for checking the status code and closing the response body.
+func postQuery(client *internalHTTP.HTTPClient, query, startTime, endTime string) (*http.Response, error) {
+	payload, err := json.Marshal(map[string]string{
+		"query":     query,
+		"startTime": startTime,
+		"endTime":   endTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	req, err := client.NewRequest("POST", "query", bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new request: %w", err)
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request execution failed: %w", err)
+	}
+	return resp, nil
+}
+
+// queryRecords runs the query and decodes the returned records
+func queryRecords(client *internalHTTP.HTTPClient, query, startTime, endTime string) ([]map[string]interface{}, error) {
+	resp, err := postQuery(client, query, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, internalHTTP.NewAPIError(resp, body)
+	}
+
+	var records []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return records, nil
+}
+
 // Returns start and end time for query in RFC3339 format
 // func parseTime(start, end string) (time.Time, time.Time, error) {
 // 	if start == defaultStart && end == defaultEnd {
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	internalHTTP "pb/pkg/http"
+)
+
+const countOnlyFlag = "count-only"
+
+// aggregateFunctions are functions that collapse rows, a query using them
+// already returns a summary that can't be counted meaningfully
+var aggregateFunctions = map[string]bool{
+	"count": true, "sum": true, "avg": true, "mean": true, "min": true, "max": true,
+	"median": true, "stddev": true, "stddev_pop": true, "stddev_samp": true,
+	"var": true, "var_pop": true, "var_samp": true, "array_agg": true, "string_agg": true,
+	"approx_distinct": true, "approx_median": true, "approx_percentile_cont": true,
+	"bit_and": true, "bit_or": true, "bool_and": true, "bool_or": true,
+}
+
+// countOnlyQuery returns a query counting the rows matched by query. A bare
+// stream name, quoted or not, counts every row of the stream; any other
+// query is wrapped in a subquery. Queries that already aggregate are rejected.
+func countOnlyQuery(query string) (string, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", err
+	}
+
+	if len(tokens) == 1 && tokens[0].ident {
+		return "select count(*) as count from " + quoteIdentifier(tokens[0].text), nil
+	}
+
+	for idx, token := range tokens {
+		if !token.ident || token.quoted {
+			continue
+		}
+		word := strings.ToLower(token.text)
+		if word == "group" && idx+1 < len(tokens) && strings.EqualFold(tokens[idx+1].text, "by") {
+			return "", errors.New("query already groups rows with GROUP BY, run it without --count-only")
+		}
+		if aggregateFunctions[word] && idx+1 < len(tokens) && tokens[idx+1].text == "(" {
+			return "", fmt.Errorf("query already aggregates with %s(), run it without --count-only", word)
+		}
+	}
+
+	if _, _, err := parseQueryReferences(query); err != nil {
+		return "", err
+	}
+	return "select count(*) as count from (" + query + ") as matched", nil
+}
+
+// printCount prints the number of rows matched by query on its own line
+func printCount(client *internalHTTP.HTTPClient, query, startTime, endTime string) error {
+	counting, err := countOnlyQuery(query)
+	if err != nil {
+		return err
+	}
+	count, err := countQuery(client, counting, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	fmt.Println(count)
+	return nil
+}
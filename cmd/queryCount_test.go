@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestCountOnlyQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"backend", `select count(*) as count from "backend"`},
+		{`"app-logs"`, `select count(*) as count from "app-logs"`},
+		{`"my ""quoted"" stream"`, `select count(*) as count from "my ""quoted"" stream"`},
+		{
+			"select * from backend where status = 500;",
+			"select count(*) as count from (select * from backend where status = 500) as matched",
+		},
+		{
+			"select host, count from backend",
+			"select count(*) as count from (select host, count from backend) as matched",
+		},
+	}
+	for _, test := range tests {
+		got, err := countOnlyQuery(test.query)
+		if err != nil {
+			t.Errorf("countOnlyQuery(%q) failed: %v", test.query, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("countOnlyQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestCountOnlyQueryRejectsAggregates(t *testing.T) {
+	for _, query := range []string{
+		"select count(*) from backend",
+		"select host, max(latency) from backend",
+		"select host from backend group by host",
+		"select * from",
+	} {
+		if _, err := countOnlyQuery(query); err == nil {
+			t.Errorf("expected countOnlyQuery(%q) to fail", query)
+		}
+	}
+}
+
+func TestPrintCount(t *testing.T) {
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		sent = payload["query"]
+		_, _ = w.Write([]byte(`[{"count": 42}]`))
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	out := captureStdout(t, func() {
+		if err := printCount(&client, "backend", "10m", "now"); err != nil {
+			t.Errorf("printCount: %v", err)
+		}
+	})
+	if string(out) != "42\n" {
+		t.Errorf("printed %q, want just the count", out)
+	}
+	if !strings.HasPrefix(sent, "select count(*) as count from") {
+		t.Errorf("unexpected query sent to the server: %q", sent)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+	"pb/pkg/log"
+	"pb/pkg/timerange"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	historyFilename = "history.jsonl"
+	historyEnv      = "PB_HISTORY"
+
+	// defaultHistorySize is the number of queries kept unless max_entries is
+	// set in the [history] config section
+	defaultHistorySize = 500
+
+	historyRunFlag = "run"
+)
+
+// HistoryEntry is one recorded invocation of pb query run. From and To are
+// kept as given, so relative windows like 10m are resolved again on replay.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile,omitempty"`
+	Query   string    `json:"query"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Streams []string  `json:"streams,omitempty"`
+	// CountOnly is set when the query ran with --count-only
+	CountOnly bool `json:"count_only,omitempty"`
+}
+
+var QueryHistoryCmd = &cobra.Command{
+	Use:     "history",
+	Short:   "List past queries and run them again",
+	Example: "  pb query history\n  pb query history --run 1\n  pb query history --run 3 --output json",
+	Long: `
+List the queries run with pb query run, most recent first. Use --run with the
+number shown in the list to run a query again over the same time window. The
+history is kept on this machine only.`,
+	Args:    cobra.NoArgs,
+	PreRunE: PreRunDefaultProfile,
+	RunE: func(command *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		command.Annotations = map[string]string{
+			"startTime": startTime.Format(time.RFC3339),
+		}
+		defer func() {
+			command.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		historyPath, err := config.StatePath(historyFilename)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		entries, err := readHistory(historyPath)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		outputFormat, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		if !command.Flags().Changed(historyRunFlag) {
+			err := printHistory(entries, outputFormat)
+			if err != nil {
+				command.Annotations[common.ErrorAnnotation] = err.Error()
+			}
+			return err
+		}
+
+		number, _ := command.Flags().GetInt(historyRunFlag)
+		entry, err := historyEntryAt(entries, number)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		err = replayQuery(entry, outputFormat)
+		if err != nil {
+			command.Annotations[common.ErrorAnnotation] = err.Error()
+		}
+		return err
+	},
+}
+
+func init() {
+	QueryHistoryCmd.Flags().Int(historyRunFlag, 0, "Run the query with this number in the list again")
+}
+
+// historyEnabled reports whether queries should be recorded. The PB_HISTORY
+// environment variable takes precedence over the [history] config section:
+// "disable" turns recording off and any other value turns it on.
+func historyEnabled(conf *config.Config) bool {
+	if env, ok := os.LookupEnv(historyEnv); ok && env != "" {
+		return env != "disable"
+	}
+	if conf == nil || conf.History == nil || conf.History.Enabled == nil {
+		return true
+	}
+	return *conf.History.Enabled
+}
+
+// historySize returns the configured number of queries to keep
+func historySize(conf *config.Config) int {
+	if conf == nil || conf.History == nil || conf.History.MaxEntries <= 0 {
+		return defaultHistorySize
+	}
+	return conf.History.MaxEntries
+}
+
+// recordQuery adds the entry to the query history unless it is disabled.
+// History is a convenience, so failures are logged and never fail the query.
+func recordQuery(entry HistoryEntry) {
+	conf, err := config.ReadConfigFromFile()
+	if err != nil {
+		conf = nil
+	}
+	if !historyEnabled(conf) {
+		return
+	}
+
+	historyPath, err := config.StatePath(historyFilename)
+	if err == nil {
+		err = appendHistory(historyPath, entry, historySize(conf))
+	}
+	if err != nil {
+		log.Debug("failed to record query history", "error", err)
+	}
+}
+
+// readHistory returns the entries in the history file, oldest first. A
+// missing file is an empty history and lines that can't be parsed are skipped.
+func readHistory(historyPath string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Query == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// appendHistory adds entry to the history file and drops the oldest entries
+// beyond limit. The file is replaced in one rename so a concurrent reader
+// never sees it half written.
+func appendHistory(historyPath string, entry HistoryEntry, limit int) error {
+	entries, err := readHistory(historyPath)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(path.Dir(historyPath), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := historyPath + ".tmp"
+	// queries can contain sensitive values, keep the file private
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, historyPath)
+}
+
+// historyEntryAt returns the entry with the given number in the list, where
+// 1 is the most recent query
+func historyEntryAt(entries []HistoryEntry, number int) (HistoryEntry, error) {
+	if len(entries) == 0 {
+		return HistoryEntry{}, errors.New("query history is empty")
+	}
+	if number < 1 || number > len(entries) {
+		return HistoryEntry{}, fmt.Errorf("no query with number %d, history has %d entries", number, len(entries))
+	}
+	return entries[len(entries)-number], nil
+}
+
+func printHistory(entries []HistoryEntry, outputFormat string) error {
+	// most recent first, matching the numbers used by --run
+	recent := make([]HistoryEntry, len(entries))
+	for idx, entry := range entries {
+		recent[len(entries)-1-idx] = entry
+	}
+
+	if outputFormat == "json" {
+		encoded, err := json.MarshalIndent(recent, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	if len(recent) == 0 {
+		fmt.Println("No queries in history")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"#", "Time", "Profile", "From", "To", "Query"})
+	table.SetAutoWrapText(false)
+	for idx, entry := range recent {
+		query := entry.Query
+		if len(entry.Streams) > 0 {
+			query += " (streams: " + strings.Join(entry.Streams, ", ") + ")"
+		}
+		if entry.CountOnly {
+			query += " (count only)"
+		}
+		table.Append([]string{
+			strconv.Itoa(idx + 1),
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Profile,
+			entry.From,
+			entry.To,
+			query,
+		})
+	}
+	table.Render()
+	return nil
+}
+
+// replayQuery runs the query of the entry again. The time window is resolved
+// relative to now and the recorded profile is used when it still exists,
+// unless the connection is given by flags or environment variables.
+func replayQuery(entry HistoryEntry, outputFormat string) error {
+	profile := DefaultProfile
+	if entry.Profile != "" && entry.Profile != DefaultProfileName && !HasProfileOverride() {
+		conf, err := config.ReadConfigFromFile()
+		if err != nil {
+			return err
+		}
+		stored, exists := conf.Profiles[entry.Profile]
+		if !exists {
+			return fmt.Errorf("profile %s used by this query does not exist anymore", entry.Profile)
+		}
+		profile = stored
+		if err := applyProfileOverride(&profile, profileOverride()); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	start := timerange.ResolveIn(entry.From, now, false, TimeZone.Location())
+	end := timerange.ResolveIn(entry.To, now, true, TimeZone.Location())
+
+	client := internalHTTP.DefaultClient(&profile)
+	if entry.CountOnly {
+		return printCount(&client, entry.Query, start, end)
+	}
+	if len(entry.Streams) > 0 {
+		return runStreamsQuery(&client, entry.Query, entry.Streams, start, end, outputFormat, outputTimeFormat(""), JSONPretty, defaultConcurrency)
+	}
+	return fetchData(&client, entry.Query, start, end, outputFormat, outputTimeFormat(""), JSONPretty, false)
+}
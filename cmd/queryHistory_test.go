@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+)
+
+func TestAppendHistoryCapsEntries(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "parseable", historyFilename)
+
+	for idx := 1; idx <= 5; idx++ {
+		entry := HistoryEntry{Time: time.Now(), Query: fmt.Sprintf("select %d", idx), From: "10m", To: "now"}
+		if err := appendHistory(historyPath, entry, 3); err != nil {
+			t.Fatalf("appendHistory: %v", err)
+		}
+	}
+
+	entries, err := readHistory(historyPath)
+	if err != nil {
+		t.Fatalf("readHistory: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Query != "select 3" || entries[2].Query != "select 5" {
+		t.Fatalf("expected the 3 most recent queries, got %+v", entries)
+	}
+
+	info, err := os.Stat(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("history file has mode %o, want 600", perm)
+	}
+}
+
+func TestReadHistorySkipsInvalidLines(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), historyFilename)
+	data := `{"query":"select 1","from":"1m","to":"now"}
+not json
+{"from":"1m","to":"now"}
+{"query":"select 2","from":"yesterday","to":"yesterday","profile":"local"}
+`
+	if err := os.WriteFile(historyPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readHistory(historyPath)
+	if err != nil {
+		t.Fatalf("readHistory: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Profile != "local" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	missing, err := readHistory(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("missing file should be an empty history, got %v, %v", missing, err)
+	}
+}
+
+func TestHistoryEntryAt(t *testing.T) {
+	entries := []HistoryEntry{{Query: "oldest"}, {Query: "middle"}, {Query: "newest"}}
+
+	entry, err := historyEntryAt(entries, 1)
+	if err != nil || entry.Query != "newest" {
+		t.Errorf("entry 1 = %q, %v; want the most recent query", entry.Query, err)
+	}
+	entry, err = historyEntryAt(entries, 3)
+	if err != nil || entry.Query != "oldest" {
+		t.Errorf("entry 3 = %q, %v; want the oldest query", entry.Query, err)
+	}
+	for _, number := range []int{0, 4, -1} {
+		if _, err := historyEntryAt(entries, number); err == nil {
+			t.Errorf("expected an error for entry %d", number)
+		}
+	}
+	if _, err := historyEntryAt(nil, 1); err == nil {
+		t.Errorf("expected an error for an empty history")
+	}
+}
+
+func TestHistoryEnabled(t *testing.T) {
+	disabled := false
+	conf := &config.Config{History: &config.History{Enabled: &disabled}}
+
+	t.Setenv(historyEnv, "")
+	if !historyEnabled(nil) {
+		t.Errorf("history should be enabled by default")
+	}
+	if historyEnabled(conf) {
+		t.Errorf("history should follow the config file")
+	}
+
+	t.Setenv(historyEnv, "enable")
+	if !historyEnabled(conf) {
+		t.Errorf("PB_HISTORY should take precedence over the config file")
+	}
+	t.Setenv(historyEnv, "disable")
+	if historyEnabled(nil) {
+		t.Errorf("PB_HISTORY=disable should turn history off")
+	}
+}
+
+func TestRecordQuery(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv(historyEnv, "")
+	historyPath := filepath.Join(xdg, "parseable", historyFilename)
+
+	recordQuery(HistoryEntry{Time: time.Now(), Profile: "local", Query: "select * from backend", From: "10m", To: "now"})
+	entries, err := readHistory(historyPath)
+	if err != nil || len(entries) != 1 || entries[0].From != "10m" {
+		t.Fatalf("query was not recorded: %+v, %v", entries, err)
+	}
+
+	t.Setenv(historyEnv, "disable")
+	recordQuery(HistoryEntry{Time: time.Now(), Query: "select 1", From: "1m", To: "now"})
+	entries, _ = readHistory(historyPath)
+	if len(entries) != 1 {
+		t.Errorf("query was recorded with history disabled: %+v", entries)
+	}
+}
@@ -20,27 +20,41 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"pb/pkg/common"
 	"pb/pkg/config"
 	internalHTTP "pb/pkg/http"
 	"pb/pkg/model"
 	"strings"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
 var SavedQueryList = &cobra.Command{
 	Use:     "list",
-	Example: "pb query list [-o | --output]",
+	Example: "  pb query list [-o | --output]\n  pb query list --window hour",
 	Short:   "List of saved queries",
 	Long:    "\nShow the list of saved queries for active user",
 	PreRunE: PreRunDefaultProfile,
-	Run: func(_ *cobra.Command, _ []string) {
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 
-		// Check if the output flag is set
-		if outputFlag != "" {
+		// Check if the output flag is set, the default output from the
+		// config file doesn't replace the interactive menu
+		if Output.set {
+			output, err := resolveOutput(outputText, outputJSON)
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
+
 			// Display all filters if output flag is set
 			userConfig, err := config.ReadConfigFromFile()
 			if err != nil {
@@ -51,23 +65,22 @@ var SavedQueryList = &cobra.Command{
 				userProfile = profile
 			}
 
-			client := &http.Client{
-				Timeout: time.Second * 60,
-			}
-			userSavedQueries := fetchFilters(client, &userProfile)
+			client := internalHTTP.DefaultClient(&userProfile)
+			userSavedQueries := fetchFilters(&client.Client, &userProfile)
 			// Collect all filter titles in a slice and join with commas
 			var filterDetails []string
 
-			if outputFlag == "json" {
+			if output == outputJSON {
 				// If JSON output is requested, marshal the saved queries to JSON
 				jsonOutput, err := json.MarshalIndent(userSavedQueries, "", "  ")
 				if err != nil {
-					fmt.Println("Error converting saved queries to JSON:", err)
-					return
+					err = fmt.Errorf("failed to convert saved queries to JSON: %w", err)
+					cmd.Annotations[common.ErrorAnnotation] = err.Error()
+					return err
 				}
 				if string(jsonOutput) == "null" {
 					fmt.Println("[]")
-					return
+					return nil
 				}
 				fmt.Println(string(jsonOutput))
 			} else {
@@ -96,24 +109,36 @@ var SavedQueryList = &cobra.Command{
 			}
 			// Print all titles as a single line, comma-separated
 			fmt.Println(strings.Join(filterDetails, " "))
-			return
+			return nil
+		}
 
+		// Check the window before opening the menu
+		granularity, _ := cmd.Flags().GetString("window")
+		window, err := model.ParseWindow(granularity)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
 		}
 
 		// Normal Saved Queries Menu if output flag not set
 		p := model.SavedQueriesMenu()
 		if _, err := p.Run(); err != nil {
-			os.Exit(1)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
 		}
 
 		a := model.QueryToApply()
 		d := model.QueryToDelete()
-		if a.Stream() != "" {
-			savedQueryToPbQuery(a.Stream(), a.StartTime(), a.EndTime())
+		if a.SavedQueryID() != "" {
+			if err := openSavedQuery(a, window); err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
 		}
 		if d.SavedQueryID() != "" {
 			deleteSavedQuery(&client, d.SavedQueryID(), d.Title())
 		}
+		return nil
 	},
 }
 
@@ -137,17 +162,66 @@ func deleteSavedQuery(client *internalHTTP.HTTPClient, savedQueryID, title strin
 	}
 }
 
-// Convert a saved query to executable pb query
-func savedQueryToPbQuery(query string, start string, end string) {
-	var timeStamps string
-	if start == "" || end == "" {
-		timeStamps = ``
-	} else {
-		startFormatted := formatToRFC3339(start)
-		endFormatted := formatToRFC3339(end)
-		timeStamps = ` --from=` + startFormatted + ` --to=` + endFormatted
+// openSavedQuery shows the results of a saved query in the interactive
+// query table, paging through them a window at a time
+func openSavedQuery(item model.Item, window time.Duration) error {
+	start, end, err := savedQueryTimeRange(item.StartTime(), item.EndTime(), time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid time range of saved query %s: %w", item.Title(), err)
 	}
-	_ = `pb query run ` + query + timeStamps
+	queryModel := model.NewQueryModel(DefaultProfile, item.SQL(), start, end, window)
+	_, err = tea.NewProgram(queryModel, tea.WithAltScreen()).Run()
+	return err
+}
+
+func init() {
+	SavedQueryList.Flags().String("window", "minute", "Span of time fetched per page when a saved query is opened (minute|hour)")
+}
+
+// defaultSavedQueryRange is the time range of saved queries stored without one
+const defaultSavedQueryRange = 10 * time.Minute
+
+// savedQueryTimeRange converts the time filter of a saved query to absolute
+// times. Each bound is a timestamp, "now", or for the start a duration
+// before the end like 1h. Without a time filter the last ten minutes are used.
+func savedQueryTimeRange(from, to string, now time.Time) (start, end time.Time, err error) {
+	end = now
+	if to != "" && to != "now" {
+		if end, err = parseSavedTime(to); err != nil {
+			return
+		}
+	}
+
+	switch {
+	case from == "":
+		start = end.Add(-defaultSavedQueryRange)
+	case from == "now":
+		start = now
+	default:
+		if duration, durationErr := time.ParseDuration(from); durationErr == nil {
+			start = end.Add(-duration)
+		} else if start, err = parseSavedTime(from); err != nil {
+			return
+		}
+	}
+
+	if !start.Before(end) {
+		err = fmt.Errorf("start %s is not before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return
+}
+
+// parseSavedTime parses a timestamp of a saved query. Timestamps written as
+// "2006-01-02 15:04:05 +0000 UTC" are parsed by their date and time only.
+func parseSavedTime(value string) (time.Time, error) {
+	if t, err := parseTimeToFormat(value); err == nil {
+		return t, nil
+	}
+	fields := strings.Fields(value)
+	if len(fields) > 2 {
+		return parseTimeToFormat(strings.Join(fields[:2], " "))
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time: %s", value)
 }
 
 // Parses all UTC time format from string to time interface
@@ -176,41 +250,6 @@ func parseTimeToFormat(input string) (time.Time, error) {
 	return t, fmt.Errorf("unable to parse time: %s", input)
 }
 
-// Converts to RFC3339
-func convertTime(input string) (string, error) {
-	t, err := parseTimeToFormat(input)
-	if err != nil {
-		return "", err
-	}
-
-	return t.Format(time.RFC3339), nil
-}
-
-// Converts User inputted time to string type RFC3339 time
-func formatToRFC3339(time string) string {
-	var formattedTime string
-	if len(strings.Fields(time)) > 1 {
-		newTime := strings.Fields(time)[0:2]
-		rfc39990time, err := convertTime(strings.Join(newTime, " "))
-		if err != nil {
-			fmt.Println("error formatting time")
-		}
-		formattedTime = rfc39990time
-	} else {
-		rfc39990time, err := convertTime(time)
-		if err != nil {
-			fmt.Println("error formatting time")
-		}
-		formattedTime = rfc39990time
-	}
-	return formattedTime
-}
-
-func init() {
-	// Add the output flag to the SavedQueryList command
-	SavedQueryList.Flags().StringVarP(&outputFlag, "output", "o", "", "Output format (text or json)")
-}
-
 type Item struct {
 	ID     string `json:"id"`
 	Title  string `json:"title"`
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSavedQueryTimeRange(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name, from, to string
+		start, end     time.Time
+	}{
+		{"no time filter", "", "", now.Add(-10 * time.Minute), now},
+		{"timestamps", "2024-05-31T10:00:00.000Z", "2024-05-31T11:00:00.000Z",
+			time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC), time.Date(2024, 5, 31, 11, 0, 0, 0, time.UTC)},
+		{"go time strings", "2024-05-31 10:00:00 +0000 UTC", "2024-05-31 11:00:00 +0000 UTC",
+			time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC), time.Date(2024, 5, 31, 11, 0, 0, 0, time.UTC)},
+		{"relative", "1h", "now", now.Add(-time.Hour), now},
+		{"relative without end", "30m", "", now.Add(-30 * time.Minute), now},
+	}
+	for _, tt := range tests {
+		start, end, err := savedQueryTimeRange(tt.from, tt.to, now)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: got %s - %s, want %s - %s", tt.name, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestSavedQueryTimeRangeInvalid(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range [][2]string{{"yesterday", "now"}, {"2024-06-02T00:00:00Z", "2024-06-01T00:00:00Z"}} {
+		if _, _, err := savedQueryTimeRange(tt[0], tt[1], now); err == nil {
+			t.Errorf("expected an error for %s - %s", tt[0], tt[1])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// eventTimeField is the event time the server adds to every record
+const eventTimeField = "p_timestamp"
+
+// writeNDJSON decodes the records of a query response one at a time and writes
+// each as a single line to w, so large results are never held in memory.
+// Numbers are copied as sent instead of going through float64. Every record
+// must have a p_timestamp, which log tools use as the event time.
+func writeNDJSON(r io.Reader, w io.Writer, timeFormat string) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	if token == nil {
+		// the server returns null when nothing matched
+		return nil
+	}
+	if token != json.Delim('[') {
+		return errors.New("error decoding JSON response: expected an array of records")
+	}
+
+	out := bufio.NewWriter(w)
+	for line := 1; decoder.More(); line++ {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("error decoding JSON response: %w", err)
+		}
+		if record[eventTimeField] == nil {
+			// write the lines before it so the error shows where output stopped
+			_ = out.Flush()
+			return fmt.Errorf("record %d has no %s, ndjson output needs the event time of every record, select %s in the query", line, eventTimeField, eventTimeField)
+		}
+		formatRecordTimestamps([]map[string]interface{}{record}, timeFormat)
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(append(encoded, '\n')); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return out.Flush()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	body := `[
+  {"p_timestamp": "2024-05-01T10:00:00.000", "host": "a", "bytes": 9007199254740993},
+  {"p_timestamp": "2024-05-01T10:00:01.000", "host": "b"}
+]`
+	var out bytes.Buffer
+	if err := writeNDJSON(strings.NewReader(body), &out, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"bytes":9007199254740993,"host":"a","p_timestamp":"2024-05-01T10:00:00.000"}` + "\n" +
+		`{"host":"b","p_timestamp":"2024-05-01T10:00:01.000"}` + "\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteNDJSONRequiresEventTime(t *testing.T) {
+	for _, body := range []string{
+		`[{"p_timestamp": "2024-05-01T10:00:00.000", "host": "a"}, {"p_timestamp": null, "host": "b"}]`,
+		`[{"p_timestamp": "2024-05-01T10:00:00.000", "host": "a"}, {"host": "b"}]`,
+	} {
+		var out bytes.Buffer
+		err := writeNDJSON(strings.NewReader(body), &out, "")
+		if err == nil || !strings.Contains(err.Error(), "record 2 has no p_timestamp") {
+			t.Errorf("%s: expected an error for the second record, got %v", body, err)
+		}
+		if want := `{"host":"a","p_timestamp":"2024-05-01T10:00:00.000"}` + "\n"; out.String() != want {
+			t.Errorf("%s: expected the lines before the error, got %q", body, out.String())
+		}
+	}
+}
+
+func TestWriteNDJSONEmptyResults(t *testing.T) {
+	for _, body := range []string{"[]", "null"} {
+		var out bytes.Buffer
+		if err := writeNDJSON(strings.NewReader(body), &out, ""); err != nil || out.Len() != 0 {
+			t.Errorf("%s: expected no lines, got %q (%v)", body, out.String(), err)
+		}
+	}
+	if err := writeNDJSON(strings.NewReader(`{"error":"boom"}`), &bytes.Buffer{}, ""); err == nil {
+		t.Error("expected an error for a response that is not an array")
+	}
+}
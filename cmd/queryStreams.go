@@ -0,0 +1,142 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	internalHTTP "pb/pkg/http"
+)
+
+const (
+	streamsFlag        = "streams"
+	concurrencyFlag    = "concurrency"
+	defaultConcurrency = 4
+
+	// sourceStreamField tags each record with the stream it was read from
+	sourceStreamField = "p_stream"
+)
+
+// streamResult holds the records returned for one stream of a fan-out query
+type streamResult struct {
+	stream  string
+	records []map[string]interface{}
+	err     error
+}
+
+// substituteStream replaces the stream in the FROM clause of query
+func substituteStream(query, stream string) (string, error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", err
+	}
+	streamIdx, err := streamTokenIndex(tokens)
+	if err != nil {
+		return "", err
+	}
+	runes := []rune(query)
+	quoted := `"` + strings.ReplaceAll(stream, `"`, `""`) + `"`
+	return string(runes[:tokens[streamIdx].start]) + quoted + string(runes[tokens[streamIdx].end:]), nil
+}
+
+// fanOutQuery runs query once per stream with at most concurrency requests
+// in flight. Results are returned in the order of streams.
+func fanOutQuery(client *internalHTTP.HTTPClient, query string, streams []string, startTime, endTime string, concurrency int) []streamResult {
+	results := make([]streamResult, len(streams))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for idx, stream := range streams {
+		results[idx].stream = stream
+		streamQuery, err := substituteStream(query, stream)
+		if err != nil {
+			results[idx].err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, streamQuery string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			records, err := queryRecords(client, streamQuery, startTime, endTime)
+			results[idx].records = records
+			results[idx].err = err
+		}(idx, streamQuery)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runStreamsQuery runs the query across streams and prints the results. JSON
+// output nests the records under their stream name, text output prints one
+// record per line tagged with its stream.
+func runStreamsQuery(client *internalHTTP.HTTPClient, query string, streams []string, startTime, endTime, outputFormat, timeFormat string, pretty bool, concurrency int) error {
+	if concurrency <= 0 {
+		return fmt.Errorf("invalid concurrency %d", concurrency)
+	}
+
+	results := fanOutQuery(client, query, streams, startTime, endTime, concurrency)
+
+	var firstErr error
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "Query on stream %s failed: %s\n", result.stream, result.err)
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			failed++
+		}
+		formatRecordTimestamps(result.records, timeFormat)
+	}
+
+	if outputFormat == "json" {
+		nested := make(map[string][]map[string]interface{}, len(results))
+		for _, result := range results {
+			if result.err == nil {
+				nested[result.stream] = result.records
+			}
+		}
+		var encoded []byte
+		if pretty {
+			encoded, _ = json.MarshalIndent(nested, "", "  ")
+		} else {
+			encoded, _ = json.Marshal(nested)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		for _, result := range results {
+			for _, record := range result.records {
+				record[sourceStreamField] = result.stream
+				encoded, _ := json.Marshal(record)
+				fmt.Println(string(encoded))
+			}
+		}
+	}
+
+	if failed > 0 {
+		// wrap the first error so the exit code reflects its status
+		return fmt.Errorf("query failed on %d of %d streams: %w", failed, len(streams), firstErr)
+	}
+	return nil
+}
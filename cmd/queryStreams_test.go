@@ -0,0 +1,98 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestSubstituteStream(t *testing.T) {
+	tests := []struct {
+		query, stream, want string
+	}{
+		{
+			query:  `select * from backend where status = 500`,
+			stream: "frontend",
+			want:   `select * from "frontend" where status = 500`,
+		},
+		{
+			query:  `select extract(hour from p_timestamp) as h, count(*) from backend group by h`,
+			stream: "frontend",
+			want:   `select extract(hour from p_timestamp) as h, count(*) from "frontend" group by h`,
+		},
+		{
+			query:  `SELECT count(*) FROM "app-logs" a`,
+			stream: "web-logs",
+			want:   `SELECT count(*) FROM "web-logs" a`,
+		},
+		{
+			query:  `select * from (select host from backend) t`,
+			stream: `odd"name`,
+			want:   `select * from (select host from "odd""name") t`,
+		},
+	}
+	for _, test := range tests {
+		got, err := substituteStream(test.query, test.stream)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestFanOutQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch body.Query {
+		case `select extract(hour from p_timestamp) as h from "backend"`:
+			_, _ = w.Write([]byte(`[{"h":10}]`))
+		case `select extract(hour from p_timestamp) as h from "app-logs"`:
+			_, _ = w.Write([]byte(`[{"h":11}]`))
+		default:
+			http.Error(w, "stream not found", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	query := `select extract(hour from p_timestamp) as h from backend`
+	results := fanOutQuery(&client, query, []string{"backend", "missing", "app-logs"}, "10m", "now", 2)
+
+	if len(results) != 3 {
+		t.Fatalf("expected a result per stream, got %d", len(results))
+	}
+	if results[0].err != nil || len(results[0].records) != 1 || results[0].records[0]["h"] != float64(10) {
+		t.Errorf("unexpected result for backend: %+v", results[0])
+	}
+	if results[1].stream != "missing" || results[1].err == nil {
+		t.Errorf("expected the missing stream to fail: %+v", results[1])
+	}
+	if results[2].err != nil || len(results[2].records) != 1 || results[2].records[0]["h"] != float64(11) {
+		t.Errorf("a failed stream should not hide the others: %+v", results[2])
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	internalHTTP "pb/pkg/http"
+	"pb/pkg/timerange"
+)
+
+const (
+	watchFlag            = "watch"
+	intervalFlag         = "interval"
+	defaultWatchInterval = 10 * time.Second
+)
+
+// queryTick is the JSON document printed on every tick of query run --watch
+type queryTick struct {
+	Time    string                   `json:"time"`
+	From    string                   `json:"from"`
+	To      string                   `json:"to"`
+	Records []map[string]interface{} `json:"records"`
+}
+
+// watchQuery runs the query every interval on the window from..to, resolved
+// against the current time on every tick, until ctx is done. Text output is
+// redrawn in place, JSON output is printed as one queryTick per tick.
+func watchQuery(ctx context.Context, client *internalHTTP.HTTPClient, query, from, to string, interval time.Duration, outputFormat, timeFormat string, pretty bool) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		start := timerange.ResolveIn(from, now, false, TimeZone.Location())
+		end := timerange.ResolveIn(to, now, true, TimeZone.Location())
+
+		if outputFormat == outputJSON {
+			records, err := queryRecords(client, query, start, end)
+			if err != nil {
+				return err
+			}
+			if records == nil {
+				records = []map[string]interface{}{}
+			}
+			formatRecordTimestamps(records, timeFormat)
+			tick := queryTick{Time: now.In(outputLocation()).Format(time.RFC3339), From: start, To: end, Records: records}
+			var encoded []byte
+			if pretty {
+				encoded, err = json.MarshalIndent(tick, "", "  ")
+			} else {
+				encoded, err = json.Marshal(tick)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(encoded))
+		} else {
+			// clear the screen and move the cursor home before redrawing
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Every %s: %s\n", interval, StyleBold.Render(query))
+			if err := fetchData(client, query, start, end, outputText, timeFormat, pretty, false); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestWatchQueryPrintsOneDocumentPerTick(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		starts = append(starts, payload["startTime"])
+		if len(starts) == 3 {
+			cancel()
+		}
+		_, _ = w.Write([]byte(`[{"status": 500}]`))
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	out := captureStdout(t, func() {
+		if err := watchQuery(ctx, &client, "select * from backend", "10m", "now", time.Millisecond, outputJSON, "", false); err != nil {
+			t.Errorf("watchQuery: %v", err)
+		}
+	})
+
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 documents, got %d:\n%s", len(lines), out)
+	}
+	for _, line := range lines {
+		var tick queryTick
+		if err := json.Unmarshal(line, &tick); err != nil {
+			t.Fatalf("invalid document %q: %v", line, err)
+		}
+		if tick.Time == "" || tick.From == "" || tick.To == "" || len(tick.Records) != 1 {
+			t.Errorf("unexpected tick %+v", tick)
+		}
+	}
+	for _, start := range starts {
+		if start != "10m" {
+			t.Errorf("relative windows are left to the server to resolve, got %q", start)
+		}
+	}
+}
+
+func TestWatchQueryRejectsInvalidInterval(t *testing.T) {
+	client := internalHTTP.DefaultClient(&config.Profile{URL: "http://localhost"})
+	if err := watchQuery(context.Background(), &client, "select 1", "1m", "now", 0, outputText, "", true); err == nil {
+		t.Error("expected a zero interval to be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestFetchRawCopiesTheResponse(t *testing.T) {
+	// unusual spacing and key order would not survive decoding
+	body := "[{\"status\":500,   \"host\":\"a\"},\n {\"status\":200,\"host\":\"b\"}]"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	var out bytes.Buffer
+	if err := fetchRaw(&client, "select * from backend", "10m", "now", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != body {
+		t.Errorf("got %q, want the body unchanged %q", out.String(), body)
+	}
+}
+
+func TestFetchRawReturnsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "stream not found", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	var out bytes.Buffer
+	err := fetchRaw(&client, "select * from missing", "10m", "now", &out)
+	var apiErr *internalHTTP.APIError
+	if !errors.As(err, &apiErr) || out.Len() != 0 {
+		t.Errorf("expected an API error and no output, got %v and %q", err, out.String())
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// retentionActions are the actions the server can take on expired data
+var retentionActions = []string{"delete"}
+
+// retentionDurationPattern matches the whole number of days the server expects
+var retentionDurationPattern = regexp.MustCompile(`^[1-9][0-9]*d$`)
+
+// validateRetention checks a retention rule before it is sent to the server
+func validateRetention(action, duration string) error {
+	if !retentionDurationPattern.MatchString(duration) {
+		return fmt.Errorf("invalid retention duration %q, use a number of days such as 30d", duration)
+	}
+	for _, valid := range retentionActions {
+		if action == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid retention action %q, must be one of: %s", action, strings.Join(retentionActions, ", "))
+}
+
+// GetRetentionCmd shows the retention policy of a stream
+var GetRetentionCmd = &cobra.Command{
+	Use:     "get stream-name",
+	Short:   "Show the retention policy of a stream",
+	Example: "  pb stream retention get backend\n  pb stream retention get backend --output json",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		retention, err := fetchRetention(&client, args[0])
+		if err != nil {
+			err = fmt.Errorf("failed to fetch retention of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		if retention == nil {
+			retention = StreamRetentionData{}
+		}
+
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(retention, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(retention) == 0 {
+			fmt.Printf("No retention period set on stream %s\n", StyleBold.Render(args[0]))
+			return nil
+		}
+		printRetention(retention)
+		return nil
+	},
+}
+
+// SetRetentionCmd replaces the retention policy of a stream
+var SetRetentionCmd = &cobra.Command{
+	Use:     "set stream-name",
+	Short:   "Set the retention policy of a stream",
+	Example: "  pb stream retention set backend --duration 30d\n  pb stream retention set backend --duration 30d --action delete",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		duration, _ := cmd.Flags().GetString("duration")
+		action, _ := cmd.Flags().GetString("action")
+		if err := validateRetention(action, duration); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := setRetention(&client, args[0], action, duration); err != nil {
+			err = fmt.Errorf("failed to set retention of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Set retention of stream %s to %s after %s\n", StyleBold.Render(args[0]), action, duration)
+		return nil
+	},
+}
+
+// ClearRetentionCmd removes the retention policy of a stream, its data is
+// then kept indefinitely
+var ClearRetentionCmd = &cobra.Command{
+	Use:     "clear stream-name",
+	Short:   "Remove the retention policy of a stream",
+	Example: "  pb stream retention clear backend",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := putRetention(&client, args[0], StreamRetentionData{}); err != nil {
+			err = fmt.Errorf("failed to clear retention of stream %s: %w", args[0], err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Cleared retention of stream %s\n", StyleBold.Render(args[0]))
+		return nil
+	},
+}
+
+func init() {
+	SetRetentionCmd.Flags().String("duration", "", "Retention period of the stream data in days, e.g. 30d")
+	SetRetentionCmd.Flags().String("action", "delete", "Action taken when data exceeds the retention period ("+strings.Join(retentionActions, "|")+")")
+	_ = SetRetentionCmd.MarkFlagRequired("duration")
+}
+
+func printRetention(retention StreamRetentionData) {
+	for _, item := range retention {
+		if item.Description != "" {
+			fmt.Printf("  %-13s %s\n", "Description:", item.Description)
+		}
+		fmt.Printf("  %-13s %s\n", "Action:", StyleBold.Render(item.Action))
+		fmt.Printf("  %-13s %s\n", "Duration:", StyleBold.Render(item.Duration))
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestValidateRetention(t *testing.T) {
+	if err := validateRetention("delete", "30d"); err != nil {
+		t.Errorf("expected 30d delete to be valid: %v", err)
+	}
+	for _, test := range []struct{ action, duration string }{
+		{"delete", "30"},
+		{"delete", "0d"},
+		{"delete", "2w"},
+		{"delete", "-1d"},
+		{"archive", "30d"},
+	} {
+		if err := validateRetention(test.action, test.duration); err == nil {
+			t.Errorf("expected %s after %s to be rejected", test.action, test.duration)
+		}
+	}
+}
+
+// useRetentionServer stores the retention sent with PUT and serves it on GET
+func useRetentionServer(t *testing.T) *StreamRetentionData {
+	stored := StreamRetentionData{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logstream/backend/retention" {
+			http.Error(w, "stream not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPut {
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &stored); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+			return
+		}
+		_ = json.NewEncoder(w).Encode(stored)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	t.Cleanup(func() { DefaultProfile = previous })
+	return &stored
+}
+
+func TestRetentionCommands(t *testing.T) {
+	stored := useRetentionServer(t)
+
+	setFlags(t, SetRetentionCmd, map[string]string{"duration": "30d", "action": "delete"})
+	captureStdout(t, func() {
+		if err := SetRetentionCmd.RunE(SetRetentionCmd, []string{"backend"}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	})
+	if len(*stored) != 1 || (*stored)[0].Action != "delete" || (*stored)[0].Duration != "30d" || (*stored)[0].Description != "delete after 30d" {
+		t.Fatalf("unexpected retention sent: %+v", *stored)
+	}
+
+	useOutput(t, outputJSON)
+	out := captureStdout(t, func() {
+		if err := GetRetentionCmd.RunE(GetRetentionCmd, []string{"backend"}); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	})
+	var got StreamRetentionData
+	if err := json.Unmarshal(out, &got); err != nil || len(got) != 1 || got[0].Duration != "30d" {
+		t.Fatalf("unexpected get output %q: %v", out, err)
+	}
+
+	captureStdout(t, func() {
+		if err := ClearRetentionCmd.RunE(ClearRetentionCmd, []string{"backend"}); err != nil {
+			t.Fatalf("clear: %v", err)
+		}
+	})
+	if len(*stored) != 0 {
+		t.Errorf("retention not cleared: %+v", *stored)
+	}
+}
+
+func TestSetRetentionRejectsInvalidDuration(t *testing.T) {
+	stored := useRetentionServer(t)
+
+	setFlags(t, SetRetentionCmd, map[string]string{"duration": "30", "action": "delete"})
+	if err := SetRetentionCmd.RunE(SetRetentionCmd, []string{"backend"}); err == nil {
+		t.Fatal("expected an invalid duration to be rejected")
+	}
+	if len(*stored) != 0 {
+		t.Errorf("nothing should be sent for an invalid duration: %+v", *stored)
+	}
+}
@@ -20,11 +20,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"pb/pkg/model/role"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -80,7 +83,7 @@ var AddRoleCmd = &cobra.Command{
 		var roles []string
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 		if err := fetchRoles(&client, &roles); err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error fetching roles: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error fetching roles: %s", err.Error())
 			return err
 		}
 
@@ -91,7 +94,7 @@ var AddRoleCmd = &cobra.Command{
 
 		_m, err := tea.NewProgram(role.New()).Run()
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error initializing program: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error initializing program: %s", err.Error())
 			return err
 		}
 
@@ -120,31 +123,16 @@ var AddRoleCmd = &cobra.Command{
 
 		req, err := client.NewRequest("PUT", "role/"+name, putBody)
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error creating request: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error creating request: %s", err.Error())
 			return err
 		}
 
-		resp, err := client.Client.Do(req)
-		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error performing request: %s", err.Error())
-			return err
-		}
-		defer resp.Body.Close()
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error reading response: %s", err.Error())
+		if _, err := client.Do(req); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
-		body := string(bodyBytes)
-
-		if resp.StatusCode == 200 {
-			fmt.Printf("Added role %s", name)
-		} else {
-			cmd.Annotations["errors"] = fmt.Sprintf("Request failed - Status: %s, Response: %s", resp.Status, body)
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-		}
 
+		fmt.Printf("Added role %s\n", name)
 		return nil
 	},
 }
@@ -152,7 +140,7 @@ var AddRoleCmd = &cobra.Command{
 var RemoveRoleCmd = &cobra.Command{
 	Use:     "remove role-name",
 	Aliases: []string{"rm"},
-	Example: "  pb role remove ingestor",
+	Example: "  pb role remove ingestor\n  pb role remove ingestor --yes",
 	Short:   "Delete a role",
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -163,33 +151,29 @@ var RemoveRoleCmd = &cobra.Command{
 		}()
 
 		name := args[0]
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		req, err := client.NewRequest("DELETE", "role/"+name, nil)
+		confirmed, err := confirmDeletion(cmd, "role", name, false)
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error creating delete request: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
+		if !confirmed {
+			fmt.Printf("Aborted, role %s was not deleted\n", name)
+			return nil
+		}
 
-		resp, err := client.Client.Do(req)
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		req, err := client.NewRequest("DELETE", "role/"+name, nil)
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error performing delete request: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error creating delete request: %s", err.Error())
 			return err
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode == 200 {
-			fmt.Printf("Removed role %s\n", StyleBold.Render(name))
-		} else {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error reading response: %s", err.Error())
-				return err
-			}
-			body := string(bodyBytes)
-			cmd.Annotations["errors"] = fmt.Sprintf("Request failed - Status: %s, Response: %s", resp.Status, body)
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
+		if _, err := client.Do(req); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
 		}
 
+		fmt.Printf("Removed role %s\n", StyleBold.Render(name))
 		return nil
 	},
 }
@@ -197,7 +181,7 @@ var RemoveRoleCmd = &cobra.Command{
 var ListRoleCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "List all roles",
-	Example: "  pb role list",
+	Example: "  pb role list\n  pb role list --with-users --output json",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		startTime := time.Now()
 		cmd.Annotations = make(map[string]string)
@@ -205,17 +189,17 @@ var ListRoleCmd = &cobra.Command{
 			cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		}()
 
-		var roles []string
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		err := fetchRoles(&client, &roles)
+		outputFormat, err := resolveOutput(outputText, outputJSON)
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error fetching roles: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		outputFormat, err := cmd.Flags().GetString("output")
+		var roles []string
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		err = fetchRoles(&client, &roles)
 		if err != nil {
-			cmd.Annotations["errors"] = fmt.Sprintf("Error retrieving output flag: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error fetching roles: %s", err.Error())
 			return err
 		}
 
@@ -234,16 +218,49 @@ var ListRoleCmd = &cobra.Command{
 		}
 		wg.Wait()
 
-		if outputFormat == "json" {
-			allRoles := map[string][]RoleData{}
-			for idx, roleName := range roles {
-				if roleResponses[idx].err == nil {
-					allRoles[roleName] = roleResponses[idx].data
+		withUsers, _ := cmd.Flags().GetBool("with-users")
+		var holders map[string][]string
+		if withUsers {
+			users, err := fetchUsers(&client)
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error fetching users: %s", err.Error())
+				return err
+			}
+			userRoleResponses := fetchRolesOfUsers(&client, users)
+			for idx, user := range users {
+				if userRoleResponses[idx].err != nil {
+					fmt.Fprintf(os.Stderr, "Error fetching roles of user %s: %v\n", user.ID, userRoleResponses[idx].err)
+				}
+			}
+			holders = usersByRole(users, userRoleResponses)
+		}
+
+		if outputFormat == outputJSON {
+			var allRoles interface{}
+			if withUsers {
+				rolesWithUsers := map[string]roleWithUsers{}
+				for idx, roleName := range roles {
+					if roleResponses[idx].err == nil {
+						users := holders[roleName]
+						if users == nil {
+							users = []string{}
+						}
+						rolesWithUsers[roleName] = roleWithUsers{Privileges: roleResponses[idx].data, Users: users}
+					}
+				}
+				allRoles = rolesWithUsers
+			} else {
+				privileges := map[string][]RoleData{}
+				for idx, roleName := range roles {
+					if roleResponses[idx].err == nil {
+						privileges[roleName] = roleResponses[idx].data
+					}
 				}
+				allRoles = privileges
 			}
 			jsonOutput, err := json.MarshalIndent(allRoles, "", "  ")
 			if err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error marshaling JSON output: %s", err.Error())
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error marshaling JSON output: %s", err.Error())
 				return fmt.Errorf("failed to marshal JSON output: %w", err)
 			}
 			fmt.Println(string(jsonOutput))
@@ -259,9 +276,16 @@ var ListRoleCmd = &cobra.Command{
 				for _, role := range fetchRes.data {
 					fmt.Println(lipgloss.NewStyle().PaddingLeft(3).Render(role.Render()))
 				}
+				if withUsers {
+					users := "none"
+					if len(holders[roleName]) > 0 {
+						users = strings.Join(holders[roleName], ", ")
+					}
+					fmt.Println(lipgloss.NewStyle().PaddingLeft(3).Render("Users: " + users))
+				}
 			} else {
 				fmt.Printf("Error fetching role data for %s: %v\n", roleName, fetchRes.err)
-				cmd.Annotations["errors"] += fmt.Sprintf("Error fetching role data for %s: %v\n", roleName, fetchRes.err)
+				cmd.Annotations[common.ErrorAnnotation] += fmt.Sprintf("Error fetching role data for %s: %v\n", roleName, fetchRes.err)
 			}
 		}
 
@@ -269,6 +293,30 @@ var ListRoleCmd = &cobra.Command{
 	},
 }
 
+// roleWithUsers is a role in the JSON output of role list --with-users
+type roleWithUsers struct {
+	Privileges []RoleData `json:"privileges"`
+	Users      []string   `json:"users"`
+}
+
+// usersByRole inverts the roles of each user into the sorted users holding
+// each role. Users whose roles could not be fetched are left out.
+func usersByRole(users []UserData, roles []userRoles) map[string][]string {
+	holders := map[string][]string{}
+	for idx, user := range users {
+		if roles[idx].err != nil {
+			continue
+		}
+		for _, role := range roles[idx].data {
+			holders[role] = append(holders[role], user.ID)
+		}
+	}
+	for role := range holders {
+		sort.Strings(holders[role])
+	}
+	return holders
+}
+
 func fetchRoles(client *internalHTTP.HTTPClient, data *[]string) error {
 	req, err := client.NewRequest("GET", "role", nil)
 	if err != nil {
@@ -331,6 +379,6 @@ func fetchSpecificRole(client *internalHTTP.HTTPClient, role string) (res []Role
 }
 
 func init() {
-	// Add the --output flag with default value "text"
-	ListRoleCmd.Flags().StringP("output", "o", "text", "Output format: 'text' or 'json'")
+	ListRoleCmd.Flags().Bool("with-users", false, "Also list the users holding each role")
+	addYesFlag(RemoveRoleCmd)
 }
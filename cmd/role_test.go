@@ -0,0 +1,40 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUsersByRole(t *testing.T) {
+	users := []UserData{{ID: "carol"}, {ID: "alice"}, {ID: "bob"}}
+	roles := []userRoles{
+		{data: []string{"admin", "reader"}},
+		{data: []string{"reader"}},
+		{err: errors.New("user not found")},
+	}
+
+	got := usersByRole(users, roles)
+	want := map[string][]string{
+		"admin":  {"carol"},
+		"reader": {"alice", "carol"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("usersByRole = %v, want %v", got, want)
+	}
+}
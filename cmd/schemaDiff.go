@@ -0,0 +1,212 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaFieldChange is a field whose data type differs between the schemas
+type schemaFieldChange struct {
+	Name  string `json:"name"`
+	Local string `json:"local"`
+	Live  string `json:"live"`
+}
+
+// schemaDiff lists how a local static schema differs from the live schema of
+// a stream. Added fields are in the local schema only, removed fields in the
+// live schema only.
+type schemaDiff struct {
+	Stream  string              `json:"stream"`
+	Added   []staticSchemaField `json:"added"`
+	Removed []staticSchemaField `json:"removed"`
+	Changed []schemaFieldChange `json:"changed"`
+}
+
+func (d schemaDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+type staticSchemaField struct {
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+}
+
+// DiffSchemaCmd compares a local static schema file with the live schema of
+// a stream
+var DiffSchemaCmd = &cobra.Command{
+	Use:     "diff",
+	Short:   "Compare a schema file with the schema of a Parseable stream",
+	Example: "  pb schema diff --stream=my_stream --file=schema.json\n  pb schema diff --stream=my_stream --file=schema.json --output json",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		streamName, _ := cmd.Flags().GetString("stream")
+		filePath, _ := cmd.Flags().GetString("file")
+		if streamName == "" || filePath == "" {
+			err := fmt.Errorf("both --stream and --file are required")
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		filePath, err = common.ExpandPath(filePath)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		fileContent, err := os.ReadFile(filePath)
+		if err != nil {
+			err = fmt.Errorf("failed to read file %s: %w", filePath, err)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		local, err := parseStaticSchema(fileContent)
+		if err != nil {
+			err = fmt.Errorf("failed to parse schema file %s: %w", filePath, err)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		liveContent, err := fetchStaticSchema(&client, streamName)
+		if err != nil {
+			err = fmt.Errorf("failed to fetch schema of stream %s: %w", streamName, err)
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		live, err := parseStaticSchema(liveContent)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		diff := diffSchemas(local, live)
+		diff.Stream = streamName
+
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+		printSchemaDiff(os.Stdout, diff)
+		return nil
+	},
+}
+
+func init() {
+	DiffSchemaCmd.Flags().StringP("stream", "s", "", "Name of the stream to compare the schema with")
+	DiffSchemaCmd.Flags().StringP("file", "f", "", "Path to the JSON file with the static schema")
+}
+
+// parseStaticSchema reads a static schema, the format accepted by pb schema
+// create. Data types are compared case-insensitively.
+func parseStaticSchema(data []byte) ([]staticSchemaField, error) {
+	var schema struct {
+		Fields []staticSchemaField `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	for idx, field := range schema.Fields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("field %d has no name", idx+1)
+		}
+		schema.Fields[idx].DataType = strings.ToLower(field.DataType)
+	}
+	return schema.Fields, nil
+}
+
+// diffSchemas compares the fields of the local and live schema by name. Each
+// list of the result is sorted by field name.
+func diffSchemas(local, live []staticSchemaField) schemaDiff {
+	liveTypes := make(map[string]string, len(live))
+	for _, field := range live {
+		liveTypes[field.Name] = field.DataType
+	}
+	localTypes := make(map[string]string, len(local))
+	for _, field := range local {
+		localTypes[field.Name] = field.DataType
+	}
+
+	diff := schemaDiff{
+		Added:   []staticSchemaField{},
+		Removed: []staticSchemaField{},
+		Changed: []schemaFieldChange{},
+	}
+	for _, field := range local {
+		liveType, ok := liveTypes[field.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, field)
+		case liveType != field.DataType:
+			diff.Changed = append(diff.Changed, schemaFieldChange{Name: field.Name, Local: field.DataType, Live: liveType})
+		}
+	}
+	for _, field := range live {
+		if _, ok := localTypes[field.Name]; !ok {
+			diff.Removed = append(diff.Removed, field)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Name < diff.Changed[j].Name })
+	return diff
+}
+
+// printSchemaDiff prints added fields in green, removed ones in red and
+// fields with a different data type in yellow
+func printSchemaDiff(out io.Writer, diff schemaDiff) {
+	if diff.empty() {
+		fmt.Fprintf(out, "Schema file matches the schema of stream %s\n", StyleBold.Render(diff.Stream))
+		return
+	}
+	fmt.Fprintf(out, "Schema file compared to stream %s:\n", StyleBold.Render(diff.Stream))
+	for _, field := range diff.Added {
+		fmt.Fprintf(out, common.Green+"  + %s (%s)"+common.Reset+"\n", field.Name, field.DataType)
+	}
+	for _, field := range diff.Removed {
+		fmt.Fprintf(out, common.Red+"  - %s (%s)"+common.Reset+"\n", field.Name, field.DataType)
+	}
+	for _, field := range diff.Changed {
+		fmt.Fprintf(out, common.Yellow+"  ~ %s (%s, live %s)"+common.Reset+"\n", field.Name, field.Local, field.Live)
+	}
+}
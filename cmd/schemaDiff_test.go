@@ -0,0 +1,90 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	local := []staticSchemaField{{"status", "int"}, {"host", "string"}, {"region", "string"}}
+	live := []staticSchemaField{{"status", "string"}, {"host", "string"}, {"latency", "float"}}
+
+	got := diffSchemas(local, live)
+	want := schemaDiff{
+		Added:   []staticSchemaField{{"region", "string"}},
+		Removed: []staticSchemaField{{"latency", "float"}},
+		Changed: []schemaFieldChange{{Name: "status", Local: "int", Live: "string"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if !diffSchemas(local, local).empty() {
+		t.Error("expected no differences between identical schemas")
+	}
+}
+
+func TestDiffSchemaCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logstream/backend/schema" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"fields":[{"name":"p_timestamp","data_type":{"Timestamp":["Millisecond",null]}},{"name":"host","data_type":"Utf8"},{"name":"status","data_type":"Int64"}]}`))
+	}))
+	defer server.Close()
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	defer func() { DefaultProfile = previous }()
+	useOutput(t, outputJSON)
+
+	file := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(file, []byte(`{"fields":[{"name":"host","data_type":"String"},{"name":"region","data_type":"string"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlags(t, DiffSchemaCmd, map[string]string{"stream": "backend", "file": file})
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = DiffSchemaCmd.RunE(DiffSchemaCmd, nil)
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	var got schemaDiff
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	want := schemaDiff{
+		Stream:  "backend",
+		Added:   []staticSchemaField{{"region", "string"}},
+		Removed: []staticSchemaField{{"status", "int"}},
+		Changed: []schemaFieldChange{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
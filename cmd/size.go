@@ -0,0 +1,68 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps the lower case unit suffixes to their size in bytes. KB, MB
+// and so on are decimal, KiB, MiB and so on binary.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+var sizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+// parseSize parses a size like 512, 10GB, 1.5 GiB or 2048 Bytes to bytes
+func parseSize(size string) (uint64, error) {
+	match := sizePattern.FindStringSubmatch(strings.TrimSpace(size))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q, expected a number with an optional unit like 10GB or 512MiB", size)
+	}
+
+	unit := strings.ToLower(match[2])
+	if unit == "bytes" || unit == "byte" {
+		unit = "b"
+	}
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid unit %q in size %q, expected one of B, KB, MB, GB, TB, KiB, MiB, GiB, TiB", match[2], size)
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", size, err)
+	}
+	bytes := math.Round(value * multiplier)
+	if bytes > math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is too large", size)
+	}
+	return uint64(bytes), nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want uint64
+	}{
+		{"512", 512},
+		{"10GB", 10_000_000_000},
+		{"10 GiB", 10 << 30},
+		{"1.5gib", 3 << 29},
+		{"20mb", 20_000_000},
+		{"2 TiB", 2 << 40},
+		{"100 B", 100},
+		{"4096 Bytes", 4096},
+		{"1 Byte", 1},
+		{"2.5 KB", 2500},
+		{"3MB", 3_000_000},
+		{" 7 GB ", 7_000_000_000},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.size)
+		if err != nil {
+			t.Errorf("parseSize(%q) failed: %s", tt.size, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+
+	for _, size := range []string{"", "GB", "10 XB", "-5GB", "ten"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("parseSize(%q) should fail", size)
+		}
+	}
+}
+
+func TestParseStatsSizes(t *testing.T) {
+	stats := func(ingestion, storage string) StreamStatsData {
+		var data StreamStatsData
+		data.Ingestion.Size = ingestion
+		data.Storage.Size = storage
+		return data
+	}
+
+	tests := []struct {
+		name                 string
+		ingestion, storage   string
+		wantIngest, wantStor uint64
+		wantRatio            float64
+	}{
+		{"bytes suffix", "1000 Bytes", "250 Bytes", 1000, 250, 75},
+		{"units", "2 MB", "500 KB", 2_000_000, 500_000, 75},
+		{"no data", "0 Bytes", "0 Bytes", 0, 0, 0},
+		{"nothing stored yet", "1000 Bytes", "", 1000, 0, 0},
+		{"unknown format", "lots", "250 Bytes", 0, 250, 0},
+	}
+	for _, tt := range tests {
+		ingestion, storage, ratio := parseStatsSizes(stats(tt.ingestion, tt.storage))
+		if ingestion != tt.wantIngest || storage != tt.wantStor || math.Abs(ratio-tt.wantRatio) > 1e-9 {
+			t.Errorf("%s: got %d, %d, %.2f, want %d, %d, %.2f", tt.name, ingestion, storage, ratio, tt.wantIngest, tt.wantStor, tt.wantRatio)
+		}
+		if math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+			t.Errorf("%s: ratio is %v", tt.name, ratio)
+		}
+	}
+}
@@ -16,18 +16,27 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"os/signal"
+	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
-	"strconv"
+	"pb/pkg/log"
+	"pb/pkg/model/streampicker"
+	"regexp"
 	"strings"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // StreamStatsData is the data structure for stream stats
@@ -61,6 +70,38 @@ type StreamRetentionData []struct {
 	Duration    string `json:"duration"`
 }
 
+// StreamInfo is the configuration of a stream as returned by the info endpoint
+type StreamInfo struct {
+	CreatedAt          string   `json:"created-at"`
+	FirstEventAt       string   `json:"first-event-at"`
+	TimePartition      string   `json:"time_partition"`
+	TimePartitionLimit string   `json:"time_partition_limit"`
+	CustomPartition    string   `json:"custom_partition"`
+	StaticSchemaFlag   jsonBool `json:"static_schema_flag"`
+	StreamType         string   `json:"stream_type"`
+}
+
+// jsonBool is a boolean that older servers encode as the string "true"
+type jsonBool bool
+
+func (f *jsonBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case bool:
+		*f = jsonBool(value)
+	case string:
+		*f = jsonBool(strings.EqualFold(value, "true"))
+	case nil:
+		*f = false
+	default:
+		return fmt.Errorf("invalid boolean value %s", data)
+	}
+	return nil
+}
+
 // AlertConfig structure
 type AlertConfig struct {
 	Version string  `json:"version"`
@@ -108,9 +149,14 @@ type RuleConfig struct {
 // AddStreamCmd is the parent command for stream
 var AddStreamCmd = &cobra.Command{
 	Use:     "add stream-name",
-	Example: "  pb stream add backend_logs",
+	Example: "  pb stream add backend_logs\n  pb stream add backend_logs --schema-file schema.json --retention-duration 30d --custom-partition region",
 	Short:   "Create a new stream",
-	Args:    cobra.ExactArgs(1),
+	Long: `
+Create a new stream. The stream can be fully provisioned in one step: a static
+schema, a custom partition and a retention policy are applied in sequence. If
+a later step fails the stream is deleted again, so no half configured stream
+is left behind.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Capture start time
 		startTime := time.Now()
@@ -120,47 +166,164 @@ var AddStreamCmd = &cobra.Command{
 		}()
 
 		name := args[0]
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		req, err := client.NewRequest("PUT", "logstream/"+name, nil)
-		if err != nil {
-			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+		schemaFile, _ := cmd.Flags().GetString("schema-file")
+		customPartition, _ := cmd.Flags().GetString("custom-partition")
+		retentionDuration, _ := cmd.Flags().GetString("retention-duration")
+		retentionAction, _ := cmd.Flags().GetString("retention-action")
+
+		var schema []byte
+		if schemaFile != "" {
+			var err error
+			if schemaFile, err = common.ExpandPath(schemaFile); err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			schema, err = os.ReadFile(schemaFile)
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return fmt.Errorf("failed to read schema file %s: %w", schemaFile, err)
+			}
+		}
+		if retentionDuration == "" && cmd.Flags().Changed("retention-action") {
+			err := errors.New("--retention-action requires --retention-duration")
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
+		if retentionDuration != "" {
+			if err := validateRetention(retentionAction, retentionDuration); err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+		}
 
-		resp, err := client.Client.Do(req)
-		if err != nil {
-			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := createStream(&client, name, streamOptions{schema: schema, customPartition: customPartition}); err != nil {
+			err = fmt.Errorf("failed to create stream %s: %w", name, err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
-		// Capture execution time
-		cmd.Annotations["executionTime"] = time.Since(startTime).String()
-
-		if resp.StatusCode == 200 {
-			fmt.Printf("Created stream %s\n", StyleBold.Render(name))
-		} else {
-			bytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+		if retentionDuration != "" {
+			if err := setRetention(&client, name, retentionAction, retentionDuration); err != nil {
+				err = rollbackStream(&client, name, fmt.Errorf("failed to set retention on stream %s: %w", name, err))
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 				return err
 			}
-			body := string(bytes)
-			defer resp.Body.Close()
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
 		}
 
+		fmt.Printf("Created stream %s\n", StyleBold.Render(name))
 		return nil
 	},
 }
 
+func init() {
+	AddStreamCmd.Flags().String("schema-file", "", "Path to a JSON file with a static schema for the stream")
+	AddStreamCmd.Flags().String("custom-partition", "", "Field to use as custom partition of the stream")
+	AddStreamCmd.Flags().String("retention-duration", "", "Retention period of the stream data, e.g. 30d")
+	AddStreamCmd.Flags().String("retention-action", "delete", "Action taken when data exceeds the retention period")
+}
+
+// streamOptions are the settings a stream is created with. They can't be
+// changed once the stream exists.
+type streamOptions struct {
+	// static schema of the stream, the schema is inferred from events when empty
+	schema             []byte
+	customPartition    string
+	timePartition      string
+	timePartitionLimit string
+}
+
+// createStream creates the stream with the given options
+func createStream(client *internalHTTP.HTTPClient, name string, opts streamOptions) error {
+	var body io.Reader
+	if len(opts.schema) > 0 {
+		body = bytes.NewReader(opts.schema)
+	}
+	req, err := client.NewRequest(http.MethodPut, "logstream/"+name, body)
+	if err != nil {
+		return err
+	}
+	if len(opts.schema) > 0 {
+		req.Header.Set("X-P-Static-Schema-Flag", "true")
+	}
+	if opts.customPartition != "" {
+		req.Header.Set("X-P-Custom-Partition", opts.customPartition)
+	}
+	if opts.timePartition != "" {
+		req.Header.Set("X-P-Time-Partition", opts.timePartition)
+	}
+	if opts.timePartitionLimit != "" {
+		req.Header.Set("X-P-Time-Partition-Limit", opts.timePartitionLimit)
+	}
+	_, err = client.Do(req)
+	return err
+}
+
+// rollbackStream deletes a stream whose provisioning failed with err and
+// returns err annotated with the outcome of the rollback
+func rollbackStream(client *internalHTTP.HTTPClient, name string, err error) error {
+	if rollbackErr := deleteStream(client, name); rollbackErr != nil {
+		return fmt.Errorf("%w; rolling back the stream also failed: %s", err, rollbackErr)
+	}
+	return fmt.Errorf("%w; stream %s was removed", err, name)
+}
+
+// setRetention replaces the retention policy of the stream
+func setRetention(client *internalHTTP.HTTPClient, name, action, duration string) error {
+	return putRetention(client, name, StreamRetentionData{{
+		Description: fmt.Sprintf("%s after %s", action, duration),
+		Action:      action,
+		Duration:    duration,
+	}})
+}
+
+func putRetention(client *internalHTTP.HTTPClient, name string, retention StreamRetentionData) error {
+	payload, err := json.Marshal(retention)
+	if err != nil {
+		return err
+	}
+	req, err := client.NewRequest(http.MethodPut, fmt.Sprintf("logstream/%s/retention", name), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(req)
+	return err
+}
+
+func putAlerts(client *internalHTTP.HTTPClient, name string, alerts AlertConfig) error {
+	payload, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := client.NewRequest(http.MethodPut, fmt.Sprintf("logstream/%s/alert", name), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(req)
+	return err
+}
+
+func deleteStream(client *internalHTTP.HTTPClient, name string) error {
+	req, err := client.NewRequest(http.MethodDelete, "logstream/"+name, nil)
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(req)
+	return err
+}
+
 // StatStreamCmd is the stat command for stream
 var StatStreamCmd = &cobra.Command{
-	Use:     "info stream-name",
-	Example: "  pb stream info backend_logs",
+	Use:     "info [stream-name]",
+	Example: "  pb stream info\n  pb stream info backend_logs\n  pb stream info backend_logs --window-since 24h\n  pb stream info backend_logs --preview-alerts --since 24h\n  pb stream info backend_logs --watch --interval 10s",
 	Short:   "Get statistics for a stream",
-	Args:    cobra.ExactArgs(1),
+	Long: `
+Get statistics for a stream. Without a stream name, pick the stream from a
+list of all streams on the server.
+
+The stats cover the lifetime of the stream. With --window-since or
+--window-until the events in that window are counted as well.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Capture start time
 		startTime := time.Now()
@@ -169,27 +332,35 @@ var StatStreamCmd = &cobra.Command{
 			cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		}()
 
-		name := args[0]
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
 		client := internalHTTP.DefaultClient(&DefaultProfile)
+		name, ok, err := streamArg(&client, args)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		if !ok {
+			return nil
+		}
 
 		// Fetch stats data
 		stats, err := fetchStats(&client, name)
 		if err != nil {
 			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
-		ingestionCount := stats.Ingestion.Count
-		ingestionSize, _ := strconv.Atoi(strings.TrimRight(stats.Ingestion.Size, " Bytes"))
-		storageSize, _ := strconv.Atoi(strings.TrimRight(stats.Storage.Size, " Bytes"))
-		compressionRatio := 100 - (float64(storageSize) / float64(ingestionSize) * 100)
-
 		// Fetch retention data
 		retention, err := fetchRetention(&client, name)
 		if err != nil {
 			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
@@ -197,7 +368,7 @@ var StatStreamCmd = &cobra.Command{
 		alertsData, err := fetchAlerts(&client, name)
 		if err != nil {
 			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
@@ -205,93 +376,433 @@ var StatStreamCmd = &cobra.Command{
 		streamType, err := fetchInfo(&client, name)
 		if err != nil {
 			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
-		// Check output format
-		output, _ := cmd.Flags().GetString("output")
-		if output == "json" {
-			// Prepare JSON response
-			data := map[string]interface{}{
-				"info": map[string]interface{}{
-					"event_count":       ingestionCount,
-					"ingestion_size":    humanize.Bytes(uint64(ingestionSize)),
-					"storage_size":      humanize.Bytes(uint64(storageSize)),
-					"compression_ratio": fmt.Sprintf("%.2f%%", compressionRatio),
-				},
-				"retention":   retention,
-				"alerts":      alertsData.Alerts,
-				"stream_type": streamType,
+		// Fetch hot tier, which is not set on most streams
+		hotTier, err := fetchHotTierIfSet(&client, name)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		// Count the events in a window when one is given, the stats
+		// endpoint only reports lifetime totals
+		var window *statsWindow
+		if cmd.Flags().Changed("window-since") || cmd.Flags().Changed("window-until") {
+			since, _ := cmd.Flags().GetString("window-since")
+			until, _ := cmd.Flags().GetString("window-until")
+			window, err = fetchStatsWindow(&client, name, since, until)
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
 			}
+		}
 
-			jsonData, err := json.MarshalIndent(data, "", "  ")
+		// Evaluate alert rules against recent data when asked to
+		var previews []alertPreview
+		if preview, _ := cmd.Flags().GetBool("preview-alerts"); preview {
+			since, _ := cmd.Flags().GetString("since")
+			previews = previewAlerts(&client, name, alertsData.Alerts, since)
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			if output == outputJSON {
+				err := errors.New("--watch is only supported with text output")
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			if window != nil {
+				err := errors.New("--watch shows lifetime stats and can't be combined with --window-since or --window-until")
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			interval, _ := cmd.Flags().GetDuration("interval")
+			err := watchStreamStats(&client, name, interval, stats, func(stats StreamStatsData) {
+				printStreamInfo(newStreamInfoReport(stats, window, streamType, retention, hotTier, alertsData.Alerts, previews))
+			})
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			}
+			return err
+		}
+
+		report := newStreamInfoReport(stats, window, streamType, retention, hotTier, alertsData.Alerts, previews)
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(report, "", "  ")
 			if err != nil {
 				// Capture error
-				cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 				return err
 			}
 			fmt.Println(string(jsonData))
 		} else {
-			// Default text output
-			isRetentionSet := len(retention) > 0
-			isAlertsSet := len(alertsData.Alerts) > 0
-
-			// Render the info section with consistent alignment
-			fmt.Println(StyleBold.Render("\nInfo:"))
-			fmt.Printf("  %-18s %d\n", "Event Count:", ingestionCount)
-			fmt.Printf("  %-18s %s\n", "Ingestion Size:", humanize.Bytes(uint64(ingestionSize)))
-			fmt.Printf("  %-18s %s\n", "Storage Size:", humanize.Bytes(uint64(storageSize)))
-			fmt.Printf("  %-18s %.2f%s\n", "Compression Ratio:", compressionRatio, "%")
-			fmt.Printf("  %-18s %s\n", "Stream Type:", streamType)
-			fmt.Println()
+			printStreamInfo(report)
+		}
 
-			if isRetentionSet {
-				fmt.Println(StyleBold.Render("Retention:"))
-				for _, item := range retention {
-					fmt.Printf("  Action:    %s\n", StyleBold.Render(item.Action))
-					fmt.Printf("  Duration:  %s\n", StyleBold.Render(item.Duration))
-					fmt.Println()
-				}
-			} else {
-				fmt.Println(StyleBold.Render("No retention period set on stream\n"))
+		return nil
+	},
+}
+
+// streamArg returns the stream named in args. Without one, the user picks a
+// stream interactively; ok is false when the picker is closed without a choice.
+func streamArg(client *internalHTTP.HTTPClient, args []string) (name string, ok bool, err error) {
+	if len(args) > 0 {
+		return args[0], true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return "", false, errors.New("a stream name is required when not running in a terminal")
+	}
+
+	streams, err := fetchStreamNames(client)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list streams: %w", err)
+	}
+	if len(streams) == 0 {
+		return "", false, errors.New("there are no streams on the server, create one using pb stream add")
+	}
+
+	m, err := tea.NewProgram(streampicker.New(streams)).Run()
+	if err != nil {
+		return "", false, fmt.Errorf("error selecting stream: %w", err)
+	}
+	picker := m.(streampicker.Model)
+	return picker.Choice, picker.Success, nil
+}
+
+// parseStatsSizes returns the ingestion and storage sizes in bytes and the
+// compression ratio. Sizes that can't be parsed are logged and reported as 0,
+// the ratio is 0 unless both sizes are known.
+func parseStatsSizes(stats StreamStatsData) (ingestionSize, storageSize uint64, compressionRatio float64) {
+	ingestionSize = parseStatsSize("ingestion", stats.Ingestion.Size)
+	storageSize = parseStatsSize("storage", stats.Storage.Size)
+	if ingestionSize > 0 && storageSize > 0 {
+		compressionRatio = 100 - (float64(storageSize) / float64(ingestionSize) * 100)
+	}
+	return
+}
+
+func parseStatsSize(kind, size string) uint64 {
+	if size == "" {
+		return 0
+	}
+	bytes, err := parseSize(size)
+	if err != nil {
+		log.Warn("failed to parse stream size", "kind", kind, "error", err)
+		return 0
+	}
+	return bytes
+}
+
+// streamInfoReport holds everything stream info shows. The JSON output is
+// the report itself and the text output renders the same fields, so the two
+// always agree.
+type streamInfoReport struct {
+	AlertPreview []alertPreview      `json:"alert_preview,omitempty"`
+	Alerts       []Alert             `json:"alerts"`
+	HotTier      *StreamHotTier      `json:"hot_tier,omitempty"`
+	Info         streamInfoSummary   `json:"info"`
+	Retention    StreamRetentionData `json:"retention"`
+	StreamType   string              `json:"stream_type"`
+	Window       *statsWindow        `json:"window,omitempty"`
+}
+
+// streamInfoSummary is the info section of stream info
+type streamInfoSummary struct {
+	CompressionRatio string `json:"compression_ratio"`
+	EventCount       int    `json:"event_count"`
+	IngestionSize    string `json:"ingestion_size"`
+	StorageSize      string `json:"storage_size"`
+}
+
+func newStreamInfoReport(stats StreamStatsData, window *statsWindow, streamType string, retention StreamRetentionData, hotTier *StreamHotTier, alerts []Alert, previews []alertPreview) streamInfoReport {
+	ingestionSize, storageSize, compressionRatio := parseStatsSizes(stats)
+
+	// unset sections are empty lists rather than null
+	if retention == nil {
+		retention = StreamRetentionData{}
+	}
+	if alerts == nil {
+		alerts = []Alert{}
+	}
+
+	return streamInfoReport{
+		AlertPreview: previews,
+		Alerts:       alerts,
+		HotTier:      hotTier,
+		Info: streamInfoSummary{
+			CompressionRatio: fmt.Sprintf("%.2f%%", compressionRatio),
+			EventCount:       stats.Ingestion.Count,
+			IngestionSize:    humanize.Bytes(ingestionSize),
+			StorageSize:      humanize.Bytes(storageSize),
+		},
+		Retention:  retention,
+		StreamType: streamType,
+		Window:     window,
+	}
+}
+
+// printStreamInfo renders the info, window, retention, hot tier and alerts
+// sections as text
+func printStreamInfo(report streamInfoReport) {
+	// Render the info section with consistent alignment
+	fmt.Println(StyleBold.Render("\nInfo:"))
+	fmt.Printf("  %-18s %d\n", "Event Count:", report.Info.EventCount)
+	fmt.Printf("  %-18s %s\n", "Ingestion Size:", report.Info.IngestionSize)
+	fmt.Printf("  %-18s %s\n", "Storage Size:", report.Info.StorageSize)
+	fmt.Printf("  %-18s %s\n", "Compression Ratio:", report.Info.CompressionRatio)
+	fmt.Printf("  %-18s %s\n", "Stream Type:", report.StreamType)
+	fmt.Println()
+
+	if window := report.Window; window != nil {
+		fmt.Println(StyleBold.Render("Window:"))
+		fmt.Printf("  %-18s %s\n", "Since:", window.Since)
+		fmt.Printf("  %-18s %s\n", "Until:", window.Until)
+		fmt.Printf("  %-18s %d\n", "Event Count:", window.EventCount)
+		fmt.Println()
+	}
+
+	if len(report.Retention) > 0 {
+		fmt.Println(StyleBold.Render("Retention:"))
+		for _, item := range report.Retention {
+			if item.Description != "" {
+				fmt.Printf("  Description: %s\n", item.Description)
 			}
+			fmt.Printf("  Action:      %s\n", StyleBold.Render(item.Action))
+			fmt.Printf("  Duration:    %s\n", StyleBold.Render(item.Duration))
+			fmt.Println()
+		}
+	} else {
+		fmt.Println(StyleBold.Render("No retention period set on stream\n"))
+	}
 
-			if isAlertsSet {
-				fmt.Println(StyleBold.Render("Alerts:"))
-				for _, alert := range alertsData.Alerts {
-					fmt.Printf("  Alert:   %s\n", StyleBold.Render(alert.Name))
-					ruleFmt := fmt.Sprintf(
-						"%s %s %s repeated %d times",
-						alert.Rule.Config.Column,
-						alert.Rule.Config.Operator,
-						fmt.Sprint(alert.Rule.Config.Value),
-						alert.Rule.Config.Repeats,
-					)
-					fmt.Printf("  Rule:    %s\n", ruleFmt)
-					fmt.Printf("  Targets: ")
-					for _, target := range alert.Targets {
-						fmt.Printf("%s, ", target.Type)
-					}
-					fmt.Print("\n\n")
-				}
-			} else {
-				fmt.Println(StyleBold.Render("No alerts set on stream\n"))
+	if report.HotTier != nil {
+		fmt.Println(StyleBold.Render("Hot Tier:"))
+		printHotTier(*report.HotTier)
+		fmt.Println()
+	}
+
+	if len(report.Alerts) > 0 {
+		fmt.Println(StyleBold.Render("Alerts:"))
+		for idx, alert := range report.Alerts {
+			fmt.Printf("  Alert:   %s\n", StyleBold.Render(alert.Name))
+			fmt.Printf("  Rule:    %s\n", ruleSummary(alert.Rule))
+			fmt.Println("  Targets:")
+			for _, target := range alert.Targets {
+				fmt.Printf("    - %s\n", targetSummary(target))
 			}
+			if report.AlertPreview != nil {
+				fmt.Printf("  Preview: %s\n", report.AlertPreview[idx].String())
+			}
+			fmt.Println()
+		}
+	} else {
+		fmt.Println(StyleBold.Render("No alerts set on stream\n"))
+	}
+}
+
+// watchStreamStats re-fetches the stream stats every interval and redraws them
+// in place along with the ingestion rate since the previous tick, until the
+// user presses Ctrl-C
+func watchStreamStats(client *internalHTTP.HTTPClient, name string, interval time.Duration, stats StreamStatsData, render func(StreamStatsData)) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s", interval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous *StreamStatsData
+	var previousAt time.Time
+	fetchedAt := time.Now()
+	for {
+		// clear the screen and move the cursor home before redrawing
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Every %s: %s\n", interval, StyleBold.Render(name))
+		render(stats)
+		if previous != nil {
+			delta := stats.Ingestion.Count - previous.Ingestion.Count
+			rate := float64(delta) / fetchedAt.Sub(previousAt).Seconds()
+			fmt.Printf("  %-18s %+d\n", "Events Delta:", delta)
+			fmt.Printf("  %-18s %.2f events/sec\n", "Ingestion Rate:", rate)
 		}
 
-		return nil
-	},
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		latest, err := fetchStats(client, name)
+		if err != nil {
+			return err
+		}
+		last := stats
+		previous, previousAt = &last, fetchedAt
+		stats, fetchedAt = latest, time.Now()
+	}
 }
 
 func init() {
-	StatStreamCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (text|json)")
+	StatStreamCmd.Flags().Bool("preview-alerts", false, "Count recent events matching each alert rule")
+	StatStreamCmd.Flags().String("since", defaultAlertPreviewWindow, "How far back --preview-alerts evaluates alert rules (e.g. 10m or 24h)")
+	StatStreamCmd.Flags().String("window-since", defaultStatsWindow, "Start of the window to count events in (e.g. 10m, 24h or an RFC3339 time)")
+	StatStreamCmd.Flags().String("window-until", "now", "End of the window to count events in (e.g. now or an RFC3339 time)")
+	StatStreamCmd.Flags().Bool("watch", false, "Refresh the stats continuously until interrupted")
+	StatStreamCmd.Flags().Duration("interval", 5*time.Second, "Refresh interval used by --watch")
+}
+
+// defaultStatsWindow is the start of the counted window when only
+// --window-until is given
+const defaultStatsWindow = "1h"
+
+// statsWindow is the number of events ingested into a stream in a window
+type statsWindow struct {
+	Since      string `json:"since"`
+	Until      string `json:"until"`
+	EventCount int    `json:"event_count"`
+}
+
+func fetchStatsWindow(client *internalHTTP.HTTPClient, name, since, until string) (*statsWindow, error) {
+	query := fmt.Sprintf("select count(*) as count from %s", quoteIdentifier(name))
+	count, err := countQuery(client, query, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events since %s until %s: %w", since, until, err)
+	}
+	return &statsWindow{Since: since, Until: until, EventCount: count}, nil
+}
+
+// defaultAlertPreviewWindow is how far back alert rules are evaluated by default
+const defaultAlertPreviewWindow = "1h"
+
+// alertPreview is the result of evaluating an alert rule against recent events
+type alertPreview struct {
+	Name    string `json:"name"`
+	Query   string `json:"query,omitempty"`
+	Since   string `json:"since"`
+	Matches int    `json:"matches"`
+	Repeats int    `json:"repeats"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (p alertPreview) String() string {
+	if p.Error != "" {
+		return StyleBold.Render("unavailable") + " (" + p.Error + ")"
+	}
+	summary := fmt.Sprintf("%d matching events in the last %s", p.Matches, p.Since)
+	if p.Matches < p.Repeats {
+		summary += fmt.Sprintf(", fewer than the %d repeats needed to fire", p.Repeats)
+	}
+	return summary
+}
+
+// previewAlerts counts, for every alert, the events in the since window that
+// match its rule. Failures are reported per alert so one bad rule does not
+// hide the results of the others.
+func previewAlerts(client *internalHTTP.HTTPClient, stream string, alerts []Alert, since string) []alertPreview {
+	previews := make([]alertPreview, len(alerts))
+	for idx, alert := range alerts {
+		preview := alertPreview{
+			Name:    alert.Name,
+			Since:   since,
+			Repeats: alert.Rule.Config.Repeats,
+		}
+
+		query, err := alertPreviewQuery(stream, alert.Rule)
+		if err == nil {
+			preview.Query = query
+			preview.Matches, err = countQuery(client, query, since, "now")
+		}
+		if err != nil {
+			preview.Error = err.Error()
+		}
+		previews[idx] = preview
+	}
+	return previews
+}
+
+// alertPreviewQuery builds a count query for the events matching a column rule
+func alertPreviewQuery(stream string, rule Rule) (string, error) {
+	if rule.Type != "" && rule.Type != "column" {
+		return "", fmt.Errorf("preview is not supported for %s rules", rule.Type)
+	}
+	config := rule.Config
+	if config.Column == "" {
+		return "", errors.New("rule has no column")
+	}
+
+	column := quoteIdentifier(config.Column)
+	_, isString := config.Value.(string)
+
+	var condition string
+	switch config.Operator {
+	case "=", "!=", ">", ">=", "<", "<=":
+		value := sqlLiteral(config.Value)
+		if config.IgnoreCase && isString {
+			column, value = "lower("+column+")", "lower("+value+")"
+		}
+		condition = fmt.Sprintf("%s %s %s", column, config.Operator, value)
+	case "=%", "!%":
+		operator := "LIKE"
+		if config.IgnoreCase {
+			operator = "ILIKE"
+		}
+		if config.Operator == "!%" {
+			operator = "NOT " + operator
+		}
+		condition = fmt.Sprintf("%s %s %s", column, operator, sqlLiteral("%"+fmt.Sprint(config.Value)+"%"))
+	case "~", "!~":
+		operator := config.Operator
+		if config.IgnoreCase {
+			operator += "*"
+		}
+		condition = fmt.Sprintf("%s %s %s", column, operator, sqlLiteral(fmt.Sprint(config.Value)))
+	default:
+		return "", fmt.Errorf("unsupported operator %q", config.Operator)
+	}
+
+	return fmt.Sprintf("select count(*) as count from %s where %s", quoteIdentifier(stream), condition), nil
+}
+
+// countQuery runs a count(*) query and returns the resulting count
+func countQuery(client *internalHTTP.HTTPClient, query, since, until string) (int, error) {
+	records, err := queryRecords(client, query, since, until)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	count, ok := records[0]["count"].(float64)
+	if !ok {
+		return 0, errors.New("unexpected response for count query")
+	}
+	return int(count), nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case nil:
+		return "NULL"
+	default:
+		return fmt.Sprint(v)
+	}
 }
 
 var RemoveStreamCmd = &cobra.Command{
 	Use:     "remove stream-name",
 	Aliases: []string{"rm"},
-	Example: " pb stream remove backend_logs",
+	Example: "  pb stream remove backend_logs\n  pb stream remove backend_logs --yes",
 	Short:   "Delete a stream",
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -303,37 +814,23 @@ var RemoveStreamCmd = &cobra.Command{
 		}()
 
 		name := args[0]
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		req, err := client.NewRequest("DELETE", "logstream/"+name, nil)
+		confirmed, err := confirmDeletion(cmd, "stream", name, true)
 		if err != nil {
-			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
-
-		resp, err := client.Client.Do(req)
-		if err != nil {
-			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
-			return err
+		if !confirmed {
+			fmt.Printf("Aborted, stream %s was not deleted\n", name)
+			return nil
 		}
 
-		// Capture execution time
-		cmd.Annotations["executionTime"] = time.Since(startTime).String()
-
-		if resp.StatusCode == 200 {
-			fmt.Printf("Successfully deleted stream %s\n", StyleBold.Render(name))
-		} else {
-			bytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
-				return err
-			}
-			body := string(bytes)
-			defer resp.Body.Close()
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := deleteStream(&client, name); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
 		}
 
+		fmt.Printf("Successfully deleted stream %s\n", StyleBold.Render(name))
 		return nil
 	},
 }
@@ -341,7 +838,7 @@ var RemoveStreamCmd = &cobra.Command{
 // ListStreamCmd is the list command for streams
 var ListStreamCmd = &cobra.Command{
 	Use:     "list",
-	Example: "  pb stream list",
+	Example: "  pb stream list\n  pb stream list --prefix prod-\n  pb stream list --contains nginx --regex '-logs$'\n  pb stream list --all-info --output table",
 	Short:   "List all streams",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		// Capture start time
@@ -351,47 +848,119 @@ var ListStreamCmd = &cobra.Command{
 			cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		}()
 
+		// Compile the filter once before fetching so an invalid pattern fails fast
+		var nameFilter streamFilter
+		nameFilter.prefix, _ = cmd.Flags().GetString("prefix")
+		nameFilter.contains, _ = cmd.Flags().GetString("contains")
+		pattern, _ := cmd.Flags().GetString("regex")
+		if pattern != "" {
+			var err error
+			nameFilter.pattern, err = regexp.Compile(pattern)
+			if err != nil {
+				err = fmt.Errorf("invalid regex %q: %w", pattern, err)
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+		}
+
+		output, err := resolveOutput(outputText, outputJSON, outputTable)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		allInfo, _ := cmd.Flags().GetBool("all-info")
+
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 		req, err := client.NewRequest("GET", "logstream", nil)
 		if err != nil {
 			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
-		resp, err := client.Client.Do(req)
+		body, err := client.Do(req)
 		if err != nil {
-			// Capture error
-			cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			err = fmt.Errorf("failed to fetch streams: %w", err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 			return err
 		}
 
 		var streams []StreamListItem
-		if resp.StatusCode == 200 {
-			bytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
-				return err
+		if err := json.Unmarshal(body, &streams); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		streams = filterStreams(streams, nameFilter)
+
+		// the table always shows the details, plain text only with --all-info
+		if output == outputTable || (output == outputText && allInfo) {
+			printStreamTable(os.Stdout, fetchStreamDetails(&client, streams, defaultConcurrency))
+			return nil
+		}
+
+		if output == outputJSON {
+			var data interface{}
+			if allInfo {
+				data = fetchStreamDetails(&client, streams, defaultConcurrency)
+			} else {
+				names := make([]string, len(streams))
+				for idx, stream := range streams {
+					names[idx] = stream.Name
+				}
+				data = names
 			}
-			if err := json.Unmarshal(bytes, &streams); err != nil {
-				cmd.Annotations["errors"] = fmt.Sprintf("Error: %s", err.Error())
+			jsonData, err := json.MarshalIndent(data, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
 				return err
 			}
-
-			for _, stream := range streams {
-				fmt.Println(stream.Render())
-			}
-		} else {
-			fmt.Printf("Failed to fetch streams. Status Code: %s\n", resp.Status)
+			fmt.Println(string(jsonData))
+			return nil
 		}
 
+		for _, stream := range streams {
+			fmt.Println(stream.Render())
+		}
 		return nil
 	},
 }
 
 func init() {
-	// Add the --output flag with default value "text"
-	ListStreamCmd.Flags().StringP("output", "o", "text", "Output format: 'text' or 'json'")
+	ListStreamCmd.Flags().Bool("all-info", false, "Include event count, size and retention of every stream. Implied by --output table")
+	ListStreamCmd.Flags().String("prefix", "", "Only list streams whose name starts with the prefix")
+	ListStreamCmd.Flags().String("contains", "", "Only list streams whose name contains the substring")
+	ListStreamCmd.Flags().String("regex", "", "Only list streams whose name matches the regular expression")
+	addYesFlag(RemoveStreamCmd)
+}
+
+// streamFilter narrows stream names. The server has no filtering parameters
+// for the stream list, so all filters are applied client side. Empty filters
+// match every name.
+type streamFilter struct {
+	prefix   string
+	contains string
+	pattern  *regexp.Regexp
+}
+
+func (f streamFilter) matches(name string) bool {
+	if !strings.HasPrefix(name, f.prefix) {
+		return false
+	}
+	if !strings.Contains(name, f.contains) {
+		return false
+	}
+	return f.pattern == nil || f.pattern.MatchString(name)
+}
+
+// filterStreams returns the streams whose name matches every filter
+func filterStreams(streams []StreamListItem, filter streamFilter) []StreamListItem {
+	filtered := make([]StreamListItem, 0, len(streams))
+	for _, stream := range streams {
+		if filter.matches(stream.Name) {
+			filtered = append(filtered, stream)
+		}
+	}
+	return filtered
 }
 
 func fetchStats(client *internalHTTP.HTTPClient, name string) (data StreamStatsData, err error) {
@@ -414,9 +983,7 @@ func fetchStats(client *internalHTTP.HTTPClient, name string) (data StreamStatsD
 	if resp.StatusCode == 200 {
 		err = json.Unmarshal(bytes, &data)
 	} else {
-		body := string(bytes)
-		body = fmt.Sprintf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-		err = errors.New(body)
+		err = internalHTTP.NewAPIError(resp, bytes)
 	}
 	return
 }
@@ -441,9 +1008,7 @@ func fetchRetention(client *internalHTTP.HTTPClient, name string) (data StreamRe
 	if resp.StatusCode == 200 {
 		err = json.Unmarshal(bytes, &data)
 	} else {
-		body := string(bytes)
-		body = fmt.Sprintf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-		err = errors.New(body)
+		err = internalHTTP.NewAPIError(resp, bytes)
 	}
 	return
 }
@@ -468,51 +1033,47 @@ func fetchAlerts(client *internalHTTP.HTTPClient, name string) (data AlertConfig
 	if resp.StatusCode == 200 {
 		err = json.Unmarshal(bytes, &data)
 	} else {
-		body := string(bytes)
-		body = fmt.Sprintf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-		err = errors.New(body)
+		err = internalHTTP.NewAPIError(resp, bytes)
 	}
 	return
 }
 
 func fetchInfo(client *internalHTTP.HTTPClient, name string) (streamType string, err error) {
+	info, err := fetchStreamInfo(client, name)
+	return info.StreamType, err
+}
+
+func fetchStreamInfo(client *internalHTTP.HTTPClient, name string) (info StreamInfo, err error) {
 	// Create a new HTTP GET request
 	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("logstream/%s/info", name), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return info, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Execute the request
 	resp, err := client.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request execution failed: %w", err)
+		return info, fmt.Errorf("request execution failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the response body
 	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return info, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Check for successful status code
 	if resp.StatusCode == http.StatusOK {
-		// Define a struct to parse the response
-		var response struct {
-			StreamType string `json:"stream_type"`
-		}
-
 		// Unmarshal JSON into the struct
-		if err := json.Unmarshal(bytes, &response); err != nil {
-			return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		if err := json.Unmarshal(bytes, &info); err != nil {
+			return info, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
-
-		// Return the extracted stream_type
-		return response.StreamType, nil
+		return info, nil
 	}
 
 	// Handle non-200 responses
 	body := string(bytes)
 	errMsg := fmt.Sprintf("Request failed\nStatus Code: %d\nResponse: %s\n", resp.StatusCode, body)
-	return "", errors.New(errMsg)
+	return info, errors.New(errMsg)
 }
@@ -0,0 +1,126 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// streamPartitions is the partitioning of a stream as printed by
+// stream partition-info
+type streamPartitions struct {
+	CustomPartition    []string `json:"custom_partition"`
+	TimePartition      string   `json:"time_partition"`
+	TimePartitionLimit string   `json:"time_partition_limit"`
+}
+
+func newStreamPartitions(info StreamInfo) streamPartitions {
+	partitions := streamPartitions{
+		CustomPartition:    []string{},
+		TimePartition:      info.TimePartition,
+		TimePartitionLimit: info.TimePartitionLimit,
+	}
+	// the server keeps the custom partition columns comma separated
+	for _, column := range strings.Split(info.CustomPartition, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			partitions.CustomPartition = append(partitions.CustomPartition, column)
+		}
+	}
+	return partitions
+}
+
+// PartitionInfoStreamCmd prints the custom and time partition of a stream
+var PartitionInfoStreamCmd = &cobra.Command{
+	Use:     "partition-info [stream-name]",
+	Example: "  pb stream partition-info backend\n  pb stream partition-info backend --output json",
+	Short:   "Show the partition columns of a stream",
+	Long: `
+Show the custom partition columns, the time partition and the time partition
+limit of a stream, as set when the stream was created. Without a stream name
+the stream is picked from a list.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		output, err := resolveOutput(outputText, outputJSON)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		name, ok, err := streamArg(&client, args)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		info, err := fetchStreamInfo(&client, name)
+		if err != nil {
+			err = fmt.Errorf("failed to fetch info of stream %s: %w", name, err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		partitions := newStreamPartitions(info)
+
+		if output == outputJSON {
+			jsonData, err := json.MarshalIndent(partitions, "", "  ")
+			if err != nil {
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+				return err
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		printStreamPartitions(partitions)
+		return nil
+	},
+}
+
+func printStreamPartitions(partitions streamPartitions) {
+	custom := "none"
+	if len(partitions.CustomPartition) > 0 {
+		custom = strings.Join(partitions.CustomPartition, ", ")
+	}
+	timePartition := partitions.TimePartition
+	if timePartition == "" {
+		timePartition = "p_timestamp (ingestion time)"
+	}
+	limit := partitions.TimePartitionLimit
+	if limit == "" {
+		limit = "none"
+	}
+
+	fmt.Printf("  %-22s %s\n", "Custom Partition:", StyleBold.Render(custom))
+	fmt.Printf("  %-22s %s\n", "Time Partition:", StyleBold.Render(timePartition))
+	fmt.Printf("  %-22s %s\n", "Time Partition Limit:", StyleBold.Render(limit))
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestPartitionInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logstream/backend/info" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"created-at":"2024-05-01T10:00:00Z","time_partition":"event_time","time_partition_limit":"30d","custom_partition":"region, host","stream_type":"UserDefined"}`))
+	}))
+	defer server.Close()
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	defer func() { DefaultProfile = previous }()
+	useOutput(t, outputJSON)
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = PartitionInfoStreamCmd.RunE(PartitionInfoStreamCmd, []string{"backend"})
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	var got streamPartitions
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	want := streamPartitions{CustomPartition: []string{"region", "host"}, TimePartition: "event_time", TimePartitionLimit: "30d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestNewStreamPartitionsWithoutCustomPartition(t *testing.T) {
+	partitions := newStreamPartitions(StreamInfo{})
+	if partitions.CustomPartition == nil || len(partitions.CustomPartition) != 0 {
+		t.Errorf("expected an empty list of custom partition columns, got %#v", partitions.CustomPartition)
+	}
+}
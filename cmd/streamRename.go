@@ -0,0 +1,200 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+// RenameStreamCmd clones the configuration of a stream to a new name
+var RenameStreamCmd = &cobra.Command{
+	Use:     "rename stream-name new-name",
+	Example: "  pb stream rename backend_logs backend",
+	Short:   "Copy the configuration of a stream to a new name",
+	Long: `
+Create a new stream with the configuration of an existing one: its static
+schema, time and custom partitions, retention policy and alerts.
+
+Parseable can't move data between streams, so the existing stream and its data
+are left untouched. Ingest into the new stream from now on, migrate the old
+data separately if needed and remove the old stream with pb stream remove.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		source, target := args[0], args[1]
+		if source == target {
+			err := fmt.Errorf("stream %s can't be renamed to itself", source)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		if err := cloneStream(&client, source, target); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		fmt.Printf("Created stream %s with the configuration of %s\n", StyleBold.Render(target), StyleBold.Render(source))
+		fmt.Printf("The data of %s was not moved. Migrate it separately if needed, then remove the stream with pb stream remove %s\n", source, source)
+		return nil
+	},
+}
+
+// cloneStream creates target with the configuration of source. If any part
+// of the configuration can't be applied target is deleted again.
+func cloneStream(client *internalHTTP.HTTPClient, source, target string) error {
+	info, err := fetchStreamInfo(client, source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch info of stream %s: %w", source, err)
+	}
+	retention, err := fetchRetention(client, source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch retention of stream %s: %w", source, err)
+	}
+	alerts, err := fetchAlertsOrEmpty(client, source)
+	if err != nil {
+		return err
+	}
+
+	opts := streamOptions{
+		customPartition:    info.CustomPartition,
+		timePartition:      info.TimePartition,
+		timePartitionLimit: info.TimePartitionLimit,
+	}
+	if info.StaticSchemaFlag {
+		opts.schema, err = fetchStaticSchema(client, source)
+		if err != nil {
+			return fmt.Errorf("failed to fetch schema of stream %s: %w", source, err)
+		}
+	}
+
+	if err := createStream(client, target, opts); err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", target, err)
+	}
+	if len(retention) > 0 {
+		if err := putRetention(client, target, retention); err != nil {
+			return rollbackStream(client, target, fmt.Errorf("failed to set retention on stream %s: %w", target, err))
+		}
+	}
+	if len(alerts.Alerts) > 0 {
+		if err := putAlerts(client, target, alerts); err != nil {
+			return rollbackStream(client, target, fmt.Errorf("failed to set alerts on stream %s: %w", target, err))
+		}
+	}
+	return nil
+}
+
+// fetchStaticSchema returns the schema of the stream in the format accepted
+// when creating a stream with a static schema
+func fetchStaticSchema(client *internalHTTP.HTTPClient, name string) ([]byte, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("logstream/%s/schema", name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, internalHTTP.NewAPIError(resp, bytes)
+	}
+	return staticSchemaFromArrow(bytes)
+}
+
+// staticSchemaFromArrow converts a stream schema, as returned by the server
+// in Arrow's JSON representation, to a static schema. Fields added by the
+// server, such as p_timestamp, are left out.
+func staticSchemaFromArrow(data []byte) ([]byte, error) {
+	var schema struct {
+		Fields []struct {
+			Name     string          `json:"name"`
+			DataType json.RawMessage `json:"data_type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	type staticField struct {
+		Name     string `json:"name"`
+		DataType string `json:"data_type"`
+	}
+	fields := []staticField{}
+	for _, field := range schema.Fields {
+		if strings.HasPrefix(field.Name, "p_") {
+			continue
+		}
+		dataType, err := staticDataType(field.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		fields = append(fields, staticField{Name: field.Name, DataType: dataType})
+	}
+	return json.Marshal(map[string]interface{}{"fields": fields})
+}
+
+// staticDataType maps an Arrow data type to a static schema type. Simple
+// types are encoded as a string, parametrised ones like timestamps as an
+// object keyed by the type name.
+func staticDataType(raw json.RawMessage) (string, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		var parametrised map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &parametrised); err != nil || len(parametrised) != 1 {
+			return "", fmt.Errorf("unsupported data type %s", raw)
+		}
+		for key := range parametrised {
+			name = key
+		}
+	}
+
+	switch name {
+	case "Utf8", "LargeUtf8":
+		return "string", nil
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64":
+		return "int", nil
+	case "Float16", "Float32", "Float64":
+		return "float", nil
+	case "Boolean":
+		return "boolean", nil
+	case "Timestamp", "Date32", "Date64":
+		return "datetime", nil
+	default:
+		return "", fmt.Errorf("unsupported data type %s", raw)
+	}
+}
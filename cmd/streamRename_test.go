@@ -0,0 +1,89 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestStaticSchemaFromArrow(t *testing.T) {
+	arrow := `{"fields": [
+		{"name": "p_timestamp", "data_type": {"Timestamp": ["Millisecond", null]}, "nullable": true},
+		{"name": "host", "data_type": "Utf8", "nullable": true},
+		{"name": "status", "data_type": "Int64", "nullable": true},
+		{"name": "latency", "data_type": "Float64", "nullable": true},
+		{"name": "cached", "data_type": "Boolean", "nullable": true},
+		{"name": "time", "data_type": {"Timestamp": ["Millisecond", null]}, "nullable": true}
+	], "metadata": {}}`
+
+	got, err := staticSchemaFromArrow([]byte(arrow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"fields":[{"name":"host","data_type":"string"},{"name":"status","data_type":"int"},` +
+		`{"name":"latency","data_type":"float"},{"name":"cached","data_type":"boolean"},{"name":"time","data_type":"datetime"}]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestStaticSchemaFromArrowUnsupported(t *testing.T) {
+	arrow := `{"fields": [{"name": "tags", "data_type": {"List": {"name": "item", "data_type": "Utf8"}}}]}`
+	if _, err := staticSchemaFromArrow([]byte(arrow)); err == nil {
+		t.Errorf("expected an error for a list field")
+	}
+}
+
+func TestStreamInfoStaticSchemaFlag(t *testing.T) {
+	for _, data := range []string{`{"static_schema_flag": true}`, `{"static_schema_flag": "true"}`} {
+		var info StreamInfo
+		if err := json.Unmarshal([]byte(data), &info); err != nil || !info.StaticSchemaFlag {
+			t.Errorf("%s: got %v, err %v", data, info.StaticSchemaFlag, err)
+		}
+	}
+}
+
+func TestCloneStreamWithoutAlerts(t *testing.T) {
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/logstream/web/info":
+			_, _ = w.Write([]byte(`{}`))
+		case "GET /api/v1/logstream/web/retention":
+			_, _ = w.Write([]byte(`[]`))
+		case "PUT /api/v1/logstream/frontend":
+			created = true
+		default:
+			// servers answer 404 for streams without alerts
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+
+	if err := cloneStream(&client, "web", "frontend"); err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Errorf("stream frontend was not created")
+	}
+}
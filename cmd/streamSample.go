@@ -0,0 +1,106 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+	"pb/pkg/timerange"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	sampleLimitFlag    = "limit"
+	defaultSampleLimit = 10
+	defaultSampleStart = "5m"
+)
+
+// SampleStreamCmd prints the most recent records of a stream
+var SampleStreamCmd = &cobra.Command{
+	Use:     "sample [stream-name]",
+	Example: "  pb stream sample backend\n  pb stream sample app-logs --limit 3 --from 1h",
+	Short:   "Print a few recent records of a stream",
+	Long: `
+Print the most recent records of a stream as JSON, to get a quick look at its
+fields. Without a stream name the stream is picked from a list.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		limit, _ := cmd.Flags().GetInt(sampleLimitFlag)
+		if limit <= 0 {
+			err := fmt.Errorf("--%s must be positive, got %d", sampleLimitFlag, limit)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		from, _ := cmd.Flags().GetString(startFlag)
+		to, _ := cmd.Flags().GetString(endFlag)
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		name, ok, err := streamArg(&client, args)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		now := time.Now()
+		start := timerange.ResolveIn(from, now, false, TimeZone.Location())
+		end := timerange.ResolveIn(to, now, true, TimeZone.Location())
+		records, err := queryRecords(&client, sampleQuery(name, limit), start, end)
+		if err != nil {
+			err = fmt.Errorf("failed to sample stream %s: %w", name, err)
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+
+		if len(records) == 0 {
+			fmt.Fprintf(os.Stderr, "No records in stream %s between %s and %s\n", name, from, to)
+			return nil
+		}
+		formatRecordTimestamps(records, outputTimeFormat(""))
+		encoded, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("Error: %s", err.Error())
+			return err
+		}
+		fmt.Println(string(encoded))
+		return nil
+	},
+}
+
+func init() {
+	SampleStreamCmd.Flags().IntP(sampleLimitFlag, "n", defaultSampleLimit, "Number of records to print")
+	SampleStreamCmd.Flags().StringP(startFlag, startFlagShort, defaultSampleStart, "Start time of the window to sample. Accepts a duration, RFC3339 time or today, yesterday, this-week, last-24h")
+	SampleStreamCmd.Flags().StringP(endFlag, endFlagShort, defaultEnd, "End time of the window to sample. Accepts now, RFC3339 time or today, yesterday, this-week, last-24h")
+}
+
+// sampleQuery selects the latest limit records of the stream
+func sampleQuery(stream string, limit int) string {
+	return fmt.Sprintf("select * from %s order by p_timestamp desc limit %d", quoteIdentifier(stream), limit)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestSampleStream(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte(`[{"level":"info","msg":"started"}]`))
+	}))
+	defer server.Close()
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	defer func() { DefaultProfile = previous }()
+	setFlags(t, SampleStreamCmd, map[string]string{sampleLimitFlag: "3"})
+
+	out := captureStdout(t, func() {
+		if err := SampleStreamCmd.RunE(SampleStreamCmd, []string{"app-logs"}); err != nil {
+			t.Errorf("sample: %v", err)
+		}
+	})
+
+	if want := `select * from "app-logs" order by p_timestamp desc limit 3`; payload["query"] != want {
+		t.Errorf("query = %q, want %q", payload["query"], want)
+	}
+	if payload["startTime"] != defaultSampleStart || payload["endTime"] != defaultEnd {
+		t.Errorf("unexpected window %s to %s", payload["startTime"], payload["endTime"])
+	}
+	if !strings.Contains(string(out), `"msg": "started"`) {
+		t.Errorf("records were not pretty printed: %s", out)
+	}
+}
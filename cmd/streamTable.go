@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// streamDetails is a stream with its stats and retention, as listed by
+// stream list --all-info
+type streamDetails struct {
+	Name      string              `json:"name"`
+	Events    int                 `json:"events"`
+	Ingested  string              `json:"ingested"`
+	Storage   string              `json:"storage"`
+	Retention StreamRetentionData `json:"retention"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// fetchStreamDetails fetches the stats and retention of every stream with at
+// most concurrency streams in flight. Failures are recorded per stream so
+// one unreadable stream does not hide the others. Results follow the order
+// of streams.
+func fetchStreamDetails(client *internalHTTP.HTTPClient, streams []StreamListItem, concurrency int) []streamDetails {
+	details := make([]streamDetails, len(streams))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for idx, stream := range streams {
+		wg.Add(1)
+		go func(idx int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			detail := streamDetails{Name: name, Retention: StreamRetentionData{}}
+			stats, err := fetchStats(client, name)
+			if err == nil {
+				detail.Events = stats.Ingestion.Count
+				detail.Ingested = stats.Ingestion.Size
+				detail.Storage = stats.Storage.Size
+				var retention StreamRetentionData
+				retention, err = fetchRetention(client, name)
+				if retention != nil {
+					detail.Retention = retention
+				}
+			}
+			if err != nil {
+				detail.Error = err.Error()
+			}
+			details[idx] = detail
+		}(idx, stream.Name)
+	}
+	wg.Wait()
+
+	return details
+}
+
+// retentionSummary describes a retention policy in a single table cell
+func retentionSummary(retention StreamRetentionData) string {
+	if len(retention) == 0 {
+		return "-"
+	}
+	rules := make([]string, len(retention))
+	for idx, rule := range retention {
+		rules[idx] = fmt.Sprintf("%s after %s", rule.Action, rule.Duration)
+	}
+	return strings.Join(rules, ", ")
+}
+
+func printStreamTable(out io.Writer, details []streamDetails) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Events", "Ingested", "Storage", "Retention"})
+	table.SetAutoWrapText(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT,
+	})
+
+	for _, detail := range details {
+		if detail.Error != "" {
+			table.Append([]string{detail.Name, "-", "-", "-", common.Red + detail.Error + common.Reset})
+			continue
+		}
+		table.Append([]string{
+			detail.Name,
+			strconv.Itoa(detail.Events),
+			detail.Ingested,
+			detail.Storage,
+			retentionSummary(detail.Retention),
+		})
+	}
+	table.Render()
+}
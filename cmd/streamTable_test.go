@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+func TestFetchStreamDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/logstream/backend/stats":
+			_, _ = w.Write([]byte(`{"ingestion":{"count":1200,"size":"4096 Bytes"},"storage":{"size":"1024 Bytes"}}`))
+		case "/api/v1/logstream/backend/retention":
+			_, _ = w.Write([]byte(`[{"description":"delete after 30d","action":"delete","duration":"30d"}]`))
+		case "/api/v1/logstream/frontend/stats":
+			_, _ = w.Write([]byte(`{"ingestion":{"count":5,"size":"10 Bytes"},"storage":{"size":"8 Bytes"}}`))
+		case "/api/v1/logstream/frontend/retention":
+			_, _ = w.Write([]byte(`null`))
+		default:
+			http.Error(w, "stream not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := internalHTTP.DefaultClient(&config.Profile{URL: server.URL})
+	streams := []StreamListItem{{Name: "backend"}, {Name: "frontend"}, {Name: "missing"}}
+	details := fetchStreamDetails(&client, streams, 2)
+
+	if len(details) != 3 || details[0].Name != "backend" || details[2].Name != "missing" {
+		t.Fatalf("details do not follow the stream order: %+v", details)
+	}
+	if details[0].Events != 1200 || details[0].Storage != "1024 Bytes" || retentionSummary(details[0].Retention) != "delete after 30d" {
+		t.Errorf("unexpected details for backend: %+v", details[0])
+	}
+	if details[1].Error != "" || retentionSummary(details[1].Retention) != "-" {
+		t.Errorf("unexpected details for frontend: %+v", details[1])
+	}
+	if !strings.Contains(details[2].Error, "stream not found") {
+		t.Errorf("expected an error for the missing stream, got %+v", details[2])
+	}
+
+	var out bytes.Buffer
+	printStreamTable(&out, details)
+	for _, want := range []string{"NAME", "RETENTION", "1200", "delete after 30d", "stream not found"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table is missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestFilterStreams(t *testing.T) {
+	streams := []StreamListItem{
+		{Name: "prod-nginx-logs"},
+		{Name: "prod-app-logs"},
+		{Name: "staging-nginx-logs"},
+		{Name: "prod-nginx-metrics"},
+	}
+
+	tests := []struct {
+		name   string
+		filter streamFilter
+		want   []string
+	}{
+		{"no filter", streamFilter{}, []string{"prod-nginx-logs", "prod-app-logs", "staging-nginx-logs", "prod-nginx-metrics"}},
+		{"prefix", streamFilter{prefix: "prod-"}, []string{"prod-nginx-logs", "prod-app-logs", "prod-nginx-metrics"}},
+		{"contains", streamFilter{contains: "nginx"}, []string{"prod-nginx-logs", "staging-nginx-logs", "prod-nginx-metrics"}},
+		{"regex", streamFilter{pattern: regexp.MustCompile(`-logs$`)}, []string{"prod-nginx-logs", "prod-app-logs", "staging-nginx-logs"}},
+		{"combined", streamFilter{prefix: "prod-", contains: "nginx", pattern: regexp.MustCompile(`-logs$`)}, []string{"prod-nginx-logs"}},
+		{"no match", streamFilter{prefix: "dev-"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		for _, stream := range filterStreams(streams, tt.filter) {
+			got = append(got, stream.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// streamInfoResponses are the responses of a fake server for stream info
+var streamInfoResponses = map[string]string{
+	"/api/v1/logstream/backend/stats":     `{"ingestion":{"count":1200,"format":"json","size":"4096 Bytes"},"storage":{"format":"parquet","size":"1024 Bytes"},"stream":"backend","time":"2024-05-01T00:00:00Z"}`,
+	"/api/v1/logstream/backend/retention": `[{"description":"delete after 30 days","action":"delete","duration":"30d"}]`,
+	"/api/v1/logstream/backend/alert":     `{"version":"v1","alerts":[{"name":"errors","message":"too many errors","rule":{"type":"column","config":{"column":"status","operator":"=","ignoreCase":false,"value":500,"repeats":3}},"targets":[{"type":"webhook","endpoint":"https://hooks.example.com/pb","headers":{"X-Token":"abc"},"skip_tls_check":true,"repeat":{"interval":"200s","times":5}}]}]}`,
+	"/api/v1/logstream/backend/info":      `{"created-at":"2024-05-01T00:00:00Z","stream_type":"UserDefined"}`,
+}
+
+// captureStdout returns what run writes to stdout
+func captureStdout(t *testing.T, run func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = previous }()
+
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	run()
+	w.Close()
+	return <-out
+}
+
+func TestStreamInfoJSONGolden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := streamInfoResponses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL, Username: "admin", Password: "admin"}
+	defer func() { DefaultProfile = previous }()
+	useOutput(t, outputJSON)
+
+	var runErr error
+	got := captureStdout(t, func() {
+		runErr = StatStreamCmd.RunE(StatStreamCmd, []string{"backend"})
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	golden := filepath.Join("testdata", "stream_info.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("stream info output does not match %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestStreamInfoPreviewAlertsSinceCountsNoWindow(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/query" {
+			var body struct {
+				Query string `json:"query"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			queries = append(queries, body.Query)
+			_, _ = w.Write([]byte(`[{"count":3}]`))
+			return
+		}
+		body, ok := streamInfoResponses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	defer func() { DefaultProfile = previous }()
+	useOutput(t, outputJSON)
+
+	flags := StatStreamCmd.Flags()
+	for name, value := range map[string]string{"preview-alerts": "true", "since": "24h"} {
+		previousValue := flags.Lookup(name).Value.String()
+		if err := flags.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = flags.Set(name, previousValue)
+			flags.Lookup(name).Changed = false
+		})
+	}
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = StatStreamCmd.RunE(StatStreamCmd, []string{"backend"})
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	var report streamInfoReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if report.Window != nil {
+		t.Errorf("--since only sets the alert preview window, got a counted window %+v", report.Window)
+	}
+	if len(report.AlertPreview) != 1 || report.AlertPreview[0].Since != "24h" || report.AlertPreview[0].Matches != 3 {
+		t.Errorf("unexpected alert preview %+v", report.AlertPreview)
+	}
+	if len(queries) != 1 {
+		t.Errorf("expected only the alert preview query, got %q", queries)
+	}
+}
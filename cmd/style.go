@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // styling for cli outputs
@@ -37,3 +38,8 @@ var (
 
 	StyleBold = lipgloss.NewStyle().Bold(true)
 )
+
+// DisableColors renders all styles as plain text
+func DisableColors() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
@@ -40,14 +40,22 @@ var TailCmd = &cobra.Command{
 	Short:   "Stream live events from a log stream",
 	Args:    cobra.ExactArgs(1),
 	PreRunE: PreRunDefaultProfile,
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		profile := DefaultProfile
-		return tail(profile, name)
+		timeFormat, err := cmd.Flags().GetString(timeFormatFlag)
+		if err != nil {
+			return err
+		}
+		return tail(profile, name, outputTimeFormat(timeFormat))
 	},
 }
 
-func tail(profile config.Profile, stream string) error {
+func init() {
+	TailCmd.Flags().String(timeFormatFlag, "", "Reformat timestamp fields using a Go time layout (e.g. '2006-01-02 15:04:05') or 'relative'")
+}
+
+func tail(profile config.Profile, stream string, timeFormat string) error {
 	payload, _ := json.Marshal(struct {
 		Stream string `json:"stream"`
 	}{
@@ -88,7 +96,25 @@ func tail(profile config.Profile, stream string) error {
 		}
 		var buf bytes.Buffer
 		array.RecordToJSON(record, &buf)
-		fmt.Println(buf.String())
+		if timeFormat == "" {
+			fmt.Println(buf.String())
+			continue
+		}
+		printWithTimeFormat(&buf, timeFormat)
+	}
+}
+
+// printWithTimeFormat prints each JSON event in buf with its timestamp fields reformatted
+func printWithTimeFormat(buf *bytes.Buffer, timeFormat string) {
+	decoder := json.NewDecoder(buf)
+	for {
+		var event map[string]interface{}
+		if err := decoder.Decode(&event); err != nil {
+			return
+		}
+		formatRecordTimestamps([]map[string]interface{}{event}, timeFormat)
+		line, _ := json.Marshal(event)
+		fmt.Println(string(line))
 	}
 }
 
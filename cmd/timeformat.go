@@ -0,0 +1,132 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	timeFormatFlag = "time-format"
+
+	// relativeTimeFormat renders timestamps relative to now, e.g. "3 minutes ago"
+	relativeTimeFormat = "relative"
+)
+
+// timestampFields are the event fields reformatted by --time-format
+var timestampFields = []string{"p_timestamp", "source_time"}
+
+// layouts accepted when parsing timestamps sent by the server
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// formatTimestamp reformats a single timestamp string. Values that
+// are not recognized as timestamps are returned unchanged.
+func formatTimestamp(value string, layout string) string {
+	for _, l := range timestampLayouts {
+		// timestamps without zone information are in UTC
+		t, err := time.ParseInLocation(l, value, time.UTC)
+		if err != nil {
+			continue
+		}
+		if layout == relativeTimeFormat {
+			return humanize.Time(t)
+		}
+		return t.In(outputLocation()).Format(layout)
+	}
+	return value
+}
+
+// formatRecordTimestamps reformats the known timestamp fields of each record in place
+func formatRecordTimestamps(records []map[string]interface{}, layout string) {
+	if layout == "" {
+		return
+	}
+	for _, record := range records {
+		for _, field := range timestampFields {
+			if value, ok := record[field].(string); ok {
+				record[field] = formatTimestamp(value, layout)
+			}
+		}
+	}
+}
+
+// TimeZone is the zone given by the global --tz flag
+var TimeZone TimeZoneFlag
+
+// zonedTimeLayout renders timestamps with their offset when --tz is given
+// without --time-format
+const zonedTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// TimeZoneFlag is an IANA time zone name such as Europe/Berlin. --from and
+// --to are read in the zone and timestamps are printed in it.
+type TimeZoneFlag struct {
+	loc *time.Location
+}
+
+func (z *TimeZoneFlag) String() string {
+	return z.Location().String()
+}
+
+func (z *TimeZoneFlag) Set(value string) error {
+	if value == "" {
+		return errors.New("time zone can't be empty")
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return fmt.Errorf("unknown time zone %q, use an IANA name such as Europe/Berlin", value)
+	}
+	z.loc = loc
+	return nil
+}
+
+func (z *TimeZoneFlag) Type() string {
+	return "zone"
+}
+
+// Location returns the zone, UTC when --tz is not given
+func (z *TimeZoneFlag) Location() *time.Location {
+	if z.loc == nil {
+		return time.UTC
+	}
+	return z.loc
+}
+
+// outputLocation is the zone timestamps are printed in: the --tz zone, or the
+// local zone of the machine when it is not given
+func outputLocation() *time.Location {
+	if TimeZone.loc == nil {
+		return time.Local
+	}
+	return TimeZone.loc
+}
+
+// outputTimeFormat returns the layout timestamps are printed with. Without
+// --time-format, timestamps are left as sent by the server unless --tz asks
+// for them in another zone.
+func outputTimeFormat(timeFormat string) string {
+	if timeFormat == "" && TimeZone.loc != nil {
+		return zonedTimeLayout
+	}
+	return timeFormat
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+)
+
+// useTimeZone sets the --tz flag for the duration of the test
+func useTimeZone(t *testing.T, zone string) {
+	t.Helper()
+	previous := TimeZone
+	if err := TimeZone.Set(zone); err != nil {
+		t.Skipf("time zone database not available: %s", err)
+	}
+	t.Cleanup(func() { TimeZone = previous })
+}
+
+func TestTimestampsInTimeZone(t *testing.T) {
+	tests := []struct {
+		zone  string
+		value string
+		want  string
+	}{
+		{"America/New_York", "2024-01-15T14:00:00.000Z", "2024-01-15T09:00:00.000-05:00"},
+		// daylight saving time
+		{"America/New_York", "2024-07-15T13:00:00.000Z", "2024-07-15T09:00:00.000-04:00"},
+		{"Asia/Kolkata", "2024-07-15T13:00:00.000", "2024-07-15T18:30:00.000+05:30"},
+		{"UTC", "2024-07-15T13:00:00.000Z", "2024-07-15T13:00:00.000Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.zone, func(t *testing.T) {
+			useTimeZone(t, test.zone)
+			if got := formatTimestamp(test.value, outputTimeFormat("")); got != test.want {
+				t.Errorf("formatTimestamp(%s) in %s = %s, want %s", test.value, test.zone, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOutputTimeFormat(t *testing.T) {
+	if got := outputTimeFormat(""); got != "" {
+		t.Errorf("timestamps should be left unchanged without --tz, got layout %q", got)
+	}
+	useTimeZone(t, "Europe/Berlin")
+	if got := outputTimeFormat("relative"); got != "relative" {
+		t.Errorf("--time-format should take precedence over --tz, got %q", got)
+	}
+}
+
+func TestTimeZoneFlagRejectsUnknownZones(t *testing.T) {
+	var zone TimeZoneFlag
+	for _, value := range []string{"", "Mars/Olympus_Mons"} {
+		if err := zone.Set(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+	if zone.String() != "UTC" {
+		t.Errorf("default zone = %s, want UTC", zone.String())
+	}
+}
@@ -20,12 +20,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slices"
 )
@@ -59,7 +61,7 @@ var addUser = &cobra.Command{
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 		users, err := fetchUsers(&client)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
@@ -67,7 +69,7 @@ var addUser = &cobra.Command{
 			return user.ID == name
 		}) {
 			fmt.Println("user already exists")
-			cmd.Annotations["error"] = "user already exists"
+			cmd.Annotations[common.ErrorAnnotation] = "user already exists"
 			return nil
 		}
 
@@ -78,7 +80,7 @@ var addUser = &cobra.Command{
 		// fetch the role names on the server
 		var rolesOnServer []string
 		if err := fetchRoles(&client, &rolesOnServer); err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 		rolesOnServerArr := strings.Join(rolesOnServer, " ")
@@ -88,7 +90,7 @@ var addUser = &cobra.Command{
 			rolesToSetArr[idx] = strings.TrimSpace(role)
 			if !strings.Contains(rolesOnServerArr, rolesToSetArr[idx]) {
 				fmt.Printf("role %s doesn't exist, please create a role using pb role add %s\n", rolesToSetArr[idx], rolesToSetArr[idx])
-				cmd.Annotations["error"] = fmt.Sprintf("role %s doesn't exist", rolesToSetArr[idx])
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("role %s doesn't exist", rolesToSetArr[idx])
 				return nil
 			}
 		}
@@ -98,31 +100,17 @@ var addUser = &cobra.Command{
 		putBody = bytes.NewBuffer([]byte(putBodyJSON))
 		req, err := client.NewRequest("POST", "user/"+name, putBody)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		resp, err := client.Client.Do(req)
+		body, err := client.Do(req)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		bytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			cmd.Annotations["error"] = err.Error()
-			return err
-		}
-		body := string(bytes)
-		defer resp.Body.Close()
-
-		if resp.StatusCode == 200 {
-			fmt.Printf("Added user: %s \nPassword is: %s\nRole(s) assigned: %s\n", name, body, rolesToSet)
-			cmd.Annotations["error"] = "none"
-		} else {
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-			cmd.Annotations["error"] = fmt.Sprintf("request failed with status code %s", resp.Status)
-		}
+		fmt.Printf("Added user: %s \nPassword is: %s\nRole(s) assigned: %s\n", name, body, rolesToSet)
 
 		return nil
 	},
@@ -136,7 +124,7 @@ var AddUserCmd = func() *cobra.Command {
 var RemoveUserCmd = &cobra.Command{
 	Use:     "remove user-name",
 	Aliases: []string{"rm"},
-	Example: "  pb user remove bob",
+	Example: "  pb user remove bob\n  pb user remove bob --yes",
 	Short:   "Delete a user",
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -147,28 +135,30 @@ var RemoveUserCmd = &cobra.Command{
 		}()
 
 		name := args[0]
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		req, err := client.NewRequest("DELETE", "user/"+name, nil)
+		confirmed, err := confirmDeletion(cmd, "user", name, false)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
+		if !confirmed {
+			fmt.Printf("Aborted, user %s was not deleted\n", name)
+			return nil
+		}
 
-		resp, err := client.Client.Do(req)
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		req, err := client.NewRequest("DELETE", "user/"+name, nil)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		if resp.StatusCode == 200 {
-			fmt.Printf("Removed user %s\n", StyleBold.Render(name))
-			cmd.Annotations["error"] = "none"
-		} else {
-			body, _ := io.ReadAll(resp.Body)
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, string(body))
-			cmd.Annotations["error"] = fmt.Sprintf("request failed with status code %s", resp.Status)
+		if _, err := client.Do(req); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
 		}
 
+		fmt.Printf("Removed user %s\n", StyleBold.Render(name))
+
 		return nil
 	},
 }
@@ -194,7 +184,7 @@ var SetUserRoleCmd = &cobra.Command{
 		client := internalHTTP.DefaultClient(&DefaultProfile)
 		users, err := fetchUsers(&client)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
@@ -202,7 +192,7 @@ var SetUserRoleCmd = &cobra.Command{
 			return user.ID == name
 		}) {
 			fmt.Printf("user doesn't exist. Please create the user with `pb user add %s`\n", name)
-			cmd.Annotations["error"] = "user does not exist"
+			cmd.Annotations[common.ErrorAnnotation] = "user does not exist"
 			return nil
 		}
 
@@ -210,7 +200,7 @@ var SetUserRoleCmd = &cobra.Command{
 		rolesToSetArr := strings.Split(rolesToSet, ",")
 		var rolesOnServer []string
 		if err := fetchRoles(&client, &rolesOnServer); err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 		rolesOnServerArr := strings.Join(rolesOnServer, " ")
@@ -219,7 +209,7 @@ var SetUserRoleCmd = &cobra.Command{
 			rolesToSetArr[idx] = strings.TrimSpace(role)
 			if !strings.Contains(rolesOnServerArr, rolesToSetArr[idx]) {
 				fmt.Printf("role %s doesn't exist, please create a role using `pb role add %s`\n", rolesToSetArr[idx], rolesToSetArr[idx])
-				cmd.Annotations["error"] = fmt.Sprintf("role %s doesn't exist", rolesToSetArr[idx])
+				cmd.Annotations[common.ErrorAnnotation] = fmt.Sprintf("role %s doesn't exist", rolesToSetArr[idx])
 				return nil
 			}
 		}
@@ -229,31 +219,16 @@ var SetUserRoleCmd = &cobra.Command{
 		putBody = bytes.NewBuffer([]byte(putBodyJSON))
 		req, err := client.NewRequest("PUT", "user/"+name+"/role", putBody)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		resp, err := client.Client.Do(req)
-		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+		if _, err := client.Do(req); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		bytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			cmd.Annotations["error"] = err.Error()
-			return err
-		}
-		body := string(bytes)
-		defer resp.Body.Close()
-
-		if resp.StatusCode == 200 {
-			fmt.Printf("Added role(s) %s to user %s\n", rolesToSet, name)
-			cmd.Annotations["error"] = "none"
-		} else {
-			fmt.Printf("Request Failed\nStatus Code: %s\nResponse: %s\n", resp.Status, body)
-			cmd.Annotations["error"] = fmt.Sprintf("request failed with status code %s", resp.Status)
-		}
+		fmt.Printf("Added role(s) %s to user %s\n", rolesToSet, name)
 
 		return nil
 	},
@@ -270,45 +245,22 @@ var ListUserCmd = &cobra.Command{
 			cmd.Annotations["executionTime"] = time.Since(startTime).String()
 		}()
 
-		client := internalHTTP.DefaultClient(&DefaultProfile)
-		users, err := fetchUsers(&client)
+		outputFormat, err := resolveOutput(outputText, outputJSON)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		roleResponses := make([]struct {
-			data []string
-			err  error
-		}, len(users))
-
-		wsg := sync.WaitGroup{}
-		for idx, user := range users {
-			wsg.Add(1)
-			out := &roleResponses[idx]
-			userID := user.ID
-			client := &client
-			go func() {
-				var userRolesData UserRoleData
-				userRolesData, out.err = fetchUserRoles(client, userID)
-				if out.err == nil {
-					for role := range userRolesData {
-						out.data = append(out.data, role)
-					}
-				}
-				wsg.Done()
-			}()
-		}
-
-		wsg.Wait()
-
-		outputFormat, err := cmd.Flags().GetString("output")
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		users, err := fetchUsers(&client)
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 			return err
 		}
 
-		if outputFormat == "json" {
+		roleResponses := fetchRolesOfUsers(&client, users)
+
+		if outputFormat == outputJSON {
 			usersWithRoles := make([]map[string]interface{}, len(users))
 			for idx, user := range users {
 				usersWithRoles[idx] = map[string]interface{}{
@@ -318,48 +270,66 @@ var ListUserCmd = &cobra.Command{
 			}
 			jsonOutput, err := json.MarshalIndent(usersWithRoles, "", "  ")
 			if err != nil {
-				cmd.Annotations["error"] = err.Error()
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
 				return fmt.Errorf("failed to marshal JSON output: %w", err)
 			}
 			fmt.Println(string(jsonOutput))
-			cmd.Annotations["error"] = "none"
 			return nil
 		}
 
-		if outputFormat == "text" {
-			fmt.Println()
-			for idx, user := range users {
-				roles := roleResponses[idx]
-				if roles.err == nil {
-					roleList := strings.Join(roles.data, ", ")
-					fmt.Printf("%s, %s\n", user.ID, roleList)
-				} else {
-					fmt.Printf("%s, error: %v\n", user.ID, roles.err)
-				}
-			}
-			fmt.Println()
-			cmd.Annotations["error"] = "none"
-			return nil
+		printUserRoles(os.Stdout, users, roleResponses)
+		return nil
+	},
+}
+
+// printUserRoles prints one line per user with the user's roles, or the
+// error fetching them
+func printUserRoles(out io.Writer, users []UserData, roleResponses []userRoles) {
+	for idx, user := range users {
+		roles := roleResponses[idx]
+		switch {
+		case roles.err != nil:
+			fmt.Fprintf(out, "%s, error: %v\n", user.ID, roles.err)
+		case len(roles.data) == 0:
+			fmt.Fprintln(out, user.ID)
+		default:
+			fmt.Fprintf(out, "%s, %s\n", user.ID, strings.Join(roles.data, ", "))
 		}
+	}
+}
+
+// userRoles holds the role names of a user, or the error fetching them
+type userRoles struct {
+	data []string
+	err  error
+}
 
-		fmt.Println()
-		for idx, user := range users {
-			roles := roleResponses[idx]
-			fmt.Print("• ")
-			fmt.Println(StandardStyleBold.Bold(true).Render(user.ID))
-			if roles.err == nil {
-				for _, role := range roles.data {
-					fmt.Println(lipgloss.NewStyle().PaddingLeft(3).Render(role))
+// fetchRolesOfUsers fetches the roles of all users concurrently. The result
+// has one entry per user, in the same order, with the role names sorted.
+func fetchRolesOfUsers(client *internalHTTP.HTTPClient, users []UserData) []userRoles {
+	roleResponses := make([]userRoles, len(users))
+
+	wsg := sync.WaitGroup{}
+	for idx, user := range users {
+		wsg.Add(1)
+		out := &roleResponses[idx]
+		userID := user.ID
+		go func() {
+			var userRolesData UserRoleData
+			userRolesData, out.err = fetchUserRoles(client, userID)
+			if out.err == nil {
+				out.data = make([]string, 0, len(userRolesData))
+				for role := range userRolesData {
+					out.data = append(out.data, role)
 				}
-			} else {
-				fmt.Println(roles.err)
+				sort.Strings(out.data)
 			}
-		}
-		fmt.Println()
+			wsg.Done()
+		}()
+	}
 
-		cmd.Annotations["error"] = "none"
-		return nil
-	},
+	wsg.Wait()
+	return roleResponses
 }
 
 func fetchUsers(client *internalHTTP.HTTPClient) (res []UserData, err error) {
@@ -413,6 +383,5 @@ func fetchUserRoles(client *internalHTTP.HTTPClient, user string) (res UserRoleD
 }
 
 func init() {
-	// Add the --output flag with shorthand -o, defaulting to empty for default layout
-	ListUserCmd.Flags().StringP("output", "o", "", "Output format: 'text' or 'json'")
+	addYesFlag(RemoveUserCmd)
 }
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/spf13/cobra"
+)
+
+var ExportUserCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Export all users and their roles",
+	Example: "  pb user export\n  pb user export --file users.json",
+	Long: `
+Export all users and their roles as JSON, in the format read by pb user import.
+Passwords can't be retrieved from the server, so imported users get new ones.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		// the export is always JSON, other formats are rejected
+		if _, err := resolveOutput(outputJSON); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+		users, err := fetchUsers(&client)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		// an incomplete export would silently drop roles on re-import
+		roleResponses := fetchRolesOfUsers(&client, users)
+		entries := make([]UserEntry, len(users))
+		for idx, user := range users {
+			if roleResponses[idx].err != nil {
+				err := fmt.Errorf("failed to fetch roles of user %s: %w", user.ID, roleResponses[idx].err)
+				cmd.Annotations[common.ErrorAnnotation] = err.Error()
+				return err
+			}
+			roles := roleResponses[idx].data
+			if roles == nil {
+				roles = []string{}
+			}
+			sort.Strings(roles)
+			entries[idx] = UserEntry{Username: user.ID, Roles: roles}
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Username < entries[j].Username
+		})
+
+		jsonData, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		path, _ := cmd.Flags().GetString("file")
+		if path == "" {
+			fmt.Println(string(jsonData))
+			return nil
+		}
+		if path, err = common.ExpandPath(path); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		if err := os.WriteFile(path, append(jsonData, '\n'), 0o600); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		fmt.Printf("Exported %d users to %s\n", len(entries), StyleBold.Render(path))
+		return nil
+	},
+}
+
+func init() {
+	ExportUserCmd.Flags().StringP("file", "f", "", "File to write the users to instead of stdout")
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"pb/pkg/common"
+	internalHTTP "pb/pkg/http"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+// UserEntry is a user and its roles as read by user import and written by
+// user export
+type UserEntry struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+// Outcomes of importing a single user
+const (
+	importCreated     = "created"
+	importWouldCreate = "would create"
+	importSkipped     = "skipped"
+	importFailed      = "failed"
+)
+
+const (
+	usersFileFlag = "file"
+	dryRunFlag    = "dry-run"
+)
+
+// userImportResult is one row of the import summary
+type userImportResult struct {
+	username string
+	roles    []string
+	status   string
+	detail   string
+}
+
+var ImportUserCmd = &cobra.Command{
+	Use:     "import",
+	Short:   "Create users in bulk from a file",
+	Example: "  pb user import --file users.json\n  pb user import --file users.json --dry-run",
+	Long: `
+Create users in bulk from a JSON file with a list of entries:
+
+  [{"username": "bob", "roles": ["admin"]}, {"username": "alice", "roles": ["reader"]}]
+
+Users that already exist are skipped. Every role must exist on the server
+before anything is created. Generated passwords are printed in the summary.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		cmd.Annotations = make(map[string]string)
+		defer func() {
+			cmd.Annotations["executionTime"] = time.Since(startTime).String()
+		}()
+
+		path, _ := cmd.Flags().GetString(usersFileFlag)
+		dryRun, _ := cmd.Flags().GetBool(dryRunFlag)
+
+		entries, err := readUserEntries(path)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		client := internalHTTP.DefaultClient(&DefaultProfile)
+
+		var rolesOnServer []string
+		if err := fetchRoles(&client, &rolesOnServer); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		if err := validateEntryRoles(entries, rolesOnServer); err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		users, err := fetchUsers(&client)
+		if err != nil {
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+
+		results := make([]userImportResult, 0, len(entries))
+		failed := 0
+		for _, entry := range entries {
+			result := userImportResult{username: entry.Username, roles: entry.Roles}
+			switch {
+			case slices.ContainsFunc(users, func(user UserData) bool { return user.ID == entry.Username }):
+				result.status = importSkipped
+				result.detail = "user already exists"
+			case dryRun:
+				result.status = importWouldCreate
+			default:
+				password, err := createUser(&client, entry.Username, entry.Roles)
+				if err != nil {
+					result.status = importFailed
+					result.detail = err.Error()
+					failed++
+				} else {
+					result.status = importCreated
+					result.detail = password
+				}
+			}
+			results = append(results, result)
+		}
+
+		printUserImportResults(results)
+
+		if failed > 0 {
+			err := fmt.Errorf("%d of %d users failed to import", failed, len(entries))
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	ImportUserCmd.Flags().StringP(usersFileFlag, "f", "", "JSON file with the users to create")
+	ImportUserCmd.Flags().Bool(dryRunFlag, false, "Validate the file and show what would be created without creating users")
+	_ = ImportUserCmd.MarkFlagRequired(usersFileFlag)
+}
+
+// readUserEntries reads and validates the user list in path
+func readUserEntries(path string) ([]UserEntry, error) {
+	path, err := common.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []UserEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for idx, entry := range entries {
+		entry.Username = strings.TrimSpace(entry.Username)
+		if entry.Username == "" {
+			return nil, fmt.Errorf("entry %d has no username", idx+1)
+		}
+		if seen[entry.Username] {
+			return nil, fmt.Errorf("user %s is listed more than once", entry.Username)
+		}
+		seen[entry.Username] = true
+		entries[idx].Username = entry.Username
+	}
+	return entries, nil
+}
+
+// validateEntryRoles checks that every role referenced by the entries exists
+func validateEntryRoles(entries []UserEntry, rolesOnServer []string) error {
+	var missing []string
+	for _, entry := range entries {
+		for _, role := range entry.Roles {
+			if !slices.Contains(rolesOnServer, role) && !slices.Contains(missing, role) {
+				missing = append(missing, role)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("role(s) %s don't exist, please create them using pb role add", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// createUser creates the user with the given roles and returns the password
+// generated by the server
+func createUser(client *internalHTTP.HTTPClient, name string, roles []string) (string, error) {
+	if roles == nil {
+		roles = []string{}
+	}
+	body, err := json.Marshal(roles)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := client.NewRequest(http.MethodPost, "user/"+name, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", internalHTTP.NewAPIError(resp, respBody)
+	}
+
+	password := strings.TrimSpace(string(respBody))
+	if password == "" {
+		return "", errors.New("server returned no password")
+	}
+	return password, nil
+}
+
+func printUserImportResults(results []userImportResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"User", "Roles", "Status", "Password / Detail"})
+	table.SetAutoWrapText(false)
+
+	for _, result := range results {
+		status := result.status
+		switch result.status {
+		case importCreated:
+			status = common.Green + status + common.Reset
+		case importFailed:
+			status = common.Red + status + common.Reset
+		}
+		table.Append([]string{result.username, strings.Join(result.roles, ", "), status, result.detail})
+	}
+	table.Render()
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pb/pkg/common"
+	"pb/pkg/config"
+)
+
+// useUserServer serves three users with their roles
+func useUserServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/user":
+			_, _ = w.Write([]byte(`[{"id":"alice","method":"native"},{"id":"bob","method":"native"},{"id":"carol","method":"native"}]`))
+		case "/api/v1/user/alice/role":
+			_, _ = w.Write([]byte(`{"writer":[],"admin":[],"reader":[],"editor":[]}`))
+		case "/api/v1/user/bob/role":
+			_, _ = w.Write([]byte(`{}`))
+		case "/api/v1/user/carol/role":
+			_, _ = w.Write([]byte(`{"reader":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previous := DefaultProfile
+	DefaultProfile = config.Profile{URL: server.URL}
+	t.Cleanup(func() { DefaultProfile = previous })
+}
+
+func TestListUsersPrintsSortedRoles(t *testing.T) {
+	useConfigDir(t)
+	useUserServer(t)
+
+	want := "alice, admin, editor, reader, writer\nbob\ncarol, reader\n"
+	// map iteration order varies, a few runs would catch unsorted roles
+	for range 5 {
+		out := captureStdout(t, func() {
+			if err := ListUserCmd.RunE(ListUserCmd, nil); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if string(out) != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+		}
+	}
+}
+
+func TestListUsersReportsNoErrorOnSuccess(t *testing.T) {
+	useConfigDir(t)
+	useUserServer(t)
+
+	for _, output := range []string{outputText, outputJSON} {
+		useOutput(t, output)
+		captureStdout(t, func() {
+			if err := ListUserCmd.RunE(ListUserCmd, nil); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if err, ok := ListUserCmd.Annotations[common.ErrorAnnotation]; ok {
+			t.Errorf("%s output recorded error %q for a successful command", output, err)
+		}
+	}
+}
+
+func TestExportUsersToFile(t *testing.T) {
+	useConfigDir(t)
+	useUserServer(t)
+	useOutput(t, outputJSON)
+
+	path := filepath.Join(t.TempDir(), "users.json")
+	setFlags(t, ExportUserCmd, map[string]string{"file": path})
+	captureStdout(t, func() {
+		if err := ExportUserCmd.RunE(ExportUserCmd, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "username": "alice",
+    "roles": [
+      "admin",
+      "editor",
+      "reader",
+      "writer"
+    ]
+  },
+  {
+    "username": "bob",
+    "roles": []
+  },
+  {
+    "username": "carol",
+    "roles": [
+      "reader"
+    ]
+  }
+]
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestExportUsersRejectsOtherFormats(t *testing.T) {
+	useConfigDir(t)
+	useUserServer(t)
+	useOutput(t, outputCSV)
+
+	if err := ExportUserCmd.RunE(ExportUserCmd, nil); err == nil {
+		t.Error("expected --output csv to be rejected")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"pb/pkg/analytics"
+	"pb/pkg/common"
 	internalHTTP "pb/pkg/http"
 	"time"
 
@@ -44,17 +45,18 @@ var VersionCmd = &cobra.Command{
 
 		err := PrintVersion("1.0.0", "abc123") // Replace with actual version and commit values
 		if err != nil {
-			cmd.Annotations["error"] = err.Error()
+			cmd.Annotations[common.ErrorAnnotation] = err.Error()
 		}
 	},
 }
 
-func init() {
-	VersionCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text|json)")
-}
-
 // PrintVersion prints version information
 func PrintVersion(version, commit string) error {
+	output, err := resolveOutput(outputText, outputJSON)
+	if err != nil {
+		return err
+	}
+
 	client := internalHTTP.DefaultClient(&DefaultProfile)
 
 	// Fetch server information
@@ -68,7 +70,7 @@ func PrintVersion(version, commit string) error {
 	}
 
 	// Output as JSON if specified
-	if outputFormat == "json" {
+	if output == outputJSON {
 		versionInfo := map[string]interface{}{
 			"client": map[string]string{
 				"version": version,
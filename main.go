@@ -24,7 +24,10 @@ import (
 
 	pb "pb/cmd"
 	"pb/pkg/analytics"
+	"pb/pkg/common"
 	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+	"pb/pkg/log"
 
 	"github.com/spf13/cobra"
 )
@@ -42,6 +45,12 @@ var (
 	versionFlagShort = "v"
 )
 
+// logging flags, applied once the flags are parsed
+var (
+	logLevel = log.LevelWarn
+	jsonLogs bool
+)
+
 func defaultInitialProfile() config.Profile {
 	return config.Profile{
 		URL:      "https://demo.parseable.com",
@@ -64,13 +73,13 @@ var cli = &cobra.Command{
 		return errors.New("no command or flag supplied")
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "cli", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -81,39 +90,59 @@ var profile = &cobra.Command{
 	Long:              "\nuse profile command to configure different Parseable instances. Each profile takes a URL and credentials.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analytics.PostRunAnalytics(cmd, args)
+		}()
+	},
+}
+
+var configCmd = &cobra.Command{
+	Use:               "config",
+	Short:             "Inspect the pb config file",
+	Long:              "\nuse config command to locate the config file and print its contents.",
+	PersistentPreRunE: analytics.CheckAndCreateULID,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "profile", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
 
 var schema = &cobra.Command{
 	Use:   "schema",
-	Short: "Generate or create schemas for JSON data or Parseable streams",
+	Short: "Generate, create or compare schemas for JSON data or Parseable streams",
 	Long: `The "schema" command allows you to either:
   - Generate a schema automatically from a JSON file for analysis or integration.
   - Create a custom schema for Parseable streams (PB streams) to structure and process your data.
+  - Compare a schema file with the schema of an existing stream.
 
 Examples:
   - To generate a schema from a JSON file:
       pb schema generate --file=data.json
   - To create a schema for a PB stream:
       pb schema create --stream-name=my_stream --config=data.json
+  - To compare a schema file with a PB stream:
+      pb schema diff --stream=my_stream --file=schema.json
 `,
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "generate", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -124,13 +153,13 @@ var user = &cobra.Command{
 	Long:              "\nuser command is used to manage users.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "user", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -141,13 +170,13 @@ var role = &cobra.Command{
 	Long:              "\nrole command is used to manage roles.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "role", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -158,13 +187,64 @@ var stream = &cobra.Command{
 	Long:              "\nstream command is used to manage streams.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analytics.PostRunAnalytics(cmd, args)
+		}()
+	},
+}
+
+var alert = &cobra.Command{
+	Use:               "alert",
+	Short:             "Manage alerts of a stream",
+	Long:              "\nalert command is used to add, list and remove the alerts of a stream.",
+	PersistentPreRunE: combinedPreRun,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !analytics.Enabled() {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analytics.PostRunAnalytics(cmd, args)
+		}()
+	},
+}
+
+var hottier = &cobra.Command{
+	Use:               "hottier",
+	Short:             "Manage the hot tier of a stream",
+	Long:              "\nhottier command is used to keep recent data of a stream on the local disk of the server for faster queries.",
+	PersistentPreRunE: combinedPreRun,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "stream", args)
+			analytics.PostRunAnalytics(cmd, args)
+		}()
+	},
+}
+
+var retention = &cobra.Command{
+	Use:               "retention",
+	Short:             "Manage the retention policy of a stream",
+	Long:              "\nretention command is used to view, set or clear how long the data of a stream is kept.",
+	PersistentPreRunE: combinedPreRun,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !analytics.Enabled() {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -175,13 +255,13 @@ var query = &cobra.Command{
 	Long:              "\nRun SQL query on a log stream. Default output format is json. Use -i flag to open interactive table view.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "query", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -192,13 +272,13 @@ var cluster = &cobra.Command{
 	Long:              "\nCluster operations for Parseable cluster on Kubernetes.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "install", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -209,13 +289,13 @@ var list = &cobra.Command{
 	Long:              "\nlist command is used to list Parseable oss installations.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "install", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -226,13 +306,13 @@ var show = &cobra.Command{
 	Long:              "\nshow command is used to get values in Parseable.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "install", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
@@ -243,27 +323,45 @@ var uninstall = &cobra.Command{
 	Long:              "\nuninstall command is used to uninstall Parseable oss/enterprise on k8s cluster.",
 	PersistentPreRunE: combinedPreRun,
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if os.Getenv("PB_ANALYTICS") == "disable" {
+		if !analytics.Enabled() {
 			return
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			analytics.PostRunAnalytics(cmd, "uninstall", args)
+			analytics.PostRunAnalytics(cmd, args)
 		}()
 	},
 }
 
 func main() {
+	setupCLI()
+
+	err := cli.Execute()
+	if err != nil {
+		wg.Wait()
+		os.Exit(internalHTTP.ExitCode(err))
+	}
+	wg.Wait()
+}
+
+// setupCLI attaches the subcommands and global flags to the root command
+func setupCLI() {
 	profile.AddCommand(pb.AddProfileCmd)
 	profile.AddCommand(pb.RemoveProfileCmd)
 	profile.AddCommand(pb.ListProfileCmd)
 	profile.AddCommand(pb.DefaultProfileCmd)
+	profile.AddCommand(pb.TestProfileCmd)
+
+	configCmd.AddCommand(pb.ConfigPathCmd)
+	configCmd.AddCommand(pb.ConfigShowCmd)
 
 	user.AddCommand(pb.AddUserCmd)
 	user.AddCommand(pb.RemoveUserCmd)
 	user.AddCommand(pb.ListUserCmd)
 	user.AddCommand(pb.SetUserRoleCmd)
+	user.AddCommand(pb.ImportUserCmd)
+	user.AddCommand(pb.ExportUserCmd)
 
 	role.AddCommand(pb.AddRoleCmd)
 	role.AddCommand(pb.RemoveRoleCmd)
@@ -273,16 +371,40 @@ func main() {
 	stream.AddCommand(pb.RemoveStreamCmd)
 	stream.AddCommand(pb.ListStreamCmd)
 	stream.AddCommand(pb.StatStreamCmd)
+	stream.AddCommand(pb.RenameStreamCmd)
+	stream.AddCommand(pb.SampleStreamCmd)
+	stream.AddCommand(pb.PartitionInfoStreamCmd)
+	stream.AddCommand(alert)
+	stream.AddCommand(hottier)
+	stream.AddCommand(retention)
+
+	alert.AddCommand(pb.AddAlertCmd)
+	alert.AddCommand(pb.ListAlertCmd)
+	alert.AddCommand(pb.RemoveAlertCmd)
+
+	hottier.AddCommand(pb.SetHotTierCmd)
+	hottier.AddCommand(pb.GetHotTierCmd)
+	hottier.AddCommand(pb.RemoveHotTierCmd)
+
+	retention.AddCommand(pb.GetRetentionCmd)
+	retention.AddCommand(pb.SetRetentionCmd)
+	retention.AddCommand(pb.ClearRetentionCmd)
 
 	query.AddCommand(pb.QueryCmd)
 	query.AddCommand(pb.SavedQueryList)
+	query.AddCommand(pb.ExplainQueryCmd)
+	query.AddCommand(pb.QueryHistoryCmd)
 
 	schema.AddCommand(pb.GenerateSchemaCmd)
 	schema.AddCommand(pb.CreateSchemaCmd)
+	schema.AddCommand(pb.DiffSchemaCmd)
 
 	cluster.AddCommand(pb.InstallOssCmd)
 	cluster.AddCommand(pb.ListOssCmd)
 	cluster.AddCommand(pb.ShowValuesCmd)
+	cluster.AddCommand(pb.ShowSecretCmd)
+	cluster.AddCommand(pb.StatusOssCmd)
+	cluster.AddCommand(pb.LogsOssCmd)
 	cluster.AddCommand(pb.UninstallOssCmd)
 
 	list.AddCommand(pb.ListOssCmd)
@@ -292,14 +414,18 @@ func main() {
 	show.AddCommand(pb.ShowValuesCmd)
 
 	cli.AddCommand(profile)
+	cli.AddCommand(configCmd)
 	cli.AddCommand(query)
+	cli.AddCommand(schema)
 	cli.AddCommand(stream)
 	cli.AddCommand(user)
 	cli.AddCommand(role)
 	cli.AddCommand(pb.TailCmd)
+	cli.AddCommand(pb.IngestCmd)
 	cli.AddCommand(cluster)
 
 	cli.AddCommand(pb.AutocompleteCmd)
+	cli.AddCommand(pb.CompletionCmd)
 
 	// Set as command
 	pb.VersionCmd.Run = func(_ *cobra.Command, _ []string) {
@@ -310,10 +436,47 @@ func main() {
 	// set as flag
 	cli.Flags().BoolP(versionFlag, versionFlagShort, false, "Print version")
 
-	cli.CompletionOptions.HiddenDefaultCmd = true
+	// pb completion replaces the completion command cobra adds by default
+	cli.CompletionOptions.DisableDefaultCmd = true
+
+	cli.PersistentFlags().BoolVar(&common.NoColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when stdout is not a terminal)")
+	cli.PersistentFlags().BoolVar(&internalHTTP.Insecure, "insecure", false, "Skip TLS certificate verification (not recommended)")
+	cli.PersistentFlags().Var(&internalHTTP.Proxy, "proxy", "Proxy URL for requests to the server, overrides HTTP_PROXY, HTTPS_PROXY and NO_PROXY")
+	cli.PersistentFlags().Var(&internalHTTP.Headers, "header", "Extra header sent with every request to the server, as \"Name: value\". Repeat for more headers, overrides headers of the profile")
+	cli.PersistentFlags().BoolVar(&internalHTTP.Compress, "compress", false, "Gzip large request bodies, such as ingested events, to save bandwidth")
+	cli.PersistentFlags().VarP(&pb.Output, "output", "o", "Output format (text|json|ndjson|csv|table), overrides output in the config file. Commands support a subset")
+	cli.PersistentFlags().Var(&pb.TimeZone, "tz", "IANA time zone (e.g. Europe/Berlin) to read --from and --to in, UTC by default. Timestamps are printed in it too")
+	cli.PersistentFlags().Var(&logLevel, "log-level", "Minimum level of the log messages written to stderr (debug|info|warn|error)")
+	cli.PersistentFlags().BoolVar(&jsonLogs, "json-logs", false, "Write log messages as JSON")
+	cli.PersistentFlags().BoolVar(&internalHTTP.Debug, "debug", false, "Trace HTTP requests and responses to stderr, with credentials redacted")
+	cli.PersistentFlags().StringVar(&pb.ServerFlag, "server", "", "URL of the Parseable server, overrides the default profile (env PB_URL)")
+	cli.PersistentFlags().StringVar(&pb.UsernameFlag, "username", "", "Username, overrides the default profile (env PB_USERNAME)")
+	cli.PersistentFlags().StringVar(&pb.EndpointFlag, "endpoint", "", "URL of a single node, e.g. one ingestor or querier in distributed mode, used instead of the profile's server for this command only")
+	cli.PersistentFlags().StringVar(&pb.PasswordFlag, "password", "", "Password, overrides the default profile (env PB_PASSWORD)")
+	cobra.OnInitialize(func() {
+		if internalHTTP.Debug {
+			logLevel = log.LevelDebug
+		}
+		log.Configure(logLevel, jsonLogs)
+		if !common.ColorEnabled() {
+			common.DisableColors()
+			pb.DisableColors()
+		}
+		// with server and credentials given on the command line or in the
+		// environment the config file is neither read nor created
+		if !pb.HasProfileOverride() {
+			initConfig()
+		}
+	})
+}
 
-	// create a default profile if file does not exist
-	if previousConfig, err := config.ReadConfigFromFile(); os.IsNotExist(err) {
+// initConfig creates the demo profile on first run and makes sure an
+// existing config file can be read
+func initConfig() {
+	// create the demo profile only on first run, when there is no config at
+	// all. An existing config is never modified here, so removing or editing
+	// the demo profile sticks.
+	if _, err := config.ReadConfigFromFile(); os.IsNotExist(err) {
 		conf := config.Config{
 			Profiles:       map[string]config.Profile{"demo": defaultInitialProfile()},
 			DefaultProfile: "demo",
@@ -323,34 +486,12 @@ func main() {
 			fmt.Printf("failed to write to file %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		// Only update the "demo" profile without overwriting other profiles
-		demoProfile, exists := previousConfig.Profiles["demo"]
-		if exists {
-			// Update fields in the demo profile only
-			demoProfile.URL = "http://demo.parseable.com"
-			demoProfile.Username = "admin"
-			demoProfile.Password = "admin"
-			previousConfig.Profiles["demo"] = demoProfile
-		} else {
-			// Add the "demo" profile if it doesn't exist
-			previousConfig.Profiles["demo"] = defaultInitialProfile()
-			previousConfig.DefaultProfile = "demo" // Optional: set as default if needed
-		}
-
-		// Write the updated configuration back to file
-		err = config.WriteConfigToFile(previousConfig)
-		if err != nil {
-			fmt.Printf("failed to write to existing file %v\n", err)
-			os.Exit(1)
-		}
-	}
-
-	err := cli.Execute()
-	if err != nil {
+	} else if err != nil {
+		// never overwrite a config file that could not be read, the user
+		// would lose their profiles
+		fmt.Fprintf(os.Stderr, "Error: %s\nFix or remove the config file and run pb again.\n", err)
 		os.Exit(1)
 	}
-	wg.Wait()
 }
 
 // Wrapper to combine existing pre-run logic and ULID check
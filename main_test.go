@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemaDiffThroughRoot(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PB_ANALYTICS", "disable")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/logstream/web/schema" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"fields":[{"name":"host","data_type":"Utf8"},{"name":"p_timestamp","data_type":{"Timestamp":["Millisecond",null]}}]}`))
+	}))
+	defer server.Close()
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(`{"fields":[{"name":"host","data_type":"string"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	setupCLI()
+	cli.SetArgs([]string{"schema", "diff", "--stream", "web", "--file", schemaFile,
+		"--server", server.URL, "--username", "admin", "--password", "admin"})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("pb schema diff failed: %s", err)
+	}
+}
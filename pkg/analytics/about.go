@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+// aboutCacheTTL is how long the about response of a server is reused. Every
+// command runs a new pb process, so the cache also lives on disk.
+const aboutCacheTTL = 10 * time.Minute
+
+// aboutCache holds the about responses fetched by this process, by profile
+var aboutCache = struct {
+	sync.Mutex
+	entries map[string]About
+}{entries: map[string]About{}}
+
+// CachedAbout returns the about response of the profile's server, reusing a
+// response fetched within aboutCacheTTL. Entries are keyed by the server URL
+// and user, so switching profiles or editing a profile's server fetches it
+// again. Use FetchAbout where an up to date response matters.
+func CachedAbout(client *internalHTTP.HTTPClient) (About, error) {
+	key := aboutCacheKey(client.Profile)
+
+	aboutCache.Lock()
+	defer aboutCache.Unlock()
+	if about, ok := aboutCache.entries[key]; ok {
+		return about, nil
+	}
+
+	path := aboutCachePath(key)
+	if about, ok := readAboutCache(path); ok {
+		aboutCache.entries[key] = about
+		return about, nil
+	}
+
+	about, err := FetchAbout(client)
+	if err != nil {
+		return about, err
+	}
+	aboutCache.entries[key] = about
+	writeAboutCache(path, about)
+	return about, nil
+}
+
+func aboutCacheKey(profile *config.Profile) string {
+	if profile == nil {
+		return ""
+	}
+	key := sha256.Sum256([]byte(profile.URL + "\x00" + profile.Username))
+	return fmt.Sprintf("%x", key[:8])
+}
+
+// aboutCachePath returns the cache file for the key, empty when there is no
+// cache directory
+func aboutCachePath(key string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "parseable", fmt.Sprintf("about-%s.json", key))
+}
+
+func readAboutCache(path string) (About, bool) {
+	var about About
+	if path == "" {
+		return about, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > aboutCacheTTL {
+		return about, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return about, false
+	}
+	if err := json.Unmarshal(data, &about); err != nil {
+		return about, false
+	}
+	return about, true
+}
+
+// writeAboutCache saves the response on a best effort basis, a missing
+// cache only costs another request
+func writeAboutCache(path string, about About) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(about)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
+)
+
+// resetAboutCache forgets the responses cached in memory
+func resetAboutCache() {
+	aboutCache.Lock()
+	aboutCache.entries = map[string]About{}
+	aboutCache.Unlock()
+}
+
+func TestCachedAbout(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetAboutCache()
+	t.Cleanup(resetAboutCache)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"version":"v1.6.0","commit":"abc123"}`))
+	}))
+	defer server.Close()
+
+	admin := internalHTTP.DefaultClient(&config.Profile{URL: server.URL, Username: "admin"})
+	for range 3 {
+		about, err := CachedAbout(&admin)
+		if err != nil || about.Commit != "abc123" {
+			t.Fatalf("got %+v, %v", about, err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("expected one request, got %d", requests.Load())
+	}
+
+	// a new process reads the response from disk
+	resetAboutCache()
+	if _, err := CachedAbout(&admin); err != nil || requests.Load() != 1 {
+		t.Fatalf("disk cache not used, %d requests, %v", requests.Load(), err)
+	}
+
+	// another profile fetches its own response
+	reader := internalHTTP.DefaultClient(&config.Profile{URL: server.URL, Username: "reader"})
+	if _, err := CachedAbout(&reader); err != nil || requests.Load() != 2 {
+		t.Fatalf("profile change did not fetch again, %d requests, %v", requests.Load(), err)
+	}
+
+	// an expired response is fetched again
+	resetAboutCache()
+	expired := time.Now().Add(-2 * aboutCacheTTL)
+	if err := os.Chtimes(aboutCachePath(aboutCacheKey(admin.Profile)), expired, expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CachedAbout(&admin); err != nil || requests.Load() != 3 {
+		t.Fatalf("expired response was reused, %d requests, %v", requests.Load(), err)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"strings"
 	"time"
 
+	"pb/pkg/common"
 	"pb/pkg/config"
 	internalHTTP "pb/pkg/http"
 
@@ -94,16 +95,17 @@ type Config struct {
 	ULID string `yaml:"ulid"`
 }
 
+// ulidFilename is the file holding the ULID identifying this installation
+const ulidFilename = "config.yaml"
+
 // CheckAndCreateULID checks for a ULID in the config file and creates it if absent.
 func CheckAndCreateULID(_ *cobra.Command, _ []string) error {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := config.StatePath(ulidFilename)
 	if err != nil {
 		fmt.Printf("could not find home directory: %v\n", err)
 		return err
 	}
 
-	configPath := filepath.Join(homeDir, ".parseable", "config.yaml")
-
 	// Check if config path exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		// Create the directory if needed
@@ -148,29 +150,82 @@ func CheckAndCreateULID(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
-func PostRunAnalytics(cmd *cobra.Command, name string, args []string) {
+// DefaultEndpoint is the URL analytics events are sent to unless configured otherwise
+const DefaultEndpoint = "https://analytics.parseable.io:80/pb"
+
+// Enabled reports whether analytics events should be sent. The PB_ANALYTICS
+// environment variable takes precedence over the [analytics] config section:
+// "disable" turns analytics off and any other value turns it on.
+func Enabled() bool {
+	if env, ok := os.LookupEnv("PB_ANALYTICS"); ok && env != "" {
+		return env != "disable"
+	}
+	conf, err := config.ReadConfigFromFile()
+	if err != nil || conf.Analytics == nil || conf.Analytics.Enabled == nil {
+		return true
+	}
+	return *conf.Analytics.Enabled
+}
+
+// endpoint returns the configured analytics endpoint or the default one
+func endpoint() string {
+	conf, err := config.ReadConfigFromFile()
+	if err != nil || conf.Analytics == nil || conf.Analytics.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return conf.Analytics.Endpoint
+}
+
+// PostRunAnalytics sends an analytics event for the command that just ran
+func PostRunAnalytics(cmd *cobra.Command, args []string) {
+	if !Enabled() {
+		return
+	}
+
+	command := newCommand(cmd, args)
 	executionTime := cmd.Annotations["executionTime"]
-	commandError := cmd.Annotations["error"]
-	flags := make(map[string]string)
-	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
-		flags[flag.Name] = flag.Value.String()
-	})
 
 	// Call SendEvent in PostRunE
 	err := sendEvent(
-		name,
-		append(args, cmd.Name()),
-		&commandError, // Pass the error here if there was one
+		command,
+		commandError(cmd),
 		executionTime,
-		flags,
 	)
 	if err != nil {
 		fmt.Println("Error sending analytics event:", err)
 	}
 }
 
-// sendEvent is a placeholder function to simulate sending an event after command execution.
-func sendEvent(commandName string, arguments []string, errors *string, executionTimestamp string, flags map[string]string) error {
+// commandError returns the error recorded by a failed command, or nil
+func commandError(cmd *cobra.Command) *string {
+	if err, ok := cmd.Annotations[common.ErrorAnnotation]; ok && err != "" {
+		return &err
+	}
+	return nil
+}
+
+// newCommand describes the executed command. The name is the full command
+// path without the root command, e.g. "stream add".
+func newCommand(cmd *cobra.Command, args []string) Command {
+	name := cmd.CommandPath()
+	if cmd.HasParent() {
+		name = strings.TrimPrefix(name, cmd.Root().Name()+" ")
+	}
+
+	flags := make(map[string]string)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		flags[flag.Name] = flag.Value.String()
+	})
+
+	return Command{
+		Name:      name,
+		Arguments: args,
+		Flags:     flags,
+	}
+}
+
+// sendEvent sends the event for an executed command to the analytics endpoint.
+func sendEvent(cmd Command, errors *string, executionTimestamp string) error {
 	ulid, err := ReadUULD()
 	if err != nil {
 		return fmt.Errorf("could not load ULID: %v", err)
@@ -183,17 +238,8 @@ func sendEvent(commandName string, arguments []string, errors *string, execution
 
 	httpClient := internalHTTP.DefaultClient(&profile)
 
-	about, _ := FetchAbout(&httpClient)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get about metadata for profile: %v", err)
-	// }
-
-	// Create the Command struct
-	cmd := Command{
-		Name:      commandName,
-		Arguments: arguments,
-		Flags:     flags,
-	}
+	// the event only needs the server commit, which rarely changes
+	about, _ := CachedAbout(&httpClient)
 
 	// Populate the Event struct with OS details and timestamp
 	event := Event{
@@ -215,7 +261,7 @@ func sendEvent(commandName string, arguments []string, errors *string, execution
 	}
 
 	// Define the target URL for the HTTP request
-	url := "https://analytics.parseable.io:80/pb"
+	url := endpoint()
 
 	// Create the HTTP POST request
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(eventJSON))
@@ -325,13 +371,11 @@ func getWindowsVersion() string {
 }
 
 func ReadUULD() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := config.StatePath(ulidFilename)
 	if err != nil {
 		return "", fmt.Errorf("could not find home directory: %v", err)
 	}
 
-	configPath := filepath.Join(homeDir, ".parseable", "config.yaml")
-
 	// Check if config path exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return "", fmt.Errorf("config file does not exist, please run CheckAndCreateULID first")
@@ -0,0 +1,76 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"pb/pkg/common"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewCommandCapturesCommandPath(t *testing.T) {
+	root := &cobra.Command{Use: "pb"}
+	stream := &cobra.Command{Use: "stream"}
+	add := &cobra.Command{Use: "add stream-name"}
+	add.Flags().String("output", "text", "")
+	root.AddCommand(stream)
+	stream.AddCommand(add)
+
+	command := newCommand(add, []string{"backend"})
+	if command.Name != "stream add" {
+		t.Fatalf("command name does not match, expected %q, actual %q", "stream add", command.Name)
+	}
+	if len(command.Arguments) != 1 || command.Arguments[0] != "backend" {
+		t.Fatalf("arguments do not match, actual %v", command.Arguments)
+	}
+	if command.Flags["output"] != "text" {
+		t.Fatalf("flags were not captured, actual %v", command.Flags)
+	}
+}
+
+func TestCommandErrorIsCaptured(t *testing.T) {
+	failing := &cobra.Command{
+		Use:           "fail",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			err := errors.New("stream not found")
+			cmd.Annotations = map[string]string{common.ErrorAnnotation: fmt.Sprintf("Error: %s", err.Error())}
+			return err
+		},
+	}
+	if err := failing.Execute(); err == nil {
+		t.Fatalf("expected the command to fail")
+	}
+
+	captured := commandError(failing)
+	if captured == nil || *captured != "Error: stream not found" {
+		t.Fatalf("command error was not captured, actual %v", captured)
+	}
+
+	succeeding := &cobra.Command{Use: "ok", Run: func(_ *cobra.Command, _ []string) {}}
+	if err := succeeding.Execute(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if captured := commandError(succeeding); captured != nil {
+		t.Fatalf("expected no error for a successful command, actual %q", *captured)
+	}
+}
@@ -18,11 +18,14 @@ package common
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v2"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,8 +40,12 @@ const (
 	dataKey       = "installer-data"
 )
 
-// ANSI escape codes for colors
-const (
+// ErrorAnnotation is the command annotation holding the error of a failed run,
+// reported with the analytics event
+const ErrorAnnotation = "error"
+
+// ANSI escape codes for colors, emptied by DisableColors
+var (
 	Yellow = "\033[33m"
 	Green  = "\033[32m"
 	Red    = "\033[31m"
@@ -47,6 +54,31 @@ const (
 	Cyan   = "\033[36m"
 )
 
+// NoColor is set by the --no-color flag
+var NoColor bool
+
+// PromptOutput is where interactive prompts are drawn, stdout when nil.
+// Commands that print data on stdout point it at os.Stderr.
+var PromptOutput io.WriteCloser
+
+// ColorEnabled reports whether colored output should be printed. Color is off
+// when --no-color is passed, NO_COLOR is set or stdout is not a terminal.
+func ColorEnabled() bool {
+	if NoColor {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// DisableColors turns the color codes into empty strings so all output built
+// from them is printed plain
+func DisableColors() {
+	Yellow, Green, Red, Reset, Blue, Cyan = "", "", "", "", "", ""
+}
+
 // InstallerEntry represents an entry in the installer.yaml file
 type InstallerEntry struct {
 	Name      string `yaml:"name"`
@@ -144,7 +176,8 @@ func PromptK8sContext() (clusterName string, err error) {
 
 	// Prompt user to select Kubernetes context
 	promptK8s := promptui.Select{
-		Items: contexts,
+		Stdout: PromptOutput,
+		Items:  contexts,
 		Templates: &promptui.SelectTemplates{
 			Label:    "{{ `Select your Kubernetes context` | yellow }}",
 			Active:   "▸ {{ . | yellow }} ", // Yellow arrow and context name for active selection
@@ -203,6 +236,17 @@ func PromptConfirmation(message string) bool {
 	return err == nil
 }
 
+// PromptTypedConfirmation asks the user to type expected to confirm an
+// operation and reports whether they did
+func PromptTypedConfirmation(message, expected string) bool {
+	prompt := promptui.Prompt{
+		Label: fmt.Sprintf("%s Type '%s' to confirm", message, expected),
+	}
+
+	answer, err := prompt.Run()
+	return err == nil && strings.TrimSpace(answer) == expected
+}
+
 func CreateDeploymentSpinner(infoMsg string) *spinner.Spinner {
 	// Custom spinner with multiple character sets for dynamic effect
 	spinnerChars := []string{
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import "testing"
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/pb")
+	t.Setenv("LOGS", "/var/log")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"sample.json", "sample.json"},
+		{"/abs/sample.json", "/abs/sample.json"},
+		{"~", "/home/pb"},
+		{"~/logs/sample.json", "/home/pb/logs/sample.json"},
+		{"~other/sample.json", "~other/sample.json"},
+		{"logs/~/sample.json", "logs/~/sample.json"},
+		{"$LOGS/app.json", "/var/log/app.json"},
+		{"${LOGS}app.json", "/var/logapp.json"},
+		{"$HOME/a", "/home/pb/a"},
+		{"$EMPTY/a", "/a"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.path)
+		if err != nil {
+			t.Errorf("ExpandPath(%q) failed: %s", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExpandPathUnsetVariable(t *testing.T) {
+	if _, err := ExpandPath("$PB_SURELY_UNSET_VARIABLE/sample.json"); err == nil {
+		t.Errorf("expected an error for an unset variable")
+	}
+}
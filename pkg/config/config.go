@@ -17,13 +17,16 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
 	path "path/filepath"
+	"time"
 
+	"github.com/gofrs/flock"
 	toml "github.com/pelletier/go-toml/v2"
 )
 
@@ -41,10 +44,97 @@ func Path() (string, error) {
 	return path.Join(dir, configAppName, configFilename), nil
 }
 
+// legacyDirName is the directory in the home directory where pb kept files
+// other than config.toml before following XDG_CONFIG_HOME
+const legacyDirName = ".parseable"
+
+// StatePath returns the path of a file pb keeps besides config.toml, such as
+// the analytics ID. With XDG_CONFIG_HOME set the file lives in
+// $XDG_CONFIG_HOME/parseable, and a copy left in ~/.parseable by older
+// versions is moved there on first use. Otherwise ~/.parseable is used.
+func StatePath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	target, legacy := resolveStatePath(name, os.Getenv("XDG_CONFIG_HOME"), homeDir)
+	if target == legacy {
+		return target, nil
+	}
+	if err := migrateLegacyFile(legacy, target); err != nil {
+		// keep using the legacy file rather than starting over without it
+		return legacy, nil
+	}
+	return target, nil
+}
+
+// resolveStatePath returns where the named file belongs and where older
+// versions kept it. Relative XDG_CONFIG_HOME values are invalid per the XDG
+// spec and are ignored.
+func resolveStatePath(name, xdgConfigHome, homeDir string) (target, legacy string) {
+	legacy = path.Join(homeDir, legacyDirName, name)
+	if xdgConfigHome == "" || !path.IsAbs(xdgConfigHome) {
+		return legacy, legacy
+	}
+	return path.Join(xdgConfigHome, configAppName, name), legacy
+}
+
+// migrateLegacyFile moves the file at legacy to target, unless target
+// already exists or there is nothing to move
+func migrateLegacyFile(legacy, target string) error {
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacy); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.MkdirAll(path.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(legacy, target); err != nil {
+		// rename fails across file systems, fall back to copying
+		data, err := os.ReadFile(legacy)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o600); err != nil {
+			return err
+		}
+		_ = os.Remove(legacy)
+	}
+	return nil
+}
+
 // Config is the struct that holds the configuration
 type Config struct {
-	Profiles       map[string]Profile
-	DefaultProfile string
+	Profiles       map[string]Profile `json:"profiles"`
+	DefaultProfile string             `json:"default_profile"`
+	// Output is the default output format of commands, e.g. json. Unset means text.
+	Output string `json:"output,omitempty" toml:"output,omitempty"`
+	// JSONPretty sets whether JSON output is indented by default. Unset means pretty.
+	JSONPretty *bool `json:"json_pretty,omitempty" toml:"json_pretty,omitempty"`
+	// Analytics configures usage telemetry
+	Analytics *Analytics `json:"analytics,omitempty" toml:"analytics,omitempty"`
+	// History configures the local history of pb query run
+	History *History `json:"history,omitempty" toml:"history,omitempty"`
+}
+
+// Analytics is the [analytics] section of the config file
+type Analytics struct {
+	// Enabled turns telemetry on or off. Unset means enabled.
+	Enabled *bool `json:"enabled,omitempty" toml:"enabled,omitempty"`
+	// Endpoint overrides the URL events are sent to
+	Endpoint string `json:"endpoint,omitempty" toml:"endpoint,omitempty"`
+}
+
+// History is the [history] section of the config file
+type History struct {
+	// Enabled turns recording of queries on or off. Unset means enabled.
+	Enabled *bool `json:"enabled,omitempty" toml:"enabled,omitempty"`
+	// MaxEntries caps the number of queries kept. Zero means the default.
+	MaxEntries int `json:"max_entries,omitempty" toml:"max_entries,omitempty"`
 }
 
 // Profile is the struct that holds the profile configuration
@@ -52,6 +142,12 @@ type Profile struct {
 	URL      string `json:"url"`
 	Username string `json:"username"`
 	Password string `json:"password,omitempty"`
+	// Skip TLS certificate verification, for servers with self-signed certificates
+	Insecure bool `json:"insecure,omitempty" toml:"insecure,omitempty"`
+	// Path to a PEM encoded CA certificate used to verify the server
+	CACertPath string `json:"ca_cert_path,omitempty" toml:"ca_cert_path,omitempty"`
+	// Headers sent with every request to the server, e.g. for an API gateway
+	Headers map[string]string `json:"headers,omitempty" toml:"headers,omitempty"`
 }
 
 func (p *Profile) GrpcAddr(port string) string {
@@ -61,30 +157,46 @@ func (p *Profile) GrpcAddr(port string) string {
 
 // WriteConfigToFile writes the configuration to the config file
 func WriteConfigToFile(config *Config) error {
-	tomlData, _ := toml.Marshal(config)
 	filePath, err := Path()
 	if err != nil {
 		return err
 	}
-	// Open or create the file for writing (it will truncate the file if it already exists
-	err = os.MkdirAll(path.Dir(filePath), os.ModePerm)
+
+	unlock, err := lockConfig(filePath)
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	return writeConfig(filePath, config)
+}
 
-	file, err := os.Create(filePath)
+// UpdateConfig applies update to the config file while holding the config
+// lock, so concurrent pb processes do not overwrite each other's changes. A
+// missing config file is treated as an empty config.
+func UpdateConfig(update func(config *Config) error) error {
+	filePath, err := Path()
 	if err != nil {
-		fmt.Println("Error creating the file:", err)
 		return err
 	}
-	defer file.Close()
-	// Write the data into the file
-	_, err = file.Write(tomlData)
+
+	unlock, err := lockConfig(filePath)
 	if err != nil {
-		fmt.Println("Error writing to the file:", err)
 		return err
 	}
-	return err
+	defer unlock()
+
+	config, err := readConfig(filePath)
+	if os.IsNotExist(err) {
+		config = &Config{}
+	} else if err != nil {
+		return err
+	}
+
+	if err := update(config); err != nil {
+		return err
+	}
+	return writeConfig(filePath, config)
 }
 
 // ReadConfigFromFile reads the configuration from the config file
@@ -93,7 +205,10 @@ func ReadConfigFromFile() (config *Config, err error) {
 	if err != nil {
 		return &Config{}, err
 	}
+	return readConfig(filePath)
+}
 
+func readConfig(filePath string) (config *Config, err error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return &Config{}, err
@@ -101,12 +216,82 @@ func ReadConfigFromFile() (config *Config, err error) {
 
 	err = toml.Unmarshal(data, &config)
 	if err != nil {
-		return &Config{}, err
+		return &Config{}, &ParseError{Path: filePath, Err: err}
 	}
 
 	return config, nil
 }
 
+// ParseError is returned when the config file exists but is not valid TOML
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("config file %s is malformed: %s", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// writeConfig replaces the config file atomically: the data is written to a
+// temporary file in the same directory which is then renamed over the config,
+// so a crash mid-write never leaves a truncated file behind
+func writeConfig(filePath string, config *Config) error {
+	tomlData, err := toml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	dir := path.Dir(filePath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, configFilename+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating the file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(tomlData); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing to the file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filePath)
+}
+
+// lockConfig takes an exclusive lock guarding read-modify-write cycles of the
+// config file and returns the function releasing it
+func lockConfig(filePath string) (unlock func(), err error) {
+	if err := os.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	fileLock := flock.New(filePath + ".lock")
+	lockCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	locked, err := fileLock.TryLockContext(lockCtx, 10*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock config file: %w", err)
+	}
+	if !locked {
+		return nil, errors.New("failed to lock config file")
+	}
+	return func() { _ = fileLock.Unlock() }, nil
+}
+
 func GetProfile() (Profile, error) {
 	conf, err := ReadConfigFromFile()
 	if os.IsNotExist(err) {
@@ -0,0 +1,161 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// useTempConfigDir points the config file at a temporary directory
+func useTempConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+func TestUpdateConfigConcurrentWrites(t *testing.T) {
+	useTempConfigDir(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- UpdateConfig(func(config *Config) error {
+				if config.Profiles == nil {
+					config.Profiles = make(map[string]Profile)
+				}
+				config.Profiles[fmt.Sprintf("profile-%d", i)] = Profile{URL: "http://localhost:8000"}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent update failed: %s", err)
+		}
+	}
+
+	config, err := ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("failed to read config: %s", err)
+	}
+	if len(config.Profiles) != writers {
+		t.Fatalf("profiles were lost, expected %d, actual %d", writers, len(config.Profiles))
+	}
+}
+
+func TestReadMalformedConfig(t *testing.T) {
+	useTempConfigDir(t)
+
+	filePath, err := Path()
+	if err != nil {
+		t.Fatalf("failed to get config path: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %s", err)
+	}
+	corrupt := []byte("DefaultProfile = 'local'\n[Profiles.local\nURL = ")
+	if err := os.WriteFile(filePath, corrupt, 0o600); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+
+	_, err = ReadConfigFromFile()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, actual %v", err)
+	}
+	if parseErr.Path != filePath {
+		t.Fatalf("error does not name the config file, expected %s, actual %s", filePath, parseErr.Path)
+	}
+
+	// updates must not replace the unreadable file
+	err = UpdateConfig(func(config *Config) error {
+		config.DefaultProfile = "demo"
+		return nil
+	})
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected update to fail with a ParseError, actual %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if string(data) != string(corrupt) {
+		t.Fatalf("malformed config file was overwritten")
+	}
+}
+
+func TestResolveStatePath(t *testing.T) {
+	cases := []struct {
+		xdg    string
+		target string
+	}{
+		{"", "/home/alice/.parseable/config.yaml"},
+		{"relative/config", "/home/alice/.parseable/config.yaml"},
+		{"/home/alice/.xdg", "/home/alice/.xdg/parseable/config.yaml"},
+	}
+	for _, tc := range cases {
+		target, legacy := resolveStatePath("config.yaml", tc.xdg, "/home/alice")
+		if target != filepath.FromSlash(tc.target) || legacy != filepath.FromSlash("/home/alice/.parseable/config.yaml") {
+			t.Errorf("XDG_CONFIG_HOME=%q: got target %s, legacy %s", tc.xdg, target, legacy)
+		}
+	}
+}
+
+func TestStatePathMigratesLegacyFile(t *testing.T) {
+	home, xdg := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	legacy := filepath.Join(home, ".parseable", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("ulid: 01H\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// without XDG_CONFIG_HOME the legacy location stays in use
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got, err := StatePath("config.yaml"); err != nil || got != legacy {
+		t.Fatalf("expected %s, got %s (%v)", legacy, got, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	got, err := StatePath("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(xdg, "parseable", "config.yaml"); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if data, err := os.ReadFile(got); err != nil || string(data) != "ulid: 01H\n" {
+		t.Fatalf("legacy file was not migrated: %q (%v)", data, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy file was left behind")
+	}
+}
@@ -18,12 +18,15 @@ package helm
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"pb/pkg/log"
+
 	"github.com/gofrs/flock"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -33,6 +36,7 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
@@ -44,13 +48,35 @@ type Helm struct {
 	ChartName   string
 	RepoURL     string
 	Version     string
+	// Optional credentials for private chart repositories
+	Username string
+	Password string
+	CAFile   string
+	// Output receives a copy of the Helm log when set, whatever the log level
+	Output io.Writer
+}
+
+// logger returns the Helm log function. Helm output is shown at info level
+// when verbose, debug level otherwise, and is copied to h.Output when set.
+func logger(h Helm, verbose bool) action.DebugLog {
+	logMethod := log.Debugf
+	if verbose {
+		logMethod = log.Infof
+	}
+	if h.Output == nil {
+		return logMethod
+	}
+	return func(format string, v ...interface{}) {
+		logMethod(format, v...)
+		fmt.Fprintf(h.Output, format+"\n", v...)
+	}
 }
 
 func ListReleases(namespace string) ([]*release.Release, error) {
 	settings := cli.New()
 
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Debugf); err != nil {
 		return nil, err
 	}
 
@@ -63,8 +89,21 @@ func ListReleases(namespace string) ([]*release.Release, error) {
 // Apply applies a Helm chart using the provided Helm struct configuration.
 // It returns an error if any operation fails, otherwise, it returns nil.
 func Apply(h Helm, verbose bool) error {
-	// Create a logger that does nothing by default
-	silentLogger := func(_ string, _ ...interface{}) {}
+	_, err := install(h, verbose, false)
+	return err
+}
+
+// Template renders the manifests of a Helm chart without installing it.
+func Template(h Helm, verbose bool) (string, error) {
+	rel, err := install(h, verbose, true)
+	if err != nil {
+		return "", err
+	}
+	return rel.Manifest, nil
+}
+
+// install runs the Helm install action, rendering the chart client side when dryRun is set.
+func install(h Helm, verbose, dryRun bool) (*release.Release, error) {
 
 	// Create settings
 	settings := cli.New()
@@ -72,11 +111,7 @@ func Apply(h Helm, verbose bool) error {
 	// Create action configuration
 	actionConfig := new(action.Configuration)
 
-	// Choose logging method based on verbose flag
-	logMethod := silentLogger
-	if verbose {
-		logMethod = log.Printf
-	}
+	logMethod := logger(h, verbose)
 
 	// Initialize action configuration with chosen logger
 	if err := actionConfig.Init(
@@ -85,7 +120,7 @@ func Apply(h Helm, verbose bool) error {
 		os.Getenv("HELM_DRIVER"),
 		logMethod,
 	); err != nil {
-		return fmt.Errorf("failed to initialize Helm configuration: %w", err)
+		return nil, fmt.Errorf("failed to initialize Helm configuration: %w", err)
 	}
 
 	// Create a new Install action
@@ -93,21 +128,30 @@ func Apply(h Helm, verbose bool) error {
 	// Setting Namespace
 	settings.SetNamespace(h.Namespace)
 	settings.EnvVars()
-	// Add repository
-	repoAdd(h)
-
-	// RepoUpdate()
+	chartRef := fmt.Sprintf("%s/%s", h.RepoName, h.ChartName)
+	if dryRun {
+		// read the chart straight from the repository, a dry run leaves the
+		// helm repositories file untouched
+		client.ChartPathOptions.RepoURL = h.RepoURL
+		chartRef = h.ChartName
+	} else if err := repoAdd(h); err != nil {
+		return nil, err
+	}
 
 	// Locate chart path
-	cp, err := client.ChartPathOptions.LocateChart(fmt.Sprintf("%s/%s", h.RepoName, h.ChartName), settings)
+	client.ChartPathOptions.Username = h.Username
+	client.ChartPathOptions.Password = h.Password
+	client.ChartPathOptions.CaFile = h.CAFile
+	client.ChartPathOptions.Version = h.Version
+	cp, err := client.ChartPathOptions.LocateChart(chartRef, settings)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("chart %s/%s version %s not found in %s: %w", h.RepoName, h.ChartName, h.Version, h.RepoURL, err)
 	}
 
 	// Load chart
 	chartRequested, err := loader.Load(cp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Set action options
@@ -120,6 +164,14 @@ func Apply(h Helm, verbose bool) error {
 	client.WaitForJobs = true
 	// client.IncludeCRDs = true
 
+	if dryRun {
+		client.DryRun = true
+		client.ClientOnly = true
+		client.Replace = true
+		client.Wait = false
+		client.WaitForJobs = false
+	}
+
 	// Merge values
 	values := values.Options{
 		Values: h.Values,
@@ -127,16 +179,16 @@ func Apply(h Helm, verbose bool) error {
 
 	vals, err := values.MergeValues(getter.All(settings))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Run the Install action
-	_, err = client.Run(chartRequested, vals)
-	if err != nil {
-		return err
-	}
-	return nil
+	return client.Run(chartRequested, vals)
 }
 
+// repoFileMode keeps the repositories file private to the user, it holds the
+// credentials of private chart repositories
+const repoFileMode = 0o600
+
 // repoAdd adds a Helm repository.
 // It takes a Helm struct as input containing the repository name and URL.
 func repoAdd(h Helm) error {
@@ -180,8 +232,11 @@ func repoAdd(h Helm) error {
 
 	// Create a new repository entry
 	c := repo.Entry{
-		Name: h.RepoName,
-		URL:  h.RepoURL,
+		Name:     h.RepoName,
+		URL:      h.RepoURL,
+		Username: h.Username,
+		Password: h.Password,
+		CAFile:   h.CAFile,
 	}
 
 	// Check if the repository is already added, update it
@@ -196,7 +251,10 @@ func repoAdd(h Helm) error {
 			err := errors.Wrapf(err, "looks like we are unable to update helm repo %q", h.RepoURL)
 			return err
 		}
-		return nil
+
+		// Keep the stored entry in sync so chart lookups use the same URL and credentials
+		f.Update(&c)
+		return f.WriteFile(repoFile, repoFileMode)
 	}
 	// Create a new chart repository
 	r, err := repo.NewChartRepository(&c, getter.All(settings))
@@ -214,7 +272,7 @@ func repoAdd(h Helm) error {
 	f.Update(&c)
 
 	// Write the updated repository file
-	if err := f.WriteFile(repoFile, 0o644); err != nil {
+	if err := f.WriteFile(repoFile, repoFileMode); err != nil {
 		return err
 	}
 	return nil
@@ -227,7 +285,7 @@ func ListRelease(releaseName, namespace string) (bool, error) {
 
 	// Initialize action configuration
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Debugf); err != nil {
 		return false, err
 	}
 
@@ -241,7 +299,7 @@ func ListRelease(releaseName, namespace string) (bool, error) {
 	}
 
 	if len(releases) == 0 {
-		fmt.Println("No release exist, install app", releaseName)
+		log.Debug("no helm releases found", "release", releaseName, "namespace", namespace)
 		return false, nil
 	}
 
@@ -262,7 +320,7 @@ func GetReleaseValues(releaseName, namespace string) (map[string]interface{}, er
 
 	// Initialize action configuration
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Debugf); err != nil {
 		return nil, err
 	}
 
@@ -277,50 +335,36 @@ func GetReleaseValues(releaseName, namespace string) (map[string]interface{}, er
 	return release.Config, nil
 }
 
-// DeleteRelease deletes a Helm release based on the specified chart name and namespace.
-func DeleteRelease(chartName, namespace string) error {
+// Upgrade upgrades an existing release to the chart and values in h.
+func Upgrade(h Helm, verbose bool) error {
 	settings := cli.New()
-	settings.SetNamespace(namespace)
-	settings.EnvVars()
-	// Initialize action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-		return err
-	}
-
-	// Create a new Uninstall action
-	client := action.NewUninstall(actionConfig)
-	// Run the Uninstall action to delete the release
-	_, err := client.Run(chartName)
-	if err != nil {
-		return err
-	}
-	return nil
-}
 
-func Upgrade(h Helm) error {
-	settings := cli.New()
+	logMethod := logger(h, verbose)
 
 	// Initialize action configuration
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), h.Namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-		return err
+	if err := actionConfig.Init(settings.RESTClientGetter(), h.Namespace, os.Getenv("HELM_DRIVER"), logMethod); err != nil {
+		return fmt.Errorf("failed to initialize Helm configuration: %w", err)
 	}
 
-	// Create a new Install action
+	// Create a new Upgrade action
 	client := action.NewUpgrade(actionConfig)
 	// Setting Namespace
 	settings.SetNamespace(h.Namespace)
 	settings.EnvVars()
 	// Add repository
-	repoAdd(h)
-
-	// RepoUpdate()
+	if err := repoAdd(h); err != nil {
+		return err
+	}
 
 	// Locate chart path
+	client.ChartPathOptions.Username = h.Username
+	client.ChartPathOptions.Password = h.Password
+	client.ChartPathOptions.CaFile = h.CAFile
+	client.ChartPathOptions.Version = h.Version
 	cp, err := client.ChartPathOptions.LocateChart(fmt.Sprintf("%s/%s", h.RepoName, h.ChartName), settings)
 	if err != nil {
-		return err
+		return fmt.Errorf("chart %s/%s version %s not found in %s: %w", h.RepoName, h.ChartName, h.Version, h.RepoURL, err)
 	}
 
 	// Load chart
@@ -330,13 +374,11 @@ func Upgrade(h Helm) error {
 	}
 
 	// Set action options
-	client.Namespace = h.ReleaseName
 	client.Namespace = h.Namespace
 	client.Version = h.Version
 	client.Wait = true
 	client.Timeout = 300 * time.Second
 	client.WaitForJobs = true
-	// client.IncludeCRDs = true
 
 	// Merge values
 	values := values.Options{
@@ -347,29 +389,32 @@ func Upgrade(h Helm) error {
 	if err != nil {
 		return err
 	}
-	// Run the Install action
+	// Run the Upgrade action
 	_, err = client.Run(h.ReleaseName, chartRequested, vals)
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
+// Uninstall removes the release and waits for its resources to be deleted.
 func Uninstall(h Helm, verbose bool) (*release.UninstallReleaseResponse, error) {
-	// Create a logger that does nothing by default
-	silentLogger := func(_ string, _ ...interface{}) {}
+	return uninstall(h, verbose, false)
+}
+
+// UninstallDryRun returns the release Uninstall would remove, without
+// deleting anything.
+func UninstallDryRun(h Helm, verbose bool) (*release.UninstallReleaseResponse, error) {
+	return uninstall(h, verbose, true)
+}
 
+func uninstall(h Helm, verbose, dryRun bool) (*release.UninstallReleaseResponse, error) {
 	// Create settings
 	settings := cli.New()
+	settings.SetNamespace(h.Namespace)
+	settings.EnvVars()
 
 	// Create action configuration
 	actionConfig := new(action.Configuration)
 
-	// Choose logging method based on verbose flag
-	logMethod := silentLogger
-	if verbose {
-		logMethod = log.Printf
-	}
+	logMethod := logger(h, verbose)
 
 	// Initialize action configuration with chosen logger
 	if err := actionConfig.Init(
@@ -381,20 +426,47 @@ func Uninstall(h Helm, verbose bool) (*release.UninstallReleaseResponse, error)
 		return &release.UninstallReleaseResponse{}, fmt.Errorf("failed to initialize Helm configuration: %w", err)
 	}
 
-	client := action.NewUninstall(actionConfig)
-	// Setting Namespace
-	settings.SetNamespace(h.Namespace)
-	settings.EnvVars()
-
-	settings.EnvVars()
-
-	client.Wait = true
-	client.Timeout = 5 * time.Minute
-
-	resp, err := client.Run(h.ReleaseName)
+	resp, err := newUninstall(actionConfig, dryRun).Run(h.ReleaseName)
 	if err != nil {
 		return &release.UninstallReleaseResponse{}, err
 	}
 
 	return resp, nil
 }
+
+// newUninstall returns the uninstall action. A dry run only looks the release
+// up, so there is nothing to wait for.
+func newUninstall(actionConfig *action.Configuration, dryRun bool) *action.Uninstall {
+	client := action.NewUninstall(actionConfig)
+	client.DryRun = dryRun
+	client.Wait = !dryRun
+	client.Timeout = 5 * time.Minute
+	return client
+}
+
+// ReleaseResources lists the resources in a release manifest as kind/name,
+// in the order they appear.
+func ReleaseResources(manifest string) []string {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var resources []string
+	for _, key := range keys {
+		doc := docs[key]
+		var resource struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &resource); err != nil || resource.Kind == "" {
+			continue
+		}
+		resources = append(resources, resource.Kind+"/"+resource.Metadata.Name)
+	}
+	return resources
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+const testManifest = `---
+# Source: parseable/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: parseable
+---
+# Source: parseable/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: parseable
+`
+
+// memoryConfiguration returns an action configuration storing releases in
+// memory, with the release parseable deployed
+func memoryConfiguration(t *testing.T) *action.Configuration {
+	store := storage.Init(driver.NewMemory())
+	err := store.Create(&release.Release{
+		Name:      "parseable",
+		Namespace: "parseable",
+		Version:   1,
+		Info:      &release.Info{Status: release.StatusDeployed},
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "parseable", Version: "1.6.5"}},
+		Manifest:  testManifest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &action.Configuration{
+		Releases:   store,
+		KubeClient: &kubefake.PrintingKubeClient{Out: io.Discard},
+		Log:        func(string, ...interface{}) {},
+	}
+}
+
+func TestNewUninstall(t *testing.T) {
+	client := newUninstall(memoryConfiguration(t), false)
+	if client.DryRun || !client.Wait || client.Timeout == 0 {
+		t.Errorf("expected uninstall to wait for the release to be removed, got dry run %t, wait %t, timeout %s", client.DryRun, client.Wait, client.Timeout)
+	}
+
+	client = newUninstall(memoryConfiguration(t), true)
+	if !client.DryRun || client.Wait {
+		t.Errorf("expected a dry run without waiting, got dry run %t, wait %t", client.DryRun, client.Wait)
+	}
+}
+
+func TestUninstallDryRunKeepsRelease(t *testing.T) {
+	cfg := memoryConfiguration(t)
+
+	resp, err := newUninstall(cfg, true).Run("parseable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Release == nil || resp.Release.Name != "parseable" {
+		t.Fatalf("expected the dry run to return the release, got %+v", resp)
+	}
+	if _, err := cfg.Releases.Last("parseable"); err != nil {
+		t.Fatalf("dry run removed the release: %v", err)
+	}
+
+	if _, err := newUninstall(cfg, false).Run("parseable"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Releases.Last("parseable"); err == nil {
+		t.Error("expected the release to be removed")
+	}
+}
+
+func TestReleaseResources(t *testing.T) {
+	got := ReleaseResources(testManifest)
+	want := []string{"Service/parseable", "Deployment/parseable"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRepoAddKeepsCredentialsPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	repoFile := filepath.Join(dir, "repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CONFIG", repoFile)
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	h := Helm{RepoName: "parseable-private", RepoURL: server.URL, Username: "admin", Password: "s3cret"}
+	// the second call updates the existing entry
+	for range 2 {
+		if err := repoAdd(h); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(repoFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Fatalf("repositories file is readable by others, mode %o", mode)
+		}
+	}
+}
+
+// serveChart serves a chart repository holding a parseable chart with a
+// single ConfigMap template and returns its URL
+func serveChart(t *testing.T) string {
+	dir := t.TempDir()
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "parseable", Version: "1.6.6"},
+		Templates: []*chart.File{{
+			Name: "templates/configmap.yaml",
+			Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n"),
+		}},
+	}
+	archive, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(c.Metadata, filepath.Base(archive), server.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(filepath.Join(dir, "index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return server.URL
+}
+
+func TestTemplateLeavesRepositoriesFile(t *testing.T) {
+	dir := t.TempDir()
+	repoFile := filepath.Join(dir, "repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CONFIG", repoFile)
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	h := Helm{ReleaseName: "parseable", Namespace: "parseable", RepoName: "parseable", RepoURL: serveChart(t), ChartName: "parseable", Version: "1.6.6"}
+	manifest, err := Template(h, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(manifest, "kind: ConfigMap") {
+		t.Errorf("chart was not rendered: %s", manifest)
+	}
+	if _, err := os.Stat(repoFile); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the repositories file: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// Compress is set by the --compress flag and gzips request bodies of at
+// least compressThreshold bytes. Responses are always accepted gzipped, the
+// transport asks for and decompresses them transparently.
+var Compress bool
+
+// compressThreshold is the smallest body worth the cost of compressing it
+const compressThreshold = 1024
+
+// gzipTransport compresses request bodies when Compress is set
+type gzipTransport struct {
+	next http.RoundTripper
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Compress || req.Header.Get("Content-Encoding") != "" || req.ContentLength < compressThreshold || req.GetBody == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	// the original body is replaced, close it as the transport would
+	compressed, err := gzipBody(req)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// the request must not be modified, send a copy whose body can be
+	// replayed when the transport retries it
+	gzipped := req.Clone(req.Context())
+	gzipped.Body = io.NopCloser(bytes.NewReader(compressed))
+	gzipped.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	gzipped.ContentLength = int64(len(compressed))
+	gzipped.Header.Set("Content-Encoding", "gzip")
+	return t.next.RoundTrip(gzipped)
+}
+
+// gzipBody returns the compressed request body, read from a fresh copy so
+// the body of req is left untouched
+func gzipBody(req *http.Request) ([]byte, error) {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.Copy(writer, body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
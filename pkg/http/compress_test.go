@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	Compress = true
+	defer func() { Compress = false }()
+
+	payload := `[` + strings.Repeat(`{"level":"info","message":"request served"},`, 100) + `{}]`
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = reader
+		}
+		received, _ := io.ReadAll(body)
+		if int64(len(received)) == r.ContentLength {
+			http.Error(w, "content length of the uncompressed body was sent", http.StatusBadRequest)
+			return
+		}
+
+		// echo the body back gzipped when the client accepts it
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write(received)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		_, _ = writer.Write(received)
+		writer.Close()
+	}))
+	defer server.Close()
+
+	client := DefaultClient(&config.Profile{URL: server.URL})
+	req, err := client.NewRequest(http.MethodPost, "ingest", bytes.NewBufferString(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	echoed, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("server rejected the request: %s", echoed)
+	}
+	if string(echoed) != payload {
+		t.Errorf("round trip changed the payload:\n%s", echoed)
+	}
+	if len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("expected a gzipped request, got encodings %v", encodings)
+	}
+
+	// the request body can still be replayed, as a retry would
+	replay, err := req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(replay); string(data) != payload {
+		t.Errorf("request body was modified")
+	}
+}
+
+func TestSmallBodiesAreNotCompressed(t *testing.T) {
+	Compress = true
+	defer func() { Compress = false }()
+
+	var encoding string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+	}))
+	defer server.Close()
+
+	client := DefaultClient(&config.Profile{URL: server.URL})
+	req, _ := client.NewRequest(http.MethodPut, "logstream/backend", strings.NewReader(`{}`))
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if encoding != "" {
+		t.Errorf("expected a small body to be sent as is, got encoding %q", encoding)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Exit codes of pb. Scripts can rely on these to tell failures apart.
+const (
+	ExitOK       = 0
+	ExitError    = 1 // any other failure
+	ExitAuth     = 2 // credentials rejected by the server
+	ExitNotFound = 3 // stream or resource does not exist
+	ExitServer   = 4 // server side failure (5xx)
+)
+
+// APIError is returned when the server answers with an unexpected status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	// Message is the error message of a JSON body, empty for other bodies
+	Message string
+}
+
+// NewAPIError builds an APIError from the response and its already read body
+func NewAPIError(resp *http.Response, body []byte) *APIError {
+	trimmed := strings.TrimSpace(string(body))
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       trimmed,
+		Message:    serverMessage(trimmed),
+	}
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = e.Body
+	}
+	if message == "" {
+		return fmt.Sprintf("request failed with status %s", e.Status)
+	}
+	return fmt.Sprintf("request failed with status %s: %s", e.Status, message)
+}
+
+// serverMessage returns the message of a JSON error body such as
+// {"error": "stream not found"}. Plain text bodies return an empty string.
+func serverMessage(body string) string {
+	if !strings.HasPrefix(body, "{") {
+		return ""
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return ""
+	}
+	for _, key := range []string{"message", "error", "detail", "msg"} {
+		if message, ok := fields[key].(string); ok && strings.TrimSpace(message) != "" {
+			return strings.TrimSpace(message)
+		}
+	}
+	return ""
+}
+
+// ExitCode maps an error returned by a command to the process exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ExitError
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+		return ExitAuth
+	case apiErr.StatusCode == http.StatusNotFound:
+		return ExitNotFound
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		return ExitServer
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestExitCodeForResponses(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{http.StatusUnauthorized, ExitAuth},
+		{http.StatusForbidden, ExitAuth},
+		{http.StatusNotFound, ExitNotFound},
+		{http.StatusInternalServerError, ExitServer},
+		{http.StatusServiceUnavailable, ExitServer},
+		{http.StatusBadRequest, ExitError},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, "stream backend does not exist\n")
+		}))
+
+		client := DefaultClient(&config.Profile{URL: server.URL})
+		req, err := client.NewRequest(http.MethodGet, "logstream/backend/stats", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %s", err)
+		}
+		resp, err := client.Client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		server.Close()
+
+		// callers usually wrap the error with more context
+		err = fmt.Errorf("failed to fetch stats: %w", NewAPIError(resp, body))
+		if got := ExitCode(err); got != tt.want {
+			t.Errorf("status %d: exit code = %d, want %d", tt.status, got, tt.want)
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Body != "stream backend does not exist" {
+			t.Errorf("status %d: unexpected error %v", tt.status, err)
+		}
+	}
+}
+
+func TestExitCodeWithoutStatus(t *testing.T) {
+	if got := ExitCode(nil); got != ExitOK {
+		t.Errorf("exit code for nil error = %d, want %d", got, ExitOK)
+	}
+	if got := ExitCode(errors.New("connection refused")); got != ExitError {
+		t.Errorf("exit code for plain error = %d, want %d", got, ExitError)
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error": "stream backend not found"}`, "request failed with status 404 Not Found: stream backend not found"},
+		{`{"message": "invalid query", "code": 12}`, "request failed with status 404 Not Found: invalid query"},
+		{"stream backend does not exist\n", "request failed with status 404 Not Found: stream backend does not exist"},
+		{`{"code": 12}`, `request failed with status 404 Not Found: {"code": 12}`},
+		{"", "request failed with status 404 Not Found"},
+	}
+	resp := &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	for _, tt := range tests {
+		if got := NewAPIError(resp, []byte(tt.body)).Error(); got != tt.want {
+			t.Errorf("body %q: error = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/logstream" {
+			fmt.Fprint(w, `[{"name":"backend"}]`)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "unknown stream"}`)
+	}))
+	defer server.Close()
+	client := DefaultClient(&config.Profile{URL: server.URL})
+
+	req, _ := client.NewRequest(http.MethodGet, "logstream", nil)
+	body, err := client.Do(req)
+	if err != nil || string(body) != `[{"name":"backend"}]` {
+		t.Errorf("Do = %q, %v", body, err)
+	}
+
+	req, _ = client.NewRequest(http.MethodGet, "logstream/missing/schema", nil)
+	_, err = client.Do(req)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "unknown stream" {
+		t.Errorf("expected an APIError with the server message, got %v", err)
+	}
+}
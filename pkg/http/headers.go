@@ -0,0 +1,142 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+
+	"pb/pkg/config"
+)
+
+// Headers is set by the repeatable --header flag. The headers are sent with
+// every request to the server and take precedence over the headers of the
+// profile.
+var Headers HeaderFlag
+
+// HeaderFlag is a list of "Name: value" headers, validated when the flag is
+// parsed
+type HeaderFlag struct {
+	Header http.Header
+}
+
+// String implements pflag.Value. Values may be credentials and are left out.
+func (h *HeaderFlag) String() string {
+	names := make([]string, 0, len(h.Header))
+	for name := range h.Header {
+		names = append(names, name+": "+redacted)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Set implements pflag.Value, every use of the flag adds a header
+func (h *HeaderFlag) Set(value string) error {
+	name, headerValue, err := parseHeader(value)
+	if err != nil {
+		return err
+	}
+	if h.Header == nil {
+		h.Header = http.Header{}
+	}
+	h.Header.Add(name, headerValue)
+	return nil
+}
+
+// Type implements pflag.Value
+func (h *HeaderFlag) Type() string {
+	return "header"
+}
+
+// parseHeader splits "Name: value" and canonicalizes the name
+func parseHeader(value string) (string, string, error) {
+	name, headerValue, found := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !found || !validHeaderName(name) {
+		return "", "", fmt.Errorf("invalid header %q, use \"Name: value\"", value)
+	}
+	return textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(headerValue), nil
+}
+
+// validHeaderName reports whether name is an HTTP token
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// customHeaders merges the headers of the profile with the --header flags
+func customHeaders(profile *config.Profile) (http.Header, error) {
+	header := http.Header{}
+	for name, value := range profile.Headers {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name %q in profile", name)
+		}
+		header.Set(name, value)
+	}
+	for name, values := range Headers.Header {
+		header[name] = values
+	}
+	return header, nil
+}
+
+// headerTransport sets custom headers on requests to the server of the
+// profile. Requests to other hosts, such as the analytics endpoint, are left
+// alone so the headers don't leak. Headers set by pb itself, including the
+// Authorization computed from the profile, are only replaced when a custom
+// header of the same name is given.
+type headerTransport struct {
+	next   http.RoundTripper
+	host   string
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+
+	// the request must not be modified, send a copy with the headers
+	withHeaders := req.Clone(req.Context())
+	for name, values := range t.header {
+		withHeaders.Header[name] = values
+	}
+	return t.next.RoundTrip(withHeaders)
+}
+
+// withCustomHeaders wraps next in a headerTransport when the profile or the
+// --header flags define headers
+func withCustomHeaders(next http.RoundTripper, profile *config.Profile) (http.RoundTripper, error) {
+	header, err := customHeaders(profile)
+	if err != nil || len(header) == 0 {
+		return next, err
+	}
+	serverURL, err := url.Parse(profile.URL)
+	if err != nil {
+		return next, fmt.Errorf("invalid server URL: %w", err)
+	}
+	return &headerTransport{next: next, host: serverURL.Host, header: header}, nil
+}
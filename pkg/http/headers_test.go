@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+// useHeaderServer starts a server recording the headers of the last request
+func useHeaderServer(t *testing.T) (*httptest.Server, *http.Header) {
+	received := &http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestCustomHeadersReachTheServer(t *testing.T) {
+	server, received := useHeaderServer(t)
+	for _, value := range []string{"X-Api-Gateway-Key: from-flag", "x-tenant: blue"} {
+		if err := Headers.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() { Headers = HeaderFlag{} }()
+
+	profile := config.Profile{
+		URL:      server.URL,
+		Username: "admin",
+		Password: "admin",
+		Headers:  map[string]string{"X-Api-Gateway-Key": "from-profile", "X-Region": "eu"},
+	}
+	client := DefaultClient(&profile)
+	req, err := client.NewRequest(http.MethodGet, "about", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := received.Get("X-Api-Gateway-Key"); got != "from-flag" {
+		t.Errorf("--header should override the profile, got %q", got)
+	}
+	if received.Get("X-Tenant") != "blue" || received.Get("X-Region") != "eu" {
+		t.Errorf("custom headers missing: %v", *received)
+	}
+	if username, _, ok := (&http.Request{Header: *received}).BasicAuth(); !ok || username != "admin" {
+		t.Errorf("the computed Authorization header was replaced: %q", received.Get("Authorization"))
+	}
+	if req.Header.Get("X-Tenant") != "" {
+		t.Error("the caller's request was modified")
+	}
+}
+
+func TestCustomAuthorizationHeaderIsExplicit(t *testing.T) {
+	server, received := useHeaderServer(t)
+	profile := config.Profile{URL: server.URL, Username: "admin", Password: "admin", Headers: map[string]string{"Authorization": "Bearer gateway-token"}}
+	client := DefaultClient(&profile)
+	req, _ := client.NewRequest(http.MethodGet, "about", nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := received.Get("Authorization"); got != "Bearer gateway-token" {
+		t.Errorf("an explicit Authorization header should be sent, got %q", got)
+	}
+}
+
+func TestCustomHeadersStayOnTheServer(t *testing.T) {
+	server, _ := useHeaderServer(t)
+	other, received := useHeaderServer(t)
+	profile := config.Profile{URL: server.URL, Headers: map[string]string{"X-Api-Gateway-Key": "secret"}}
+	client := DefaultClient(&profile)
+	resp, err := client.Client.Get(other.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if received.Get("X-Api-Gateway-Key") != "" {
+		t.Error("custom headers were sent to another host")
+	}
+}
+
+func TestHeaderFlag(t *testing.T) {
+	var flag HeaderFlag
+	for _, invalid := range []string{"no-colon", ": value", "bad name: value"} {
+		if err := flag.Set(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+	if err := flag.Set("x-api-key:  abc:def "); err != nil {
+		t.Fatal(err)
+	}
+	if got := flag.Header.Get("X-Api-Key"); got != "abc:def" {
+		t.Errorf("got value %q", got)
+	}
+	if strings.Contains(flag.String(), "abc") {
+		t.Errorf("flag value leaks the header value: %s", flag.String())
+	}
+}
@@ -17,32 +17,169 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"pb/pkg/config"
+	"pb/pkg/log"
+	"sync"
 	"time"
+
+	"golang.org/x/net/http/httpproxy"
 )
 
+// Insecure is set by the --insecure flag and disables TLS certificate
+// verification for every profile
+var Insecure bool
+
+var insecureWarning sync.Once
+
 type HTTPClient struct {
 	Client  http.Client
 	Profile *config.Profile
 }
 
 func DefaultClient(profile *config.Profile) HTTPClient {
+	client := http.Client{
+		Timeout: 60 * time.Second,
+	}
+
+	var err error
+	client.Transport = &loggingTransport{next: &gzipTransport{next: sharedTransport(profile)}}
+	if client.Transport, err = withCustomHeaders(client.Transport, profile); err != nil {
+		log.Warn("ignoring custom headers", "error", err)
+	}
+
 	return HTTPClient{
-		Client: http.Client{
-			Timeout: 60 * time.Second,
-		},
+		Client:  client,
 		Profile: profile,
 	}
 }
 
+// transportKey holds the settings a transport is built from
+type transportKey struct {
+	insecure    bool
+	caCertPath  string
+	proxy       string
+	environment httpproxy.Config
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[transportKey]*http.Transport{}
+)
+
+// sharedTransport returns the transport for the profile. Clients created with
+// the same TLS and proxy settings share it, so connections to the server are
+// kept alive and reused across clients instead of being dialed per request.
+func sharedTransport(profile *config.Profile) *http.Transport {
+	key := transportKey{insecure: Insecure || profile.Insecure, caCertPath: profile.CACertPath}
+	if Proxy.URL != nil {
+		key.proxy = Proxy.URL.String()
+	} else {
+		key.environment = *httpproxy.FromEnvironment()
+	}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if transport, ok := transports[key]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc()
+	tlsConfig, err := TLSConfig(profile)
+	if err != nil {
+		log.Warn("invalid TLS configuration, using the system certificate pool", "error", err)
+	} else if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	transports[key] = transport
+	return transport
+}
+
+// TLSConfig returns the TLS configuration for the profile, or nil when the
+// defaults apply
+func TLSConfig(profile *config.Profile) (*tls.Config, error) {
+	if Insecure || profile.Insecure {
+		insecureWarning.Do(func() {
+			fmt.Fprintln(os.Stderr, "WARNING: TLS certificate verification is disabled. The connection to the server is not secure.")
+		})
+		return &tls.Config{InsecureSkipVerify: true}, nil // #nosec G402 -- explicitly requested by the user
+	}
+
+	if profile.CACertPath == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(profile.CACertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate %s: %w", profile.CACertPath, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in %s", profile.CACertPath)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// loggingTransport logs every request and its outcome at debug level, with
+// headers and bodies when Debug is set
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Debug {
+		log.Debug("sending request", "method", req.Method, "url", req.URL.Redacted(), "headers", redactHeaders(req.Header), "body", requestBody(req))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		log.Debug("request failed", "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+	if Debug {
+		log.Debug("request completed", "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "duration", time.Since(start),
+			"headers", redactHeaders(resp.Header), "body", responseBody(req, resp))
+	} else {
+		log.Debug("request completed", "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "duration", time.Since(start))
+	}
+	return resp, nil
+}
+
 func (client *HTTPClient) baseAPIURL(path string) (x string) {
 	x, _ = url.JoinPath(client.Profile.URL, "api/v1/", path)
 	return
 }
 
+// Do sends req and returns the body of the response. Responses with a status
+// other than 2xx are returned as an *APIError carrying the server's message.
+func (client *HTTPClient) Do(req *http.Request) ([]byte, error) {
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, NewAPIError(resp, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
 func (client *HTTPClient) NewRequest(method string, path string, body io.Reader) (req *http.Request, err error) {
 	req, err = http.NewRequest(method, client.baseAPIURL(path), body)
 	if err != nil {
@@ -0,0 +1,128 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+func TestDefaultClientUsesProfileCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// without the CA the self-signed server certificate is rejected
+	profile := config.Profile{URL: server.URL}
+	client := DefaultClient(&profile)
+	if _, err := client.Client.Get(server.URL); err == nil {
+		t.Fatalf("expected certificate verification to fail without the CA")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA: %s", err)
+	}
+
+	profile.CACertPath = caPath
+	client = DefaultClient(&profile)
+	logging, _ := client.Client.Transport.(*loggingTransport)
+	if logging == nil {
+		t.Fatalf("client transport is not wrapped for logging")
+	}
+	gzipped, ok := logging.next.(*gzipTransport)
+	if !ok {
+		t.Fatalf("client transport is not wrapped for compression")
+	}
+	transport, ok := gzipped.next.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil || transport.TLSClientConfig.RootCAs == nil {
+		t.Fatalf("custom CA pool is not set on the transport")
+	}
+
+	resp, err := client.Client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with custom CA failed: %s", err)
+	}
+	resp.Body.Close()
+}
+
+// countingServer starts a server counting the connections dialed to it
+func countingServer(tb testing.TB) (*httptest.Server, *atomic.Int64) {
+	dialed := &atomic.Int64{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"v1.0.0"}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dialed.Add(1)
+		}
+	}
+	server.Start()
+	tb.Cleanup(server.Close)
+	return server, dialed
+}
+
+// get sends a request with a client created for it, the way commands do
+func get(tb testing.TB, profile *config.Profile) {
+	client := DefaultClient(profile)
+	req, err := client.NewRequest(http.MethodGet, "about", nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := client.Do(req); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestDefaultClientsReuseConnections(t *testing.T) {
+	server, dialed := countingServer(t)
+	profile := config.Profile{URL: server.URL}
+
+	for i := 0; i < 5; i++ {
+		get(t, &profile)
+	}
+	if n := dialed.Load(); n != 1 {
+		t.Errorf("expected clients of the same profile to share one connection, %d were dialed", n)
+	}
+
+	// a different TLS setting gets a transport of its own
+	insecure := config.Profile{URL: server.URL, Insecure: true}
+	if DefaultClient(&insecure).Client.Transport.(*loggingTransport).next.(*gzipTransport).next == DefaultClient(&profile).Client.Transport.(*loggingTransport).next.(*gzipTransport).next {
+		t.Error("expected an insecure profile not to share the transport of a verifying one")
+	}
+}
+
+func BenchmarkDefaultClientRequests(b *testing.B) {
+	server, dialed := countingServer(b)
+	profile := config.Profile{URL: server.URL}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		get(b, &profile)
+	}
+	b.ReportMetric(float64(dialed.Load())/float64(b.N), "dials/op")
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Proxy is set by the --proxy flag. It replaces the proxy configured by the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+var Proxy ProxyURL
+
+// ProxyURL is the URL of an HTTP or SOCKS5 proxy, validated when the flag is
+// parsed
+type ProxyURL struct {
+	URL *url.URL
+}
+
+// String implements pflag.Value
+func (p *ProxyURL) String() string {
+	if p.URL == nil {
+		return ""
+	}
+	return p.URL.Redacted()
+}
+
+// Set implements pflag.Value
+func (p *ProxyURL) Set(value string) error {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy URL %q, the scheme must be http, https or socks5", value)
+	}
+	if proxyURL.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q, the host is missing", value)
+	}
+	p.URL = proxyURL
+	return nil
+}
+
+// Type implements pflag.Value
+func (p *ProxyURL) Type() string {
+	return "url"
+}
+
+// proxyFunc selects the proxy of each request. Unlike http.ProxyFromEnvironment,
+// which reads the environment once per process, the environment is read when
+// the client is created.
+func proxyFunc() func(*http.Request) (*url.URL, error) {
+	if Proxy.URL != nil {
+		return http.ProxyURL(Proxy.URL)
+	}
+	fromEnvironment := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return fromEnvironment(req.URL)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pb/pkg/config"
+)
+
+// useProxy starts a proxy recording the URLs it was asked for
+func useProxy(t *testing.T) (*httptest.Server, *[]string) {
+	requested := &[]string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requested = append(*requested, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, requested
+}
+
+func TestDefaultClientUsesProxyFromEnvironment(t *testing.T) {
+	proxy, requested := useProxy(t)
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	profile := config.Profile{URL: "http://parseable.example.com"}
+	client := DefaultClient(&profile)
+	req, err := client.NewRequest(http.MethodGet, "about", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		t.Fatalf("request through the proxy failed: %s", err)
+	}
+	resp.Body.Close()
+
+	if len(*requested) != 1 || (*requested)[0] != "http://parseable.example.com/api/v1/about" {
+		t.Fatalf("expected the request to go through the proxy, proxy saw %v", *requested)
+	}
+
+	// hosts in NO_PROXY are reached directly
+	req, _ = http.NewRequest(http.MethodGet, "http://internal.example.com", nil)
+	if proxyURL, err := client.Client.Transport.(*loggingTransport).next.(*gzipTransport).next.(*http.Transport).Proxy(req); err != nil || proxyURL != nil {
+		t.Errorf("expected no proxy for a NO_PROXY host, got %v (%v)", proxyURL, err)
+	}
+}
+
+func TestProxyFlagOverridesEnvironment(t *testing.T) {
+	proxy, requested := useProxy(t)
+	t.Setenv("HTTP_PROXY", "http://unreachable.invalid:3128")
+	if err := Proxy.Set(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { Proxy = ProxyURL{} }()
+
+	profile := config.Profile{URL: "http://parseable.example.com"}
+	client := DefaultClient(&profile)
+	resp, err := client.Client.Get("http://parseable.example.com/api/v1/about")
+	if err != nil {
+		t.Fatalf("request through the proxy failed: %s", err)
+	}
+	resp.Body.Close()
+	if len(*requested) != 1 {
+		t.Fatalf("expected the request to go through the --proxy proxy, proxy saw %v", *requested)
+	}
+}
+
+func TestProxyURLValidation(t *testing.T) {
+	var p ProxyURL
+	for _, value := range []string{"http://proxy:3128", "https://proxy", "socks5://proxy:1080"} {
+		if err := p.Set(value); err != nil {
+			t.Errorf("Set(%q): %s", value, err)
+		}
+	}
+	for _, value := range []string{"proxy:3128", "ftp://proxy", "http://"} {
+		if err := p.Set(value); err == nil {
+			t.Errorf("Set(%q): expected an error", value)
+		}
+	}
+}
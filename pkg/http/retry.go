@@ -0,0 +1,81 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"pb/pkg/log"
+)
+
+// RetryPolicy controls how often DoWithRetry sends a request
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first one
+	Attempts int
+	// Backoff is the wait before the second try, doubled after every retry
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy suits idempotent requests on flaky links
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: time.Second}
+
+// sleep waits between retries, replaced in tests
+var sleep = time.Sleep
+
+// DoWithRetry sends the request built by newRequest until it succeeds, fails
+// with an error that retrying can't fix or the attempts are used up. Network
+// errors and 408, 429 and 5xx responses are retried. newRequest is called for
+// every attempt, so bodies that can only be read once, such as open files,
+// are rebuilt. Only use it for requests that are safe to repeat.
+func (client *HTTPClient) DoWithRetry(policy RetryPolicy, newRequest func() (*http.Request, error)) ([]byte, error) {
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := client.Do(req)
+		if err == nil || attempt >= policy.Attempts || !retriable(err) {
+			return body, err
+		}
+
+		log.Warn("request failed, retrying", "method", req.Method, "url", req.URL.Redacted(), "attempt", attempt, "wait", backoff.String(), "error", err)
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// retriable reports whether sending the request again may succeed
+func retriable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		// the request didn't get a response, e.g. a reset connection
+		return true
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+}
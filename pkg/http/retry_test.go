@@ -0,0 +1,77 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+)
+
+// retryServer answers with the given statuses in turn, then with 200
+func retryServer(t *testing.T, statuses ...int) (*HTTPClient, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls <= len(statuses) {
+			w.WriteHeader(statuses[calls-1])
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := sleep
+	sleep = func(time.Duration) {}
+	t.Cleanup(func() { sleep = previous })
+
+	client := DefaultClient(&config.Profile{URL: server.URL})
+	return &client, &calls
+}
+
+func TestDoWithRetry(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	newRequest := func(client *HTTPClient) func() (*http.Request, error) {
+		return func() (*http.Request, error) {
+			return client.NewRequest(http.MethodPut, "logstream/backend", strings.NewReader("{}"))
+		}
+	}
+
+	client, calls := retryServer(t, http.StatusBadGateway, http.StatusTooManyRequests)
+	body, err := client.DoWithRetry(policy, newRequest(client))
+	if err != nil || string(body) != "ok" || *calls != 3 {
+		t.Errorf("expected success on the third attempt, got %q, %v after %d calls", body, err, *calls)
+	}
+
+	client, calls = retryServer(t, http.StatusBadRequest)
+	_, err = client.DoWithRetry(policy, newRequest(client))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || *calls != 1 {
+		t.Errorf("expected a client error not to be retried, got %v after %d calls", err, *calls)
+	}
+
+	client, calls = retryServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	_, err = client.DoWithRetry(policy, newRequest(client))
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || *calls != 3 {
+		t.Errorf("expected to give up after 3 attempts, got %v after %d calls", err, *calls)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Debug is set by the --debug flag and traces the headers and bodies of every
+// request and response, with credentials redacted
+var Debug bool
+
+// maxTracedBody is the number of body bytes shown in a trace
+const maxTracedBody = 2048
+
+const redacted = "[REDACTED]"
+
+// headers that carry credentials and are never traced
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// custom headers whose name suggests a credential, e.g. X-Api-Gateway-Key
+var sensitiveHeaderName = regexp.MustCompile(`(?i)key|token|secret|password`)
+
+var (
+	// "password": "...", "secret_key": "..." and similar JSON members
+	sensitiveMember = regexp.MustCompile(`(?i)("[a-z_-]*(?:password|secret|token|key)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// credentials in an Authorization value written into a body
+	authScheme = regexp.MustCompile(`(?i)\b(basic|bearer)\s+[a-z0-9+/=._~-]+`)
+)
+
+// redactHeaders returns a copy of header with the credentials replaced
+func redactHeaders(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if clone.Get(name) != "" {
+			clone.Set(name, redacted)
+		}
+	}
+	for name := range clone {
+		if sensitiveHeaderName.MatchString(name) {
+			clone.Set(name, redacted)
+		}
+	}
+	return clone
+}
+
+// streamedContentTypes are response bodies that arrive over time and are
+// never traced, reading them ahead would hold back the caller
+var streamedContentTypes = []string{"application/x-ndjson", "text/event-stream"}
+
+// redactBody replaces credentials in body and truncates it to maxTracedBody.
+// Callers read at most maxTracedBody+1 bytes, so a longer body is only
+// marked as truncated.
+func redactBody(body []byte) string {
+	text := string(body)
+	truncated := len(text) > maxTracedBody
+	if truncated {
+		text = text[:maxTracedBody]
+	}
+	text = sensitiveMember.ReplaceAllString(text, `$1"`+redacted+`"`)
+	text = authScheme.ReplaceAllString(text, "$1 "+redacted)
+	if truncated {
+		text += "... (truncated)"
+	}
+	return text
+}
+
+// returnsCredentials reports whether the response to req carries a
+// credential, like the password generated when a user is created
+func returnsCredentials(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.Contains(req.URL.Path, "/api/v1/user/")
+}
+
+// requestBody returns a copy of the request body without consuming it
+func requestBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	if req.GetBody == nil {
+		return "(streamed body not shown)"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Sprintf("(body not available: %s)", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxTracedBody+1))
+	if err != nil {
+		return fmt.Sprintf("(body not available: %s)", err)
+	}
+	return redactBody(data)
+}
+
+// responseBody reads the start of the response body and puts it back in
+// front of the rest, so the caller still reads the whole body. Only the
+// first maxTracedBody+1 bytes are buffered.
+func responseBody(req *http.Request, resp *http.Response) string {
+	contentType := resp.Header.Get("Content-Type")
+	for _, streamed := range streamedContentTypes {
+		if strings.HasPrefix(contentType, streamed) {
+			return "(streamed body not shown)"
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTracedBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil {
+		return fmt.Sprintf("(body not available: %s)", err)
+	}
+	if returnsCredentials(req) {
+		return redacted
+	}
+	return redactBody(data)
+}
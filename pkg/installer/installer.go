@@ -19,28 +19,37 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"pb/pkg/common"
 	"pb/pkg/helm"
+	"pb/pkg/log"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/manifoldco/promptui"
 	yamling "gopkg.in/yaml.v3"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -48,24 +57,74 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/client-go/util/retry"
 )
 
-func Installer(verbose bool) {
-	printBanner()
-	waterFall(verbose)
+const (
+	defaultRepoName  = "parseable"
+	defaultChartName = "parseable"
+
+	// privateRepoName is the helm repository entry of a custom chart
+	// repository, kept apart from the user's entry of the public one
+	privateRepoName = "parseable-private"
+
+	// parseableSecretName is the secret holding the server configuration and credentials
+	parseableSecretName = "parseable-env-secret"
+
+	// DefaultRepoURL is the public Parseable Helm chart repository
+	DefaultRepoURL = "https://charts.parseable.com"
+	// DefaultChartVersion is the Parseable chart version installed when none is specified
+	DefaultChartVersion = "1.6.6"
+)
+
+func Installer(opts InstallOptions) error {
+	if !opts.DryRun {
+		printBanner()
+		return waterFall(opts)
+	}
+
+	// keep stdout limited to the rendered YAML on dry runs, prompts and
+	// status lines go to stderr
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	common.PromptOutput = os.Stderr
+	defer func() {
+		os.Stdout = stdout
+		common.PromptOutput = nil
+	}()
+	return waterFall(opts)
 }
 
 // waterFall orchestrates the installation process
-func waterFall(verbose bool) {
+func waterFall(opts InstallOptions) error {
+	if opts.RepoURL == "" {
+		opts.RepoURL = DefaultRepoURL
+	}
+	if opts.ChartVersion == "" {
+		opts.ChartVersion = DefaultChartVersion
+	}
+	if _, err := semver.StrictNewVersion(opts.ChartVersion); err != nil {
+		return fmt.Errorf("invalid chart version %q, expected a semantic version such as %s: %w", opts.ChartVersion, DefaultChartVersion, err)
+	}
+
 	var chartValues []string
+	if opts.Playground {
+		// playground installs run without prompts against the current kubernetes context
+		chartValues = append(chartValues, "parseable.store=local-store")
+		chartValues = append(chartValues, "parseable.localModeSecret.enabled=true")
+		return installPlayground(opts, chartValues)
+	}
+
 	plan, err := promptUserPlanSelection()
 	if err != nil {
-		log.Fatalf("Failed to prompt for plan selection: %v", err)
+		return fmt.Errorf("failed to prompt for plan selection: %w", err)
 	}
 
 	_, err = common.PromptK8sContext()
 	if err != nil {
-		log.Fatalf("Failed to prompt for kubernetes context: %v", err)
+		return fmt.Errorf("failed to prompt for kubernetes context: %w", err)
 	}
 
 	if plan.Name == "Playground" {
@@ -75,33 +134,45 @@ func waterFall(verbose bool) {
 		// Prompt for namespace and credentials
 		pbInfo, err := promptNamespaceAndCredentials()
 		if err != nil {
-			log.Fatalf("Failed to prompt for namespace and credentials: %v", err)
+			return fmt.Errorf("failed to prompt for namespace and credentials: %w", err)
 		}
 
 		// Prompt for agent deployment
 		_, agentValues, err := promptAgentDeployment(chartValues, *pbInfo)
 		if err != nil {
-			log.Fatalf("Failed to prompt for agent deployment: %v", err)
-		}
-
-		if err := applyParseableSecret(pbInfo, LocalStore, ObjectStoreConfig{}); err != nil {
-			log.Fatalf("Failed to apply secret object store configuration: %v", err)
+			return fmt.Errorf("failed to prompt for agent deployment: %w", err)
 		}
 
 		// Define the deployment configuration
 		config := HelmDeploymentConfig{
 			ReleaseName: pbInfo.Name,
 			Namespace:   pbInfo.Namespace,
-			RepoName:    "parseable",
-			RepoURL:     "https://charts.parseable.com",
-			ChartName:   "parseable",
-			Version:     "1.6.6",
+			RepoName:    chartRepoName(opts.RepoURL),
+			RepoURL:     opts.RepoURL,
+			ChartName:   defaultChartName,
+			Version:     opts.ChartVersion,
 			Values:      agentValues,
-			Verbose:     verbose,
+			Verbose:     opts.Verbose,
+		}
+		promptRepoCredentials(&config)
+
+		if opts.DryRun {
+			if err := renderRelease(pbInfo, LocalStore, ObjectStoreConfig{}, config); err != nil {
+				return fmt.Errorf("failed to render parseable manifests: %w", err)
+			}
+			return nil
+		}
+
+		if err := checkExistingRelease(&config, true); err != nil {
+			return err
+		}
+
+		if err := applyParseableSecret(pbInfo, LocalStore, ObjectStoreConfig{}); err != nil {
+			return fmt.Errorf("failed to apply secret object store configuration: %w", err)
 		}
 
 		if err := deployRelease(config); err != nil {
-			log.Fatalf("Failed to deploy parseable, err: %v", err)
+			return fmt.Errorf("failed to deploy parseable: %w", err)
 		}
 
 		if err := updateInstallerConfigMap(common.InstallerEntry{
@@ -110,12 +181,12 @@ func waterFall(verbose bool) {
 			Version:   config.Version,
 			Status:    "success",
 		}); err != nil {
-			log.Fatalf("Failed to update parseable installer file, err: %v", err)
+			return fmt.Errorf("failed to update parseable installer file: %w", err)
 		}
 
 		printSuccessBanner(*pbInfo, config.Version, "parseable", "parseable")
 
-		return
+		return nil
 	}
 
 	// pb supports only distributed deployments
@@ -124,45 +195,57 @@ func waterFall(verbose bool) {
 	// Prompt for namespace and credentials
 	pbInfo, err := promptNamespaceAndCredentials()
 	if err != nil {
-		log.Fatalf("Failed to prompt for namespace and credentials: %v", err)
+		return fmt.Errorf("failed to prompt for namespace and credentials: %w", err)
 	}
 
 	// Prompt for agent deployment
 	_, agentValues, err := promptAgentDeployment(chartValues, *pbInfo)
 	if err != nil {
-		log.Fatalf("Failed to prompt for agent deployment: %v", err)
+		return fmt.Errorf("failed to prompt for agent deployment: %w", err)
 	}
 
 	// Prompt for store configuration
 	store, storeValues, err := promptStore(agentValues)
 	if err != nil {
-		log.Fatalf("Failed to prompt for store configuration: %v", err)
+		return fmt.Errorf("failed to prompt for store configuration: %w", err)
 	}
 
 	// Prompt for object store configuration and get the final chart values
 	objectStoreConfig, storeConfigs, err := promptStoreConfigs(store, storeValues, plan)
 	if err != nil {
-		log.Fatalf("Failed to prompt for object store configuration: %v", err)
-	}
-
-	if err := applyParseableSecret(pbInfo, store, objectStoreConfig); err != nil {
-		log.Fatalf("Failed to apply secret object store configuration: %v", err)
+		return fmt.Errorf("failed to prompt for object store configuration: %w", err)
 	}
 
 	// Define the deployment configuration
 	config := HelmDeploymentConfig{
 		ReleaseName: pbInfo.Name,
 		Namespace:   pbInfo.Namespace,
-		RepoName:    "parseable",
-		RepoURL:     "https://charts.parseable.com",
-		ChartName:   "parseable",
-		Version:     "1.6.6",
+		RepoName:    chartRepoName(opts.RepoURL),
+		RepoURL:     opts.RepoURL,
+		ChartName:   defaultChartName,
+		Version:     opts.ChartVersion,
 		Values:      storeConfigs,
-		Verbose:     verbose,
+		Verbose:     opts.Verbose,
+	}
+	promptRepoCredentials(&config)
+
+	if opts.DryRun {
+		if err := renderRelease(pbInfo, store, objectStoreConfig, config); err != nil {
+			return fmt.Errorf("failed to render parseable manifests: %w", err)
+		}
+		return nil
+	}
+
+	if err := checkExistingRelease(&config, true); err != nil {
+		return err
+	}
+
+	if err := applyParseableSecret(pbInfo, store, objectStoreConfig); err != nil {
+		return fmt.Errorf("failed to apply secret object store configuration: %w", err)
 	}
 
 	if err := deployRelease(config); err != nil {
-		log.Fatalf("Failed to deploy parseable, err: %v", err)
+		return fmt.Errorf("failed to deploy parseable: %w", err)
 	}
 
 	if err := updateInstallerConfigMap(common.InstallerEntry{
@@ -171,13 +254,92 @@ func waterFall(verbose bool) {
 		Version:   config.Version,
 		Status:    "success",
 	}); err != nil {
-		log.Fatalf("Failed to update parseable installer file, err: %v", err)
+		return fmt.Errorf("failed to update parseable installer file: %w", err)
 	}
 
 	ingestorURL, queryURL := getParseableSvcUrls(pbInfo.Name, pbInfo.Namespace)
 
 	printSuccessBanner(*pbInfo, config.Version, ingestorURL, queryURL)
+	return nil
+}
 
+// installPlayground deploys a local store Parseable with default values and generated credentials
+func installPlayground(opts InstallOptions, chartValues []string) error {
+	password, err := generatePassword()
+	if err != nil {
+		return fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	pbInfo := &ParseableInfo{
+		Name:      "parseable",
+		Namespace: "parseable",
+		Username:  "admin",
+		Password:  password,
+	}
+
+	config := HelmDeploymentConfig{
+		ReleaseName: pbInfo.Name,
+		Namespace:   pbInfo.Namespace,
+		RepoName:    chartRepoName(opts.RepoURL),
+		RepoURL:     opts.RepoURL,
+		ChartName:   defaultChartName,
+		Version:     opts.ChartVersion,
+		Values:      chartValues,
+		Verbose:     opts.Verbose,
+	}
+
+	if opts.DryRun {
+		if err := renderRelease(pbInfo, LocalStore, ObjectStoreConfig{}, config); err != nil {
+			return fmt.Errorf("failed to render parseable manifests: %w", err)
+		}
+		return nil
+	}
+
+	// playground installs never prompt, so they leave an existing release
+	// and its credentials alone instead of replacing them
+	if err := checkExistingRelease(&config, false); err != nil {
+		return err
+	}
+	exists, err := secretExists(pbInfo.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to check for an existing secret: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("secret %s already exists in namespace %s, remove it to install the playground", parseableSecretName, pbInfo.Namespace)
+	}
+
+	if err := applyParseableSecret(pbInfo, LocalStore, ObjectStoreConfig{}); err != nil {
+		return fmt.Errorf("failed to apply secret object store configuration: %w", err)
+	}
+
+	if err := deployRelease(config); err != nil {
+		return fmt.Errorf("failed to deploy parseable: %w", err)
+	}
+
+	if err := updateInstallerConfigMap(common.InstallerEntry{
+		Name:      pbInfo.Name,
+		Namespace: pbInfo.Namespace,
+		Version:   config.Version,
+		Status:    "success",
+	}); err != nil {
+		return fmt.Errorf("failed to update parseable installer file: %w", err)
+	}
+
+	printSuccessBanner(*pbInfo, config.Version, "parseable", "parseable")
+
+	fmt.Println("\n" + common.Yellow + "Generated credentials (store them safely):" + common.Reset)
+	fmt.Printf("  • Username:         %s\n", pbInfo.Username)
+	fmt.Printf("  • Password:         %s\n", pbInfo.Password)
+	return nil
+}
+
+// generatePassword returns a random hex encoded password
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // promptStorageClass fetches and prompts the user to select a Kubernetes storage class
@@ -214,8 +376,9 @@ func promptStorageClass() (string, error) {
 
 	// Use promptui to allow the user to select a storage class
 	prompt := promptui.Select{
-		Label: "Select a Kubernetes storage class",
-		Items: storageClassNames,
+		Stdout: common.PromptOutput,
+		Label:  "Select a Kubernetes storage class",
+		Items:  storageClassNames,
 	}
 
 	_, selectedStorageClass, err := prompt.Run()
@@ -226,6 +389,58 @@ func promptStorageClass() (string, error) {
 	return selectedStorageClass, nil
 }
 
+// createNamespaceOption is the namespace prompt entry for typing a new namespace
+const createNamespaceOption = "+ Create a new namespace"
+
+// promptNamespace lets the user pick an existing namespace, or type the name of
+// a new one that will be created during the install
+func promptNamespace(reader *bufio.Reader) (string, error) {
+	config, err := loadKubeConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	namespaces, err := clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch namespaces: %w", err)
+	}
+
+	items := []string{createNamespaceOption}
+	for _, ns := range namespaces.Items {
+		items = append(items, ns.Name)
+	}
+
+	prompt := promptui.Select{
+		Stdout: common.PromptOutput,
+		Label:  "Select the Kubernetes namespace for deployment",
+		Items:  items,
+		Size:   10,
+	}
+	_, selected, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to select namespace: %w", err)
+	}
+	if selected != createNamespaceOption {
+		return selected, nil
+	}
+
+	fmt.Print(common.Yellow + "Enter the name of the new namespace: " + common.Reset)
+	namespace, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read namespace: %w", err)
+	}
+	namespace = strings.TrimSpace(namespace)
+	if namespace == "" {
+		return "", fmt.Errorf("namespace cannot be empty")
+	}
+	return namespace, nil
+}
+
 // promptNamespaceAndCredentials prompts the user for namespace and credentials
 func promptNamespaceAndCredentials() (*ParseableInfo, error) {
 	// Prompt user for release name
@@ -238,12 +453,10 @@ func promptNamespaceAndCredentials() (*ParseableInfo, error) {
 	name = strings.TrimSpace(name)
 
 	// Prompt user for namespace
-	fmt.Print(common.Yellow + "Enter the Kubernetes namespace for deployment: " + common.Reset)
-	namespace, err := reader.ReadString('\n')
+	namespace, err := promptNamespace(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read namespace: %w", err)
+		return nil, err
 	}
-	namespace = strings.TrimSpace(namespace)
 
 	// Prompt for username
 	fmt.Print(common.Yellow + "Enter the Parseable username: " + common.Reset)
@@ -269,8 +482,8 @@ func promptNamespaceAndCredentials() (*ParseableInfo, error) {
 	}, nil
 }
 
-// applyParseableSecret creates and applies the Kubernetes secret
-func applyParseableSecret(ps *ParseableInfo, store ObjectStore, objectStoreConfig ObjectStoreConfig) error {
+// parseableSecretManifest returns the Kubernetes secret manifest for the selected store
+func parseableSecretManifest(ps *ParseableInfo, store ObjectStore, objectStoreConfig ObjectStoreConfig) string {
 	var secretManifest string
 	if store == LocalStore {
 		secretManifest = getParseableSecretLocal(ps)
@@ -281,6 +494,12 @@ func applyParseableSecret(ps *ParseableInfo, store ObjectStore, objectStoreConfi
 	} else if store == GcsStore {
 		secretManifest = getParseableSecretGcs(ps, objectStoreConfig)
 	}
+	return secretManifest
+}
+
+// applyParseableSecret creates and applies the Kubernetes secret
+func applyParseableSecret(ps *ParseableInfo, store ObjectStore, objectStoreConfig ObjectStoreConfig) error {
+	secretManifest := parseableSecretManifest(ps, store, objectStoreConfig)
 
 	// apply the Kubernetes Secret
 	if err := applyManifest(secretManifest); err != nil {
@@ -291,7 +510,39 @@ func applyParseableSecret(ps *ParseableInfo, store ObjectStore, objectStoreConfi
 	return nil
 }
 
+// parseableSecretExists reports whether namespace already holds the secret
+// with the server configuration and credentials
+func parseableSecretExists(namespace string) (bool, error) {
+	config, err := loadKubeConfig()
+	if err != nil {
+		return false, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Secrets(namespace).Get(context.TODO(), parseableSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func getParseableSecretBlob(ps *ParseableInfo, objectStore ObjectStoreConfig) string {
+	// Only the keys of the configured authentication method are written, so
+	// the server never sees both an access key and a service principal
+	var credentials string
+	if objectStore.BlobStore.AccessKey != "" {
+		credentials = fmt.Sprintf("  azr.access_key: %s\n",
+			base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.AccessKey)))
+	} else {
+		credentials = fmt.Sprintf("  azr.client_id: %s\n  azr.client_secret: %s\n  azr.tenant_id: %s\n",
+			base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.ClientID)),
+			base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.ClientSecret)),
+			base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.TenantID)))
+	}
+
 	// Create the Secret manifest
 	secretManifest := fmt.Sprintf(`
 apiVersion: v1
@@ -301,8 +552,7 @@ metadata:
   namespace: %s
 type: Opaque
 data:
-  azr.access_key: %s
-  azr.account: %s
+%s  azr.account: %s
   azr.container: %s
   azr.url: %s
   username: %s
@@ -312,7 +562,7 @@ data:
   staging.dir: %s
 `,
 		ps.Namespace,
-		base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.AccessKey)),
+		credentials,
 		base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.StorageAccountName)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.Container)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.BlobStore.URL)),
@@ -325,6 +575,147 @@ data:
 	return secretManifest
 }
 
+// Authentication methods supported for Azure Blob Storage
+const (
+	blobAuthAccessKey        = "Access key"
+	blobAuthServicePrincipal = "Service principal (client ID / secret / tenant ID)"
+)
+
+// promptBlobAuthMethod asks whether to authenticate with an access key or a service principal
+func promptBlobAuthMethod() (string, error) {
+	prompt := promptui.Select{
+		Stdout: common.PromptOutput,
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ `Blob authentication` | yellow }}",
+			Active:   "▸ {{ . | yellow }} ",
+			Inactive: "  {{ . | yellow }}",
+			Selected: "{{ `Selected authentication:` | green }} '{{ . | green }}' ✔ ",
+		},
+		Items: []string{blobAuthAccessKey, blobAuthServicePrincipal},
+	}
+	_, method, err := prompt.Run()
+	return method, err
+}
+
+// validateBlobAuth checks that exactly one authentication method is fully configured
+func validateBlobAuth(blob Blob) error {
+	hasAccessKey := blob.AccessKey != ""
+	hasServicePrincipal := blob.ClientID != "" || blob.ClientSecret != "" || blob.TenantID != ""
+
+	switch {
+	case hasAccessKey && hasServicePrincipal:
+		return fmt.Errorf("access key and service principal credentials are mutually exclusive")
+	case hasAccessKey:
+		return nil
+	case hasServicePrincipal:
+		if blob.ClientID == "" || blob.ClientSecret == "" || blob.TenantID == "" {
+			return fmt.Errorf("client ID, client secret and tenant ID are all required for service principal authentication")
+		}
+		return nil
+	default:
+		return fmt.Errorf("either an access key or service principal credentials must be provided")
+	}
+}
+
+// Authentication methods supported for Google Cloud Storage
+const (
+	gcsAuthHMACKeys         = "HMAC keys (access key / secret key)"
+	gcsAuthWorkloadIdentity = "Workload identity (no static keys)"
+)
+
+// promptGcsAuthMethod asks whether to authenticate with HMAC keys or workload identity
+func promptGcsAuthMethod() (string, error) {
+	prompt := promptui.Select{
+		Stdout: common.PromptOutput,
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ `GCS authentication` | yellow }}",
+			Active:   "▸ {{ . | yellow }} ",
+			Inactive: "  {{ . | yellow }}",
+			Selected: "{{ `Selected authentication:` | green }} '{{ . | green }}' ✔ ",
+		},
+		Items: []string{gcsAuthHMACKeys, gcsAuthWorkloadIdentity},
+	}
+	_, method, err := prompt.Run()
+	return method, err
+}
+
+// validateGcsAuth checks that exactly one authentication method is fully configured
+func validateGcsAuth(gcs GCS) error {
+	hasKeys := gcs.AccessKey != "" || gcs.SecretKey != ""
+	hasWorkloadIdentity := gcs.GCPServiceAccount != "" || gcs.ServiceAccount != ""
+
+	switch {
+	case hasKeys && hasWorkloadIdentity:
+		return fmt.Errorf("HMAC keys and workload identity are mutually exclusive")
+	case hasKeys:
+		if gcs.AccessKey == "" || gcs.SecretKey == "" {
+			return fmt.Errorf("access key and secret key are both required for HMAC key authentication")
+		}
+		return nil
+	case hasWorkloadIdentity:
+		if gcs.GCPServiceAccount == "" || gcs.ServiceAccount == "" {
+			return fmt.Errorf("the Kubernetes and Google service accounts are both required for workload identity")
+		}
+		if !strings.HasSuffix(gcs.GCPServiceAccount, ".iam.gserviceaccount.com") {
+			return fmt.Errorf("%s is not a Google service account email, expected NAME@PROJECT.iam.gserviceaccount.com", gcs.GCPServiceAccount)
+		}
+		return nil
+	default:
+		return fmt.Errorf("either HMAC keys or workload identity must be configured")
+	}
+}
+
+// gcsWorkloadIdentityValues returns the chart values that run Parseable as
+// the Kubernetes service account bound to the Google service account
+func gcsWorkloadIdentityValues(gcs GCS) []string {
+	return []string{
+		"parseable.serviceAccount.create=true",
+		"parseable.serviceAccount.name=" + gcs.ServiceAccount,
+		`parseable.serviceAccount.annotations.iam\.gke\.io/gcp-service-account=` + gcs.GCPServiceAccount,
+	}
+}
+
+// Kinds of S3 endpoints the installer can configure
+const (
+	s3EndpointAWS    = "AWS S3"
+	s3EndpointCustom = "Custom endpoint (MinIO or other S3-compatible store)"
+)
+
+// promptS3Endpoint asks whether the bucket is on AWS or on an S3-compatible store
+func promptS3Endpoint() (string, error) {
+	prompt := promptui.Select{
+		Stdout: common.PromptOutput,
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ `S3 endpoint` | yellow }}",
+			Active:   "▸ {{ . | yellow }} ",
+			Inactive: "  {{ . | yellow }}",
+			Selected: "{{ `Selected endpoint:` | green }} '{{ . | green }}' ✔ ",
+		},
+		Items: []string{s3EndpointAWS, s3EndpointCustom},
+	}
+	_, endpoint, err := prompt.Run()
+	return endpoint, err
+}
+
+// validateS3Endpoint checks that endpoint is an http(s) URL of the store
+// itself. The bucket is configured separately, so a path is rejected.
+func validateS3Endpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid S3 endpoint %q: scheme must be http or https", endpoint)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid S3 endpoint %q: host is missing", endpoint)
+	}
+	if strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" {
+		return fmt.Errorf("invalid S3 endpoint %q: must not include a path or query, the bucket is set separately", endpoint)
+	}
+	return nil
+}
+
 func getParseableSecretS3(ps *ParseableInfo, objectStore ObjectStoreConfig) string {
 	// Create the Secret manifest
 	secretManifest := fmt.Sprintf(`
@@ -340,6 +731,7 @@ data:
   s3.bucket: %s
   s3.access.key: %s
   s3.secret.key: %s
+  s3.path.style: %s
   username: %s
   password: %s
   addr: %s
@@ -352,6 +744,7 @@ data:
 		base64.StdEncoding.EncodeToString([]byte(objectStore.S3Store.Bucket)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.S3Store.AccessKey)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.S3Store.SecretKey)),
+		base64.StdEncoding.EncodeToString([]byte(strconv.FormatBool(objectStore.S3Store.PathStyle))),
 		base64.StdEncoding.EncodeToString([]byte(ps.Username)),
 		base64.StdEncoding.EncodeToString([]byte(ps.Password)),
 		base64.StdEncoding.EncodeToString([]byte("0.0.0.0:8000")),
@@ -362,6 +755,15 @@ data:
 }
 
 func getParseableSecretGcs(ps *ParseableInfo, objectStore ObjectStoreConfig) string {
+	// With workload identity the pod authenticates as a Google service
+	// account, so no keys are written
+	var credentials string
+	if objectStore.GCSStore.GCPServiceAccount == "" {
+		credentials = fmt.Sprintf("  gcs.access.key: %s\n  gcs.secret.key: %s\n",
+			base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.AccessKey)),
+			base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.SecretKey)))
+	}
+
 	// Create the Secret manifest
 	secretManifest := fmt.Sprintf(`
 apiVersion: v1
@@ -374,9 +776,7 @@ data:
   gcs.url: %s
   gcs.region: %s
   gcs.bucket: %s
-  gcs.access.key: %s
-  gcs.secret.key: %s
-  username: %s
+%s  username: %s
   password: %s
   addr: %s
   fs.dir: %s
@@ -386,8 +786,7 @@ data:
 		base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.URL)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.Region)),
 		base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.Bucket)),
-		base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.AccessKey)),
-		base64.StdEncoding.EncodeToString([]byte(objectStore.GCSStore.SecretKey)),
+		credentials,
 		base64.StdEncoding.EncodeToString([]byte(ps.Username)),
 		base64.StdEncoding.EncodeToString([]byte(ps.Password)),
 		base64.StdEncoding.EncodeToString([]byte("0.0.0.0:8000")),
@@ -428,7 +827,8 @@ data:
 func promptAgentDeployment(chartValues []string, pbInfo ParseableInfo) (string, []string, error) {
 	// Prompt for Agent Deployment type
 	promptAgentSelect := promptui.Select{
-		Items: []string{string(fluentbit), string(vector), "I have my agent running / I'll set up later"},
+		Stdout: common.PromptOutput,
+		Items:  []string{string(fluentbit), string(vector), "I have my agent running / I'll set up later"},
 		Templates: &promptui.SelectTemplates{
 			Label:    "{{ `Logging agent` | yellow }}",
 			Active:   "▸ {{ . | yellow }} ", // Yellow arrow and context name for active selection
@@ -451,7 +851,8 @@ func promptAgentDeployment(chartValues []string, pbInfo ParseableInfo) (string,
 
 		// Prompt for namespaces to exclude
 		promptExcludeNamespaces := promptui.Prompt{
-			Label: "Enter namespaces to exclude from collection (comma-separated, e.g., kube-system,default): ",
+			Stdout: common.PromptOutput,
+			Label:  "Enter namespaces to exclude from collection (comma-separated, e.g., kube-system,default): ",
 			Templates: &promptui.PromptTemplates{
 				Prompt:  "{{ `Namespaces to exclude` | yellow }}: ",
 				Valid:   "{{ `` | green }}: {{ . | yellow }}",
@@ -476,6 +877,7 @@ func promptAgentDeployment(chartValues []string, pbInfo ParseableInfo) (string,
 func promptStore(chartValues []string) (ObjectStore, []string, error) {
 	// Prompt for store type
 	promptStore := promptui.Select{
+		Stdout: common.PromptOutput,
 		Templates: &promptui.SelectTemplates{
 			Label:    "{{ `Object store` | yellow }}",
 			Active:   "▸ {{ . | yellow }} ", // Yellow arrow and context name for active selection
@@ -509,7 +911,7 @@ func promptStoreConfigs(store ObjectStore, chartValues []string, plan Plan) (Obj
 	// Initialize a struct to hold store values
 	var storeValues ObjectStoreConfig
 
-	fmt.Println(common.Green + "Configuring:" + common.Reset + " " + store)
+	fmt.Println(common.Green + "Configuring:" + common.Reset + " " + string(store))
 
 	// Store selected store type in chart values
 	switch store {
@@ -521,15 +923,31 @@ func promptStoreConfigs(store ObjectStore, chartValues []string, plan Plan) (Obj
 			Bucket:    promptForInputWithDefault(common.Yellow+"  Enter S3 Bucket: "+common.Reset, ""),
 		}
 
-		// Dynamically construct the URL after Region is set
-		storeValues.S3Store.URL = promptForInputWithDefault(
-			common.Yellow+"  Enter S3 URL (default: https://s3."+storeValues.S3Store.Region+".amazonaws.com): "+common.Reset,
-			"https://s3."+storeValues.S3Store.Region+".amazonaws.com",
-		)
+		endpoint, err := promptS3Endpoint()
+		if err != nil {
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for S3 endpoint: %w", err)
+		}
+		if endpoint == s3EndpointCustom {
+			storeValues.S3Store.URL = promptForInputWithDefault(common.Yellow+"  Enter S3 endpoint URL (e.g. http://minio.minio.svc:9000): "+common.Reset, "")
+			pathStyle := promptForInputWithDefault(common.Yellow+"  Use path-style addressing? (Y/n): "+common.Reset, "y")
+			storeValues.S3Store.PathStyle = !strings.EqualFold(pathStyle, "n") && !strings.EqualFold(pathStyle, "no")
+		} else {
+			// Dynamically construct the URL after Region is set
+			storeValues.S3Store.URL = promptForInputWithDefault(
+				common.Yellow+"  Enter S3 URL (default: https://s3."+storeValues.S3Store.Region+".amazonaws.com): "+common.Reset,
+				"https://s3."+storeValues.S3Store.Region+".amazonaws.com",
+			)
+		}
+		if err := validateS3Endpoint(storeValues.S3Store.URL); err != nil {
+			return storeValues, chartValues, err
+		}
+		if storeValues.S3Store.PathStyle {
+			chartValues = append(chartValues, "parseable.env.P_S3_PATH_STYLE=true")
+		}
 
 		sc, err := promptStorageClass()
 		if err != nil {
-			log.Fatalf("Failed to prompt for storage class: %v", err)
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for storage class: %w", err)
 		}
 		storeValues.StorageClass = sc
 		storeValues.ObjectStore = S3Store
@@ -547,15 +965,26 @@ func promptStoreConfigs(store ObjectStore, chartValues []string, plan Plan) (Obj
 	case BlobStore:
 		sc, err := promptStorageClass()
 		if err != nil {
-			log.Fatalf("Failed to prompt for storage class: %v", err)
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for storage class: %w", err)
 		}
 		storeValues.BlobStore = Blob{
 			StorageAccountName: promptForInputWithDefault(common.Yellow+"  Enter Blob Storage Account Name: "+common.Reset, ""),
 			Container:          promptForInputWithDefault(common.Yellow+"  Enter Blob Container: "+common.Reset, ""),
-			// ClientID:           promptForInputWithDefault(common.Yellow+"  Enter Client ID: "+common.Reset, ""),
-			// ClientSecret:       promptForInputWithDefault(common.Yellow+"  Enter Client Secret: "+common.Reset, ""),
-			// TenantID:           promptForInputWithDefault(common.Yellow+"  Enter Tenant ID: "+common.Reset, ""),
-			AccessKey: promptForInputWithDefault(common.Yellow+"  Enter Access Keys: "+common.Reset, ""),
+		}
+
+		authMethod, err := promptBlobAuthMethod()
+		if err != nil {
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for blob authentication method: %w", err)
+		}
+		if authMethod == blobAuthServicePrincipal {
+			storeValues.BlobStore.ClientID = promptForInputWithDefault(common.Yellow+"  Enter Client ID: "+common.Reset, "")
+			storeValues.BlobStore.ClientSecret = promptForInputWithDefault(common.Yellow+"  Enter Client Secret: "+common.Reset, "")
+			storeValues.BlobStore.TenantID = promptForInputWithDefault(common.Yellow+"  Enter Tenant ID: "+common.Reset, "")
+		} else {
+			storeValues.BlobStore.AccessKey = promptForInputWithDefault(common.Yellow+"  Enter Access Keys: "+common.Reset, "")
+		}
+		if err := validateBlobAuth(storeValues.BlobStore); err != nil {
+			return storeValues, chartValues, fmt.Errorf("invalid blob store credentials: %w", err)
 		}
 
 		// Dynamically construct the URL after Region is set
@@ -580,14 +1009,30 @@ func promptStoreConfigs(store ObjectStore, chartValues []string, plan Plan) (Obj
 	case GcsStore:
 		sc, err := promptStorageClass()
 		if err != nil {
-			log.Fatalf("Failed to prompt for storage class: %v", err)
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for storage class: %w", err)
 		}
 		storeValues.GCSStore = GCS{
-			Bucket:    promptForInputWithDefault(common.Yellow+"  Enter GCS Bucket: "+common.Reset, ""),
-			Region:    promptForInputWithDefault(common.Yellow+"  Enter GCS Region (default: us-east1): "+common.Reset, "us-east1"),
-			URL:       promptForInputWithDefault(common.Yellow+"  Enter GCS URL (default: https://storage.googleapis.com):", "https://storage.googleapis.com"),
-			AccessKey: promptForInputWithDefault(common.Yellow+"  Enter GCS Access Key: "+common.Reset, ""),
-			SecretKey: promptForInputWithDefault(common.Yellow+"  Enter GCS Secret Key: "+common.Reset, ""),
+			Bucket: promptForInputWithDefault(common.Yellow+"  Enter GCS Bucket: "+common.Reset, ""),
+			Region: promptForInputWithDefault(common.Yellow+"  Enter GCS Region (default: us-east1): "+common.Reset, "us-east1"),
+			URL:    promptForInputWithDefault(common.Yellow+"  Enter GCS URL (default: https://storage.googleapis.com):", "https://storage.googleapis.com"),
+		}
+
+		authMethod, err := promptGcsAuthMethod()
+		if err != nil {
+			return storeValues, chartValues, fmt.Errorf("failed to prompt for GCS authentication method: %w", err)
+		}
+		if authMethod == gcsAuthWorkloadIdentity {
+			storeValues.GCSStore.ServiceAccount = promptForInputWithDefault(common.Yellow+"  Enter Kubernetes Service Account (default: parseable): "+common.Reset, "parseable")
+			storeValues.GCSStore.GCPServiceAccount = promptForInputWithDefault(common.Yellow+"  Enter Google Service Account email: "+common.Reset, "")
+		} else {
+			storeValues.GCSStore.AccessKey = promptForInputWithDefault(common.Yellow+"  Enter GCS Access Key: "+common.Reset, "")
+			storeValues.GCSStore.SecretKey = promptForInputWithDefault(common.Yellow+"  Enter GCS Secret Key: "+common.Reset, "")
+		}
+		if err := validateGcsAuth(storeValues.GCSStore); err != nil {
+			return storeValues, chartValues, fmt.Errorf("invalid GCS credentials: %w", err)
+		}
+		if authMethod == gcsAuthWorkloadIdentity {
+			chartValues = append(chartValues, gcsWorkloadIdentityValues(storeValues.GCSStore)...)
 		}
 
 		storeValues.StorageClass = sc
@@ -665,13 +1110,32 @@ func applyManifest(manifest string) error {
 	}
 
 	// Apply the manifest using the dynamic client
-	_, err = dynamicClient.Resource(gvr).Namespace(namespace).Create(context.TODO(), &obj, metav1.CreateOptions{})
-	if err != nil {
+	if err := createOrUpdate(context.TODO(), dynamicClient.Resource(gvr).Namespace(namespace), &obj); err != nil {
 		return fmt.Errorf("failed to apply manifest: %w", err)
 	}
 	return nil
 }
 
+// createOrUpdate creates the object, or updates it in place if it already
+// exists from a previous install
+func createOrUpdate(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+	log.Debug("creating resource", "kind", obj.GetKind(), "name", obj.GetName(), "namespace", obj.GetNamespace())
+	_, err := client.Create(ctx, obj, metav1.CreateOptions{})
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	log.Debug("resource already exists, updating it", "kind", obj.GetKind(), "name", obj.GetName())
+
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
 // loadKubeConfig loads the kubeconfig from the default location
 func loadKubeConfig() (*rest.Config, error) {
 	kubeconfig := clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
@@ -725,20 +1189,45 @@ func printBanner() {
 }
 
 type HelmDeploymentConfig struct {
-	ReleaseName string
-	Namespace   string
-	RepoName    string
-	RepoURL     string
-	ChartName   string
-	Version     string
-	Values      []string
-	Verbose     bool
+	ReleaseName  string
+	Namespace    string
+	RepoName     string
+	RepoURL      string
+	RepoUsername string
+	RepoPassword string
+	RepoCAFile   string
+	ChartName    string
+	Version      string
+	Values       []string
+	Verbose      bool
+	Upgrade      bool // Upgrade the existing release instead of installing a new one
 }
 
-// deployRelease handles the deployment of a Helm release using a configuration struct
-func deployRelease(config HelmDeploymentConfig) error {
-	// Helm application configuration
-	app := helm.Helm{
+// promptRepoCredentials asks for chart repository credentials when a custom repository is used
+func promptRepoCredentials(config *HelmDeploymentConfig) {
+	if config.RepoURL == DefaultRepoURL {
+		return
+	}
+
+	fmt.Println(common.Green + "Configuring:" + common.Reset + " chart repository " + config.RepoURL)
+	config.RepoUsername = promptForInputWithDefault(common.Yellow+"  Enter repository username (leave empty for public repositories): "+common.Reset, "")
+	if config.RepoUsername != "" {
+		config.RepoPassword = promptForInputWithDefault(common.Yellow+"  Enter repository password: "+common.Reset, "")
+	}
+	config.RepoCAFile = promptForInputWithDefault(common.Yellow+"  Enter path to repository CA file (optional): "+common.Reset, "")
+}
+
+// chartRepoName returns the name of the helm repository entry for repoURL
+func chartRepoName(repoURL string) string {
+	if repoURL == DefaultRepoURL {
+		return defaultRepoName
+	}
+	return privateRepoName
+}
+
+// helmApp converts the deployment configuration to a Helm application configuration
+func helmApp(config HelmDeploymentConfig) helm.Helm {
+	return helm.Helm{
 		ReleaseName: config.ReleaseName,
 		Namespace:   config.Namespace,
 		RepoName:    config.RepoName,
@@ -746,18 +1235,115 @@ func deployRelease(config HelmDeploymentConfig) error {
 		ChartName:   config.ChartName,
 		Version:     config.Version,
 		Values:      config.Values,
+		Username:    config.RepoUsername,
+		Password:    config.RepoPassword,
+		CAFile:      config.RepoCAFile,
+	}
+}
+
+// manifestOutput receives the rendered manifests of a dry run. It is the
+// stdout of the process, stdout itself points to stderr during a dry run.
+var manifestOutput io.Writer = os.Stdout
+
+// renderRelease prints the secret and chart manifests as a single YAML stream instead of applying them
+func renderRelease(ps *ParseableInfo, store ObjectStore, objectStoreConfig ObjectStoreConfig, config HelmDeploymentConfig) error {
+	manifest, err := helm.Template(helmApp(config), config.Verbose)
+	if err != nil {
+		return err
 	}
 
+	fmt.Fprintln(manifestOutput, "---")
+	fmt.Fprintln(manifestOutput, strings.TrimSpace(parseableSecretManifest(ps, store, objectStoreConfig)))
+	fmt.Fprintln(manifestOutput, strings.TrimSpace(manifest))
+	return nil
+}
+
+// deployRelease handles the deployment of a Helm release using a configuration struct
+// Helm and cluster operations used by the installer, replaced in tests
+var (
+	releaseExists  = helm.ListRelease
+	installRelease = helm.Apply
+	upgradeRelease = helm.Upgrade
+	confirmUpgrade = promptUpgrade
+	secretExists   = parseableSecretExists
+)
+
+// checkExistingRelease switches config to an upgrade when the release is
+// already deployed and the user confirms it. Without interaction an existing
+// release is never touched and the install is aborted.
+func checkExistingRelease(config *HelmDeploymentConfig, interactive bool) error {
+	exists, err := releaseExists(config.ReleaseName, config.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to check for an existing release: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	if !interactive {
+		return fmt.Errorf("release %s already exists in namespace %s, uninstall it first or run the install without --playground", config.ReleaseName, config.Namespace)
+	}
+	upgrade, err := confirmUpgrade(config.ReleaseName, config.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to prompt for upgrade: %w", err)
+	}
+	if !upgrade {
+		return fmt.Errorf("release %s already exists in namespace %s, installation aborted", config.ReleaseName, config.Namespace)
+	}
+	config.Upgrade = true
+	return nil
+}
+
+// promptUpgrade asks whether an existing release should be upgraded
+func promptUpgrade(releaseName, namespace string) (bool, error) {
+	const (
+		upgrade = "Upgrade"
+		abort   = "Abort"
+	)
+	prompt := promptui.Select{
+		Stdout: common.PromptOutput,
+		Label:  fmt.Sprintf("Release %s already exists in namespace %s", releaseName, namespace),
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . | yellow }}",
+			Active:   "▸ {{ . | yellow }} ",
+			Inactive: "  {{ . | yellow }}",
+			Selected: "{{ `Selected:` | green }} '{{ . | green }}' ✔ ",
+		},
+		Items: []string{upgrade, abort},
+	}
+	_, choice, err := prompt.Run()
+	return choice == upgrade, err
+}
+
+func deployRelease(config HelmDeploymentConfig) error {
+	// Helm application configuration
+	app := helmApp(config)
+	log.Debug("deploying helm release", "release", config.ReleaseName, "namespace", config.Namespace, "chart_version", config.Version, "upgrade", config.Upgrade)
+
 	// Create a spinner
-	msg := fmt.Sprintf(" Deploying parseable release name [%s] namespace [%s] ", config.ReleaseName, config.Namespace)
+	action := "Deploying"
+	if config.Upgrade {
+		action = "Upgrading"
+	}
+	msg := fmt.Sprintf(" %s parseable release name [%s] namespace [%s] ", action, config.ReleaseName, config.Namespace)
 	spinner := common.CreateDeploymentSpinner(msg)
 
-	// Redirect standard output if not in verbose mode
-	var oldStdout *os.File
+	// Capture standard output and the Helm log if not in verbose mode, they
+	// are only shown when the deployment fails
+	var output lockedBuffer
+	var oldStdout, stdoutWriter *os.File
+	copied := make(chan struct{})
 	if !config.Verbose {
-		oldStdout = os.Stdout
-		_, w, _ := os.Pipe()
-		os.Stdout = w
+		app.Output = &output
+		if r, w, err := os.Pipe(); err == nil {
+			oldStdout, stdoutWriter = os.Stdout, w
+			os.Stdout = w
+			go func() {
+				_, _ = io.Copy(&output, r)
+				r.Close()
+				close(copied)
+			}()
+		}
 	}
 
 	spinner.Start()
@@ -769,7 +1355,11 @@ func deployRelease(config HelmDeploymentConfig) error {
 
 	go func() {
 		defer wg.Done()
-		if err := helm.Apply(app, config.Verbose); err != nil {
+		deploy := installRelease
+		if config.Upgrade {
+			deploy = upgradeRelease
+		}
+		if err := deploy(app, config.Verbose); err != nil {
 			errCh <- err
 		}
 	}()
@@ -779,18 +1369,41 @@ func deployRelease(config HelmDeploymentConfig) error {
 
 	// Stop the spinner and restore stdout
 	spinner.Stop()
-	if !config.Verbose {
+	if stdoutWriter != nil {
 		os.Stdout = oldStdout
+		stdoutWriter.Close()
+		<-copied
 	}
 
 	// Check for errors
 	if err, ok := <-errCh; ok {
+		if captured := strings.TrimSpace(output.String()); captured != "" {
+			fmt.Fprintf(os.Stderr, "\n%sHelm output:%s\n%s\n\n", common.Yellow, common.Reset, captured)
+		}
 		return err
 	}
 
 	return nil
 }
 
+// lockedBuffer is a buffer that is safe for concurrent writes
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
 // printSuccessBanner remains the same as in the original code
 func printSuccessBanner(pbInfo ParseableInfo, version, ingestorURL, queryURL string) {
 
@@ -836,30 +1449,53 @@ func printSuccessBanner(pbInfo ParseableInfo, version, ingestorURL, queryURL str
 }
 
 func startPortForward(namespace, serviceName, remotePort, localPort string, verbose bool) error {
-	// Build the port-forward command
-	cmd := exec.Command("kubectl", "port-forward",
-		fmt.Sprintf("svc/%s", serviceName),
-		fmt.Sprintf("%s:%s", localPort, remotePort),
-		"-n", namespace,
-	)
+	config, err := loadKubeConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
 
-	// Redirect the command's output to the standard output for debugging
-	if !verbose {
-		cmd.Stdout = nil // Suppress standard output
-		cmd.Stderr = nil // Suppress standard error
-	} else {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
-	// Run the command in the background
-	if err := cmd.Start(); err != nil {
+	// Port forwarding works against pods, resolve a running pod and its port behind the service
+	podName, podPort, err := resolveServicePod(clientset, namespace, serviceName, remotePort)
+	if err != nil {
+		return err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+
+	reqURL := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, reqURL)
+
+	// Redirect the forwarder's output to the standard output for debugging
+	var out, errOut io.Writer = io.Discard, io.Discard
+	if verbose {
+		out, errOut = os.Stdout, os.Stderr
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	forwarder, err := portforward.New(dialer, []string{fmt.Sprintf("%s:%s", localPort, podPort)}, stopCh, readyCh, out, errOut)
+	if err != nil {
 		return fmt.Errorf("failed to start port-forward: %w", err)
 	}
 
 	// Run in a goroutine to keep it alive
 	go func() {
-		_ = cmd.Wait()
+		if err := forwarder.ForwardPorts(); err != nil {
+			log.Debug("port-forward stopped", "error", err)
+		}
 	}()
 
 	// Check connection on the forwarded port
@@ -876,10 +1512,58 @@ func startPortForward(namespace, serviceName, remotePort, localPort string, verb
 	}
 
 	// If we reach here, port-forwarding failed
-	cmd.Process.Kill() // Stop the kubectl process
+	close(stopCh)
 	return fmt.Errorf(common.Red+"failed to establish port-forward connection to localhost:%s", localPort)
 }
 
+// resolveServicePod returns a running pod backing the service and the pod port that serves remotePort
+func resolveServicePod(clientset kubernetes.Interface, namespace, serviceName, remotePort string) (string, string, error) {
+	svc, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get service %s: %w", serviceName, err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list pods for service %s: %w", serviceName, err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		return pod.Name, servicePodPort(svc, &pod, remotePort), nil
+	}
+
+	return "", "", fmt.Errorf("no running pods found for service %s", serviceName)
+}
+
+// servicePodPort maps a service port to the container port it targets on the pod
+func servicePodPort(svc *v1.Service, pod *v1.Pod, remotePort string) string {
+	for _, port := range svc.Spec.Ports {
+		if strconv.Itoa(int(port.Port)) != remotePort {
+			continue
+		}
+		if port.TargetPort.Type == intstr.Int {
+			if port.TargetPort.IntVal == 0 {
+				return remotePort
+			}
+			return strconv.Itoa(int(port.TargetPort.IntVal))
+		}
+		// named target port, look it up on the pod containers
+		for _, container := range pod.Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if containerPort.Name == port.TargetPort.StrVal {
+					return strconv.Itoa(int(containerPort.ContainerPort))
+				}
+			}
+		}
+	}
+	return remotePort
+}
+
 func openBrowser(url string) {
 	var cmd *exec.Cmd
 	switch os := runtime.GOOS; os {
@@ -944,63 +1628,86 @@ func updateInstallerConfigMap(entry common.InstallerEntry) error {
 		Resource: "configmaps",
 	}
 
-	// Fetch the existing ConfigMap or initialize a new one
-	cm, err := dynamicClient.Resource(configMapResource).Namespace(namespace).Get(context.TODO(), configMapName, metav1.GetOptions{})
-	var data map[string]interface{}
-	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to fetch ConfigMap: %v", err)
-		}
-		// If not found, initialize a new ConfigMap
-		data = map[string]interface{}{
-			"metadata": map[string]interface{}{
-				"name":      configMapName,
-				"namespace": namespace,
-			},
-			"data": map[string]interface{}{},
-		}
-	} else {
-		data = cm.Object
-	}
+	return reconcileInstallerEntry(context.TODO(), dynamicClient.Resource(configMapResource).Namespace(namespace), configMapName, dataKey, entry)
+}
 
-	// Retrieve existing data and append the new entry
-	existingData := data["data"].(map[string]interface{})
-	var entries []common.InstallerEntry
-	if raw, ok := existingData[dataKey]; ok {
-		if err := yaml.Unmarshal([]byte(raw.(string)), &entries); err != nil {
-			return fmt.Errorf("failed to parse existing ConfigMap data: %v", err)
-		}
+// reconcileInstallerEntry records the entry in the installer ConfigMap. An
+// existing entry with the same name and namespace is replaced, and the write is
+// retried when a concurrent install modified the ConfigMap in the meantime.
+func reconcileInstallerEntry(ctx context.Context, client dynamic.ResourceInterface, configMapName, dataKey string, entry common.InstallerEntry) error {
+	isRetriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
 	}
-	entries = append(entries, entry)
 
-	// Marshal the updated data back to YAML
-	updatedData, err := yamling.Marshal(entries)
-	if err != nil {
-		return fmt.Errorf("failed to marshal updated data: %v", err)
-	}
+	return retry.OnError(retry.DefaultRetry, isRetriable, func() error {
+		// Fetch the existing ConfigMap or initialize a new one
+		cm, err := client.Get(ctx, configMapName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to fetch ConfigMap: %w", err)
+			}
+			cm = nil
+		}
 
-	// Update the ConfigMap data
-	existingData[dataKey] = string(updatedData)
-	data["data"] = existingData
+		var entries []common.InstallerEntry
+		if cm != nil {
+			raw, _, err := unstructured.NestedString(cm.Object, "data", dataKey)
+			if err != nil {
+				return fmt.Errorf("failed to read existing ConfigMap data: %w", err)
+			}
+			if err := yaml.Unmarshal([]byte(raw), &entries); err != nil {
+				return fmt.Errorf("failed to parse existing ConfigMap data: %w", err)
+			}
+		}
+		entries = upsertInstallerEntry(entries, entry)
 
-	// Apply the ConfigMap
-	if cm == nil {
-		_, err = dynamicClient.Resource(configMapResource).Namespace(namespace).Create(context.TODO(), &unstructured.Unstructured{
-			Object: data,
-		}, metav1.CreateOptions{})
+		// Marshal the updated data back to YAML
+		updatedData, err := yamling.Marshal(entries)
 		if err != nil {
-			return fmt.Errorf("failed to create ConfigMap: %v", err)
+			return fmt.Errorf("failed to marshal updated data: %w", err)
 		}
-	} else {
-		_, err = dynamicClient.Resource(configMapResource).Namespace(namespace).Update(context.TODO(), &unstructured.Unstructured{
-			Object: data,
-		}, metav1.UpdateOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to update ConfigMap: %v", err)
+
+		if cm == nil {
+			cm = &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name": configMapName,
+					},
+				},
+			}
+			if err := unstructured.SetNestedField(cm.Object, string(updatedData), "data", dataKey); err != nil {
+				return err
+			}
+			if _, err := client.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create ConfigMap: %w", err)
+			}
+			return nil
 		}
-	}
 
-	return nil
+		// The ResourceVersion from Get makes this update fail with a conflict
+		// if the ConfigMap changed since it was read
+		if err := unstructured.SetNestedField(cm.Object, string(updatedData), "data", dataKey); err != nil {
+			return err
+		}
+		if _, err := client.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update ConfigMap: %w", err)
+		}
+		return nil
+	})
+}
+
+// upsertInstallerEntry replaces the entry for the same release and namespace,
+// or appends it if the release has not been recorded before
+func upsertInstallerEntry(entries []common.InstallerEntry, entry common.InstallerEntry) []common.InstallerEntry {
+	for i, existing := range entries {
+		if existing.Name == entry.Name && existing.Namespace == entry.Namespace {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
 }
 
 func getParseableSvcUrls(releaseName, namespace string) (ingestorURL, queryURL string) {
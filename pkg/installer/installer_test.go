@@ -0,0 +1,392 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pb/pkg/common"
+	"pb/pkg/helm"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func testSecret(username string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      "parseable-env-secret",
+				"namespace": "parseable",
+			},
+			"stringData": map[string]interface{}{
+				"username": username,
+			},
+		},
+	}
+}
+
+func TestCreateOrUpdateIsIdempotent(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme()).Resource(gvr).Namespace("parseable")
+	ctx := context.Background()
+
+	if err := createOrUpdate(ctx, client, testSecret("admin")); err != nil {
+		t.Fatalf("initial apply failed: %s", err)
+	}
+	// a re-install applies the same secret again with new values
+	if err := createOrUpdate(ctx, client, testSecret("parseable")); err != nil {
+		t.Fatalf("re-apply failed: %s", err)
+	}
+
+	secret, err := client.Get(ctx, "parseable-env-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get secret: %s", err)
+	}
+	username, _, _ := unstructured.NestedString(secret.Object, "stringData", "username")
+	if username != "parseable" {
+		t.Fatalf("secret was not updated, expected username parseable, actual %s", username)
+	}
+}
+
+func TestReconcileInstallerEntryUpdatesExistingRelease(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme()).Resource(gvr).Namespace("pb-system")
+	ctx := context.Background()
+
+	installs := []common.InstallerEntry{
+		{Name: "parseable", Namespace: "parseable", Version: "1.6.5"},
+		{Name: "parseable", Namespace: "staging", Version: "1.6.5"},
+		// re-installing the first release records the new version
+		{Name: "parseable", Namespace: "parseable", Version: "1.6.6"},
+	}
+	for _, entry := range installs {
+		if err := reconcileInstallerEntry(ctx, client, "parseable-installer", "installer-data", entry); err != nil {
+			t.Fatalf("failed to record %s/%s: %s", entry.Namespace, entry.Name, err)
+		}
+	}
+
+	cm, err := client.Get(ctx, "parseable-installer", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ConfigMap: %s", err)
+	}
+	raw, _, _ := unstructured.NestedString(cm.Object, "data", "installer-data")
+	var entries []common.InstallerEntry
+	if err := yaml.Unmarshal([]byte(raw), &entries); err != nil {
+		t.Fatalf("failed to parse ConfigMap data: %s", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, actual %d: %v", len(entries), entries)
+	}
+	if entries[0].Version != "1.6.6" {
+		t.Fatalf("entry was not updated, expected version 1.6.6, actual %s", entries[0].Version)
+	}
+}
+
+// stubRelease replaces the helm operations with a fake release store holding
+// the given releases and returns the names of the operations that ran
+func stubRelease(t *testing.T, existing map[string]bool, confirm bool) *[]string {
+	calls := &[]string{}
+	oldExists, oldInstall, oldUpgrade, oldConfirm := releaseExists, installRelease, upgradeRelease, confirmUpgrade
+	t.Cleanup(func() {
+		releaseExists, installRelease, upgradeRelease, confirmUpgrade = oldExists, oldInstall, oldUpgrade, oldConfirm
+	})
+
+	releaseExists = func(name, _ string) (bool, error) {
+		return existing[name], nil
+	}
+	installRelease = func(h helm.Helm, _ bool) error {
+		*calls = append(*calls, "install")
+		if existing[h.ReleaseName] {
+			return errors.New("cannot re-use a name that is still in use")
+		}
+		existing[h.ReleaseName] = true
+		return nil
+	}
+	upgradeRelease = func(_ helm.Helm, _ bool) error {
+		*calls = append(*calls, "upgrade")
+		return nil
+	}
+	confirmUpgrade = func(_, _ string) (bool, error) {
+		*calls = append(*calls, "confirm")
+		return confirm, nil
+	}
+	return calls
+}
+
+func TestReinstallUpgradesExistingRelease(t *testing.T) {
+	calls := stubRelease(t, map[string]bool{}, true)
+
+	for i := 0; i < 2; i++ {
+		config := HelmDeploymentConfig{ReleaseName: "parseable", Namespace: "parseable"}
+		if err := checkExistingRelease(&config, true); err != nil {
+			t.Fatalf("install %d: %s", i+1, err)
+		}
+		if err := deployRelease(config); err != nil {
+			t.Fatalf("install %d: %s", i+1, err)
+		}
+	}
+
+	if strings.Join(*calls, ",") != "install,confirm,upgrade" {
+		t.Fatalf("expected install then confirmed upgrade, actual %v", *calls)
+	}
+}
+
+func TestReinstallAbortsWithoutConfirmation(t *testing.T) {
+	calls := stubRelease(t, map[string]bool{"parseable": true}, false)
+
+	config := HelmDeploymentConfig{ReleaseName: "parseable", Namespace: "parseable"}
+	if err := checkExistingRelease(&config, true); err == nil {
+		t.Fatal("expected the install to be aborted")
+	}
+
+	// non-interactive installs never touch an existing release
+	if err := checkExistingRelease(&config, false); err == nil || config.Upgrade {
+		t.Fatalf("expected the install to be aborted, actual upgrade %v, error %v", config.Upgrade, err)
+	}
+	if strings.Join(*calls, ",") != "confirm" {
+		t.Fatalf("unexpected helm operations %v", *calls)
+	}
+}
+
+// stubSecret replaces the lookup of the parseable secret
+func stubSecret(t *testing.T, exists bool) {
+	oldSecretExists := secretExists
+	t.Cleanup(func() { secretExists = oldSecretExists })
+	secretExists = func(_ string) (bool, error) {
+		return exists, nil
+	}
+}
+
+func TestPlaygroundKeepsExistingRelease(t *testing.T) {
+	calls := stubRelease(t, map[string]bool{"parseable": true}, true)
+	stubSecret(t, true)
+
+	if err := installPlayground(InstallOptions{RepoURL: DefaultRepoURL}, nil); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected the playground install to be aborted, actual %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("existing release was changed: %v", *calls)
+	}
+}
+
+func TestPlaygroundKeepsExistingSecret(t *testing.T) {
+	calls := stubRelease(t, map[string]bool{}, true)
+	stubSecret(t, true)
+
+	err := installPlayground(InstallOptions{RepoURL: DefaultRepoURL}, nil)
+	if err == nil || !strings.Contains(err.Error(), parseableSecretName) {
+		t.Fatalf("expected the playground install to be aborted, actual %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("release was deployed over the existing secret: %v", *calls)
+	}
+}
+
+func TestDeployReleasePrintsHelmOutputOnFailure(t *testing.T) {
+	stubRelease(t, map[string]bool{}, true)
+	installRelease = func(h helm.Helm, _ bool) error {
+		fmt.Println("rendering chart")
+		fmt.Fprintln(h.Output, "template: parseable/templates/ingestor.yaml: nil pointer")
+		return errors.New("failed to render chart")
+	}
+
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStderr := os.Stderr
+	os.Stderr = stderr
+	defer func() { os.Stderr = oldStderr }()
+
+	if err := deployRelease(HelmDeploymentConfig{ReleaseName: "parseable", Namespace: "parseable"}); err == nil {
+		t.Fatal("expected the deployment to fail")
+	}
+
+	printed, _ := os.ReadFile(stderr.Name())
+	for _, want := range []string{"rendering chart", "nil pointer"} {
+		if !strings.Contains(string(printed), want) {
+			t.Errorf("captured output %q is missing %q", printed, want)
+		}
+	}
+}
+
+func TestValidateGcsAuth(t *testing.T) {
+	cases := []struct {
+		name    string
+		gcs     GCS
+		wantErr bool
+	}{
+		{"keys", GCS{AccessKey: "key", SecretKey: "secret"}, false},
+		{"workload identity", GCS{ServiceAccount: "parseable", GCPServiceAccount: "pb@project.iam.gserviceaccount.com"}, false},
+		{"none", GCS{}, true},
+		{"both", GCS{AccessKey: "key", SecretKey: "secret", ServiceAccount: "parseable", GCPServiceAccount: "pb@project.iam.gserviceaccount.com"}, true},
+		{"missing secret key", GCS{AccessKey: "key"}, true},
+		{"not a service account email", GCS{ServiceAccount: "parseable", GCPServiceAccount: "pb@example.com"}, true},
+	}
+	for _, tc := range cases {
+		if err := validateGcsAuth(tc.gcs); (err != nil) != tc.wantErr {
+			t.Errorf("%s: validateGcsAuth() error = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestGcsSecretOmitsKeysWithWorkloadIdentity(t *testing.T) {
+	ps := &ParseableInfo{Name: "parseable", Namespace: "parseable", Username: "admin", Password: "admin"}
+	store := ObjectStoreConfig{GCSStore: GCS{Bucket: "logs", ServiceAccount: "parseable", GCPServiceAccount: "pb@project.iam.gserviceaccount.com"}}
+
+	manifest := getParseableSecretGcs(ps, store)
+	if strings.Contains(manifest, "gcs.access.key") || strings.Contains(manifest, "gcs.secret.key") {
+		t.Errorf("secret contains key fields with workload identity:\n%s", manifest)
+	}
+
+	store.GCSStore = GCS{Bucket: "logs", AccessKey: "key", SecretKey: "secret"}
+	manifest = getParseableSecretGcs(ps, store)
+	if !strings.Contains(manifest, "gcs.access.key") || !strings.Contains(manifest, "gcs.secret.key") {
+		t.Errorf("secret is missing key fields:\n%s", manifest)
+	}
+}
+
+func TestValidateS3Endpoint(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"https://s3.us-east-1.amazonaws.com", false},
+		{"http://minio.minio.svc:9000", false},
+		{"http://minio.minio.svc:9000/", false},
+		{"minio.minio.svc:9000", true},
+		{"ftp://minio:9000", true},
+		{"http://", true},
+		{"http://minio:9000/logs", true},
+		{"", true},
+	}
+	for _, tc := range cases {
+		if err := validateS3Endpoint(tc.endpoint); (err != nil) != tc.wantErr {
+			t.Errorf("validateS3Endpoint(%q) error = %v, wantErr %v", tc.endpoint, err, tc.wantErr)
+		}
+	}
+}
+
+func TestS3SecretIncludesPathStyle(t *testing.T) {
+	ps := &ParseableInfo{Name: "parseable", Namespace: "parseable", Username: "admin", Password: "admin"}
+	store := ObjectStoreConfig{S3Store: S3{URL: "http://minio:9000", Bucket: "logs", PathStyle: true}}
+
+	manifest := getParseableSecretS3(ps, store)
+	if !strings.Contains(manifest, "s3.path.style: dHJ1ZQ==") {
+		t.Errorf("secret does not enable path-style addressing:\n%s", manifest)
+	}
+}
+
+func TestChartRepoNameKeepsPublicEntry(t *testing.T) {
+	if name := chartRepoName(DefaultRepoURL); name != defaultRepoName {
+		t.Errorf("public repository got entry %s, want %s", name, defaultRepoName)
+	}
+	if name := chartRepoName("https://charts.example.com"); name == defaultRepoName {
+		t.Errorf("private repository overwrites the %s entry", defaultRepoName)
+	}
+}
+
+// serveChart serves a chart repository holding a parseable chart with a
+// single ConfigMap template and returns its URL
+func serveChart(t *testing.T) string {
+	dir := t.TempDir()
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: defaultChartName, Version: DefaultChartVersion},
+		Templates: []*chart.File{{
+			Name: "templates/configmap.yaml",
+			Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n"),
+		}},
+	}
+	archive, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(c.Metadata, filepath.Base(archive), server.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(filepath.Join(dir, "index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return server.URL
+}
+
+func TestDryRunPrintsOnlyManifests(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "repositories.yaml"))
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	var manifests bytes.Buffer
+	oldManifestOutput := manifestOutput
+	manifestOutput = &manifests
+	t.Cleanup(func() { manifestOutput = oldManifestOutput })
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	err = Installer(InstallOptions{RepoURL: serveChart(t), DryRun: true, Playground: true})
+	restored := os.Stdout == w
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored {
+		t.Errorf("stdout was not restored after the dry run")
+	}
+	if len(printed) != 0 {
+		t.Errorf("dry run printed to stdout: %s", printed)
+	}
+	for _, kind := range []string{"kind: Secret", "kind: ConfigMap"} {
+		if !strings.Contains(manifests.String(), kind) {
+			t.Errorf("manifests are missing %q: %s", kind, manifests.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "repositories.yaml")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the repositories file: %v", err)
+	}
+}
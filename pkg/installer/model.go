@@ -27,6 +27,15 @@ const (
 	_ loggingAgent = "I have my agent running / I'll set up later"
 )
 
+// InstallOptions holds the options supplied to the installer from the command line.
+type InstallOptions struct {
+	Verbose      bool   // Enable verbose logging.
+	RepoURL      string // URL of the Helm chart repository, defaults to the Parseable repository.
+	ChartVersion string // Version of the Parseable chart to install.
+	DryRun       bool   // Print the rendered manifests instead of applying them.
+	Playground   bool   // Install the playground plan without prompting.
+}
+
 // ParseableInfo represents the info used to authenticate, metadata with Parseable.
 type ParseableInfo struct {
 	Name      string // Name for parseable
@@ -65,24 +74,27 @@ type S3 struct {
 	SecretKey string // Secret key for authentication.
 	Bucket    string // Bucket name in the S3 store.
 	Region    string // Region of the S3 store.
+	PathStyle bool   // Address buckets as URL paths, as MinIO and most S3-compatible stores expect.
 }
 
 // GCS contains configuration details for a Google Cloud Storage backend.
 type GCS struct {
-	URL       string // URL of the GCS-compatible object store.
-	AccessKey string // Access key for authentication.
-	SecretKey string // Secret key for authentication.
-	Bucket    string // Bucket name in the GCS store.
-	Region    string // Region of the GCS store.
+	URL               string // URL of the GCS-compatible object store.
+	AccessKey         string // Access key for authentication, exclusive with workload identity.
+	SecretKey         string // Secret key for authentication, exclusive with workload identity.
+	Bucket            string // Bucket name in the GCS store.
+	Region            string // Region of the GCS store.
+	ServiceAccount    string // Kubernetes service account Parseable runs as with workload identity.
+	GCPServiceAccount string // Google service account the Kubernetes service account impersonates.
 }
 
 // Blob contains configuration details for an Azure Blob Storage backend.
 type Blob struct {
-	AccessKey          string // Access key for authentication.
+	AccessKey          string // Access key for authentication, exclusive with the service principal fields.
 	StorageAccountName string // Account name for Azure Blob Storage.
 	Container          string // Container name in the Azure Blob store.
-	ClientID           string // Client ID to authenticate.
-	ClientSecret       string // Client Secret to authenticate.
-	TenantID           string // TenantID
+	ClientID           string // Client ID of the service principal.
+	ClientSecret       string // Client secret of the service principal.
+	TenantID           string // Azure AD tenant ID of the service principal.
 	URL                string // URL of the Azure Blob store.
 }
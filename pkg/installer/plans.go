@@ -99,6 +99,7 @@ func promptUserPlanSelection() (Plan, error) {
 	label := fmt.Sprintf(common.Yellow + "Select deployment type:")
 
 	prompt := promptui.Select{
+		Stdout:    common.PromptOutput,
 		Label:     label,
 		Items:     planList,
 		Templates: templates,
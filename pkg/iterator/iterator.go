@@ -20,8 +20,10 @@ import (
 	"time"
 )
 
+// MinuteCheckPoint marks a window with data. Despite its name the window
+// spans the iterator's window size, which is one minute by default.
 type MinuteCheckPoint struct {
-	// minute start time.
+	// window start time.
 	time time.Time
 }
 
@@ -29,6 +31,7 @@ type QueryIterator[OK any, ERR any] struct {
 	rangeStartTime time.Time
 	rangeEndTime   time.Time
 	ascending      bool
+	window         time.Duration
 	index          int
 	windows        []MinuteCheckPoint
 	ready          bool
@@ -38,10 +41,21 @@ type QueryIterator[OK any, ERR any] struct {
 }
 
 func NewQueryIterator[OK any, ERR any](startTime time.Time, endTime time.Time, ascending bool, queryRunner func(time.Time, time.Time) (OK, ERR), hasData func(time.Time, time.Time) bool) QueryIterator[OK, ERR] {
+	return NewQueryIteratorWithWindow(startTime, endTime, ascending, time.Minute, queryRunner, hasData)
+}
+
+// NewQueryIteratorWithWindow creates an iterator that pages through the time
+// range in windows of the given size, e.g. time.Hour for sparse streams. The
+// range boundaries are expected to be aligned to the window size.
+func NewQueryIteratorWithWindow[OK any, ERR any](startTime time.Time, endTime time.Time, ascending bool, window time.Duration, queryRunner func(time.Time, time.Time) (OK, ERR), hasData func(time.Time, time.Time) bool) QueryIterator[OK, ERR] {
+	if window <= 0 {
+		window = time.Minute
+	}
 	iter := QueryIterator[OK, ERR]{
 		rangeStartTime: startTime,
 		rangeEndTime:   endTime,
 		ascending:      ascending,
+		window:         window,
 		index:          -1,
 		windows:        []MinuteCheckPoint{},
 		ready:          true,
@@ -77,21 +91,21 @@ func (iter *QueryIterator[OK, ERR]) populateNextNonEmpty() {
 		if iter.ascending {
 			inspectMinute = MinuteCheckPoint{time: iter.rangeStartTime}
 		} else {
-			inspectMinute = MinuteCheckPoint{iter.rangeEndTime.Add(-time.Minute)}
+			inspectMinute = MinuteCheckPoint{iter.rangeEndTime.Add(-iter.window)}
 		}
 	} else {
-		inspectMinute = MinuteCheckPoint{time: nextMinute(iter.windows[len(iter.windows)-1].time, iter.ascending)}
+		inspectMinute = MinuteCheckPoint{time: iter.nextWindow(iter.windows[len(iter.windows)-1].time)}
 	}
 
 	iter.ready = false
 	for iter.inRange(inspectMinute.time) {
-		if iter.hasData(inspectMinute.time, inspectMinute.time.Add(time.Minute)) {
+		if iter.hasData(inspectMinute.time, inspectMinute.time.Add(iter.window)) {
 			iter.windows = append(iter.windows, inspectMinute)
 			iter.ready = true
 			return
 		}
 		inspectMinute = MinuteCheckPoint{
-			time: nextMinute(inspectMinute.time, iter.ascending),
+			time: iter.nextWindow(inspectMinute.time),
 		}
 	}
 
@@ -108,7 +122,7 @@ func (iter *QueryIterator[OK, ERR]) Next() (OK, ERR) {
 		iter.ready = false
 		go iter.populateNextNonEmpty()
 	}
-	return iter.queryRunner(currentMinute.time, currentMinute.time.Add(time.Minute))
+	return iter.queryRunner(currentMinute.time, currentMinute.time.Add(iter.window))
 }
 
 func (iter *QueryIterator[OK, ERR]) Prev() (OK, ERR) {
@@ -116,12 +130,12 @@ func (iter *QueryIterator[OK, ERR]) Prev() (OK, ERR) {
 		iter.index--
 	}
 	currentMinute := iter.windows[iter.index]
-	return iter.queryRunner(currentMinute.time, currentMinute.time.Add(time.Minute))
+	return iter.queryRunner(currentMinute.time, currentMinute.time.Add(iter.window))
 }
 
-func nextMinute(current time.Time, ascending bool) time.Time {
-	if ascending {
-		return current.Add(time.Minute)
+func (iter *QueryIterator[OK, ERR]) nextWindow(current time.Time) time.Time {
+	if iter.ascending {
+		return current.Add(iter.window)
 	}
-	return current.Add(-time.Minute)
+	return current.Add(-iter.window)
 }
@@ -206,3 +206,63 @@ func checkCurrentWindowIndex(expectedValue string, currentWindow MinuteCheckPoin
 		t.Fatalf("window time does not match start, expected %s, actual %s", expectedTime.String(), currentWindow.time.String())
 	}
 }
+
+func HourlyTestScenario() DummyQueryProvider {
+	return DummyQueryProvider{
+		state: map[string]int{
+			"02 Jan 06 10:00 +0000": 5,
+			"02 Jan 06 11:00 +0000": 0,
+			"02 Jan 06 12:00 +0000": 0,
+			"02 Jan 06 13:00 +0000": 2,
+			"02 Jan 06 14:00 +0000": 0,
+		},
+	}
+}
+
+func TestIteratorHourWindowAscending(t *testing.T) {
+	scenario := HourlyTestScenario()
+	endTime := scenario.StartTime().Add(5 * time.Hour)
+	iter := NewQueryIteratorWithWindow(scenario.StartTime(), endTime, true, time.Hour, scenario.QueryRunnerFunc(), scenario.HasDataFunc())
+
+	iter.Next()
+	for !iter.Ready() {
+		continue
+	}
+	checkCurrentWindowIndex("02 Jan 06 10:00 +0000", iter.windows[iter.index], t)
+	if iter.CanFetchPrev() {
+		t.Fatalf("first window should not allow fetching the previous one")
+	}
+
+	iter.Next()
+	for !iter.Ready() {
+		continue
+	}
+	checkCurrentWindowIndex("02 Jan 06 13:00 +0000", iter.windows[iter.index], t)
+	if !iter.CanFetchPrev() {
+		t.Fatalf("second window should allow fetching the previous one")
+	}
+	if !iter.Finished() {
+		t.Fatalf("iter should be finished now but it is not")
+	}
+}
+
+func TestIteratorHourWindowDescending(t *testing.T) {
+	scenario := HourlyTestScenario()
+	endTime := scenario.StartTime().Add(5 * time.Hour)
+	iter := NewQueryIteratorWithWindow(scenario.StartTime(), endTime, false, time.Hour, scenario.QueryRunnerFunc(), scenario.HasDataFunc())
+
+	iter.Next()
+	for !iter.Ready() {
+		continue
+	}
+	checkCurrentWindowIndex("02 Jan 06 13:00 +0000", iter.windows[iter.index], t)
+
+	iter.Next()
+	for !iter.Ready() {
+		continue
+	}
+	checkCurrentWindowIndex("02 Jan 06 10:00 +0000", iter.windows[iter.index], t)
+	if !iter.Finished() {
+		t.Fatalf("iter should be finished now but it is not")
+	}
+}
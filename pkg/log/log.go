@@ -0,0 +1,161 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package log is the leveled logger of pb. Log records go to stderr so they
+// never mix with command output on stdout.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Level is the minimum severity of the records that are logged
+type Level int
+
+// Supported levels, from most to least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// ParseLevel returns the level with the given name
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelWarn, fmt.Errorf("unknown log level %q, expected one of debug, info, warn, error", name)
+}
+
+// String implements pflag.Value
+func (l *Level) String() string {
+	return levelNames[*l]
+}
+
+// Set implements pflag.Value
+func (l *Level) Set(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Type implements pflag.Value
+func (l *Level) Type() string {
+	return "level"
+}
+
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+var (
+	level  = new(slog.LevelVar)
+	logger = newLogger(os.Stderr, false)
+)
+
+func init() {
+	level.Set(slog.LevelWarn)
+}
+
+func newLogger(w io.Writer, json bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if json {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Configure sets the minimum level and the output format of the logger
+func Configure(l Level, json bool) {
+	level.Set(l.slogLevel())
+	logger = newLogger(os.Stderr, json)
+}
+
+// SetOutput redirects the log records, keeping the current level
+func SetOutput(w io.Writer, json bool) {
+	logger = newLogger(w, json)
+}
+
+// SetLevel changes the minimum level of the logged records
+func SetLevel(l Level) {
+	level.Set(l.slogLevel())
+}
+
+// Enabled reports whether records of the given level are logged
+func Enabled(l Level) bool {
+	return logger.Enabled(context.Background(), l.slogLevel())
+}
+
+// Debug logs msg with the key value pairs in args at debug level
+func Debug(msg string, args ...any) {
+	logger.Debug(msg, args...)
+}
+
+// Info logs msg with the key value pairs in args at info level
+func Info(msg string, args ...any) {
+	logger.Info(msg, args...)
+}
+
+// Warn logs msg with the key value pairs in args at warn level
+func Warn(msg string, args ...any) {
+	logger.Warn(msg, args...)
+}
+
+// Error logs msg with the key value pairs in args at error level
+func Error(msg string, args ...any) {
+	logger.Error(msg, args...)
+}
+
+// Debugf logs a printf style message at debug level. Its signature matches
+// the loggers expected by libraries such as Helm.
+func Debugf(format string, args ...any) {
+	if Enabled(LevelDebug) {
+		logger.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// Infof logs a printf style message at info level
+func Infof(format string, args ...any) {
+	if Enabled(LevelInfo) {
+		logger.Info(fmt.Sprintf(format, args...))
+	}
+}
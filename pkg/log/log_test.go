@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf, false)
+	SetLevel(LevelWarn)
+	defer Configure(LevelWarn, false)
+
+	Debug("hidden debug")
+	Info("hidden info")
+	Warn("shown warning", "stream", "backend")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("records below the level were logged: %q", out)
+	}
+	if !strings.Contains(out, "shown warning") || !strings.Contains(out, "stream=backend") {
+		t.Errorf("warning was not logged: %q", out)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf, true)
+	SetLevel(LevelDebug)
+	defer Configure(LevelWarn, false)
+
+	Debugf("request took %dms", 12)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log record is not JSON: %q", buf.String())
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "request took 12ms" {
+		t.Errorf("unexpected record %v", record)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	var l Level
+	if err := l.Set("INFO"); err != nil || l != LevelInfo {
+		t.Errorf("Set(INFO) = %v, level %v", err, l)
+	}
+	if err := l.Set("verbose"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	table "github.com/evertras/bubble-table/table"
+)
+
+var exportKeyBind = key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl s", "save rows to csv"))
+
+// exportRows writes the rows as CSV to a new file in the working directory
+// and returns its name. Columns follow the schema order; values that are not
+// strings, such as the maps in p_metadata and p_tags, are written as JSON.
+func exportRows(schema []string, rows []table.Row, now time.Time) (string, error) {
+	name := fmt.Sprintf("pb-export-%s.csv", now.Format("20060102-150405"))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(schema); err != nil {
+		return "", err
+	}
+
+	record := make([]string, len(schema))
+	for _, row := range rows {
+		for idx, column := range schema {
+			record[idx] = exportValue(row.Data[column])
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return name, file.Close()
+}
+
+func exportValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(encoded)
+	}
+}
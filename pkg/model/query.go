@@ -19,12 +19,16 @@ package model
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"os"
 	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
 	"pb/pkg/iterator"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -96,8 +100,9 @@ var (
 	}
 
 	paginatorKeyBinds = []key.Binding{
-		key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl r", "Fetch Next Minute")),
-		key.NewBinding(key.WithKeys("ctrl+b"), key.WithHelp("ctrl b", "Fetch Prev Minute")),
+		key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl r", "Fetch Next Window")),
+		key.NewBinding(key.WithKeys("ctrl+b"), key.WithHelp("ctrl b", "Fetch Prev Window")),
+		key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl o", "Toggle newest/oldest first")),
 	}
 
 	QueryNavigationMap = []string{"query", "time", "table"}
@@ -112,6 +117,8 @@ type FetchData struct {
 	status FetchResult
 	schema []string
 	data   []map[string]interface{}
+	// err describes why the fetch failed, including the server's message
+	err error
 }
 
 const (
@@ -133,9 +140,15 @@ type QueryModel struct {
 	profile       config.Profile
 	help          help.Model
 	status        StatusBar
-	queryIterator *iterator.QueryIterator[QueryData, FetchResult]
+	queryIterator *iterator.QueryIterator[QueryData, error]
 	overlay       uint
 	focused       int
+	// schema of the rows in the table, in the order returned by the server
+	schema []string
+	// ascending pages through the time range oldest first instead of newest first
+	ascending bool
+	// window is the span of time fetched per page
+	window time.Duration
 }
 
 func (m *QueryModel) focusSelected() {
@@ -159,41 +172,80 @@ func (m *QueryModel) initIterator() {
 	m.queryIterator = iter
 }
 
-func createIteratorFromModel(m *QueryModel) *iterator.QueryIterator[QueryData, FetchResult] {
+func createIteratorFromModel(m *QueryModel) *iterator.QueryIterator[QueryData, error] {
 	startTime := m.timeRange.start.Time()
 	endTime := m.timeRange.end.Time()
 
-	startTime = startTime.Truncate(time.Minute)
-	endTime = endTime.Truncate(time.Minute).Add(time.Minute)
+	startTime = startTime.Truncate(m.window)
+	endTime = endTime.Truncate(m.window).Add(m.window)
 
 	table := streamNameFromQuery(m.query.Value())
 	if table != "" {
-		iter := iterator.NewQueryIterator(
+		// one client serves every window so its connections are reused
+		client := internalHTTP.DefaultClient(&m.profile)
+		iter := iterator.NewQueryIteratorWithWindow(
 			startTime, endTime,
-			false,
-			func(t1, t2 time.Time) (QueryData, FetchResult) {
-				client := &http.Client{
-					Timeout: time.Second * 50,
-				}
-				return fetchData(client, &m.profile, m.query.Value(), t1.UTC().Format(time.RFC3339), t2.UTC().Format(time.RFC3339))
+			m.ascending,
+			m.window,
+			func(t1, t2 time.Time) (QueryData, error) {
+				return fetchData(&client.Client, &m.profile, m.query.Value(), t1.UTC().Format(time.RFC3339), t2.UTC().Format(time.RFC3339))
 			},
 			func(_, _ time.Time) bool {
-				client := &http.Client{
-					Timeout: time.Second * 50,
-				}
-				res, err := fetchData(client, &m.profile, "select count(*) as count from "+table, m.timeRange.StartValueUtc(), m.timeRange.EndValueUtc())
-				if err == fetchErr {
+				res, err := fetchData(&client.Client, &m.profile, "select count(*) as count from "+table, m.timeRange.StartValueUtc(), m.timeRange.EndValueUtc())
+				if err != nil {
 					return false
 				}
-				count := res.Records[0]["count"].(float64)
-				return count > 0
+				return hasCount(res)
 			})
 		return &iter
 	}
 	return nil
 }
 
-func NewQueryModel(profile config.Profile, queryStr string, startTime, endTime time.Time) QueryModel {
+// hasCount reports whether the result of a count query is a positive count.
+// Missing records, a missing count and values that are not numbers, such as
+// null, count as no data.
+func hasCount(res QueryData) bool {
+	if len(res.Records) == 0 {
+		return false
+	}
+	switch count := res.Records[0]["count"].(type) {
+	case float64:
+		return count > 0
+	case int:
+		return count > 0
+	case int64:
+		return count > 0
+	case json.Number:
+		value, err := count.Float64()
+		return err == nil && value > 0
+	case string:
+		value, err := strconv.ParseFloat(count, 64)
+		return err == nil && value > 0
+	default:
+		return false
+	}
+}
+
+// ParseWindow returns the page window for a granularity name: minute or hour
+func ParseWindow(granularity string) (time.Duration, error) {
+	switch strings.ToLower(granularity) {
+	case "", "minute":
+		return time.Minute, nil
+	case "hour":
+		return time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown window %q, expected minute or hour", granularity)
+	}
+}
+
+// NewQueryModel creates the interactive query view. Results are paged through
+// in windows of the given size; a zero window means one minute.
+func NewQueryModel(profile config.Profile, queryStr string, startTime, endTime time.Time, window time.Duration) QueryModel {
+	if window <= 0 {
+		window = time.Minute
+	}
+
 	w, h, _ := term.GetSize(int(os.Stdout.Fd()))
 
 	inputs := NewTimeInputModel(startTime, endTime)
@@ -243,6 +295,7 @@ func NewQueryModel(profile config.Profile, queryStr string, startTime, endTime t
 		help:          help,
 		queryIterator: nil,
 		status:        NewStatusBar(profile.URL, w),
+		window:        window,
 	}
 	model.queryIterator = createIteratorFromModel(&model)
 	return model
@@ -285,9 +338,10 @@ func (m QueryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case FetchData:
 		if msg.status == fetchOk {
+			m.status.Error = ""
 			m.UpdateTable(msg)
 		} else {
-			m.status.Error = "failed to query"
+			m.status.Error = "failed to query: " + msg.err.Error()
 		}
 		return m, nil
 
@@ -331,6 +385,22 @@ func (m QueryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// reverse the paging direction over the same time range
+		if msg.Type == tea.KeyCtrlO {
+			m.overlay = overlayNone
+			m.ascending = !m.ascending
+			m.initIterator()
+			if m.queryIterator != nil && m.queryIterator.Ready() && !m.queryIterator.Finished() {
+				return m, IteratorNext(m.queryIterator)
+			}
+			return m, nil
+		}
+
+		if msg.Type == tea.KeyCtrlS {
+			m.exportTable()
+			return m, nil
+		}
+
 		if msg.Type == tea.KeyCtrlB {
 			m.overlay = overlayNone
 			if m.queryIterator.CanFetchPrev() {
@@ -417,6 +487,12 @@ func (m QueryModel) View() string {
 			line.WriteString(inactiveStyle.Render(">>"))
 		}
 
+		if m.ascending {
+			line.WriteString(inactiveStyle.Render("  oldest first"))
+		} else {
+			line.WriteString(inactiveStyle.Render("  newest first"))
+		}
+
 		mainViewRenderElements = append(mainViewRenderElements, line.String())
 	}
 
@@ -443,6 +519,7 @@ func (m QueryModel) View() string {
 	} else {
 		helpKeys = append(helpKeys, additionalKeyBinds)
 	}
+	helpKeys = append(helpKeys, []key.Binding{exportKeyBind})
 
 	helpView = m.help.FullHelpView(helpKeys)
 
@@ -470,13 +547,13 @@ func NewFetchTask(profile config.Profile, query string, startTime string, endTim
 			data:   []map[string]interface{}{},
 		}
 
-		client := &http.Client{
-			Timeout: time.Second * 50,
-		}
+		client := internalHTTP.DefaultClient(&profile)
 
-		data, status := fetchData(client, &profile, query, startTime, endTime)
+		data, err := fetchData(&client.Client, &profile, query, startTime, endTime)
 
-		if status == fetchOk {
+		if err != nil {
+			res.err = err
+		} else {
 			res.data = data.Records
 			res.schema = data.Fields
 			res.status = fetchOk
@@ -486,7 +563,7 @@ func NewFetchTask(profile config.Profile, query string, startTime string, endTim
 	}
 }
 
-func IteratorNext(iter *iterator.QueryIterator[QueryData, FetchResult]) func() tea.Msg {
+func IteratorNext(iter *iterator.QueryIterator[QueryData, error]) func() tea.Msg {
 	return func() tea.Msg {
 		res := FetchData{
 			status: fetchErr,
@@ -494,9 +571,11 @@ func IteratorNext(iter *iterator.QueryIterator[QueryData, FetchResult]) func() t
 			data:   []map[string]interface{}{},
 		}
 
-		data, status := iter.Next()
+		data, err := iter.Next()
 
-		if status == fetchOk {
+		if err != nil {
+			res.err = err
+		} else {
 			res.data = data.Records
 			res.schema = data.Fields
 			res.status = fetchOk
@@ -506,7 +585,7 @@ func IteratorNext(iter *iterator.QueryIterator[QueryData, FetchResult]) func() t
 	}
 }
 
-func IteratorPrev(iter *iterator.QueryIterator[QueryData, FetchResult]) func() tea.Msg {
+func IteratorPrev(iter *iterator.QueryIterator[QueryData, error]) func() tea.Msg {
 	return func() tea.Msg {
 		res := FetchData{
 			status: fetchErr,
@@ -514,9 +593,11 @@ func IteratorPrev(iter *iterator.QueryIterator[QueryData, FetchResult]) func() t
 			data:   []map[string]interface{}{},
 		}
 
-		data, status := iter.Prev()
+		data, err := iter.Prev()
 
-		if status == fetchOk {
+		if err != nil {
+			res.err = err
+		} else {
 			res.data = data.Records
 			res.schema = data.Fields
 			res.status = fetchOk
@@ -526,39 +607,42 @@ func IteratorPrev(iter *iterator.QueryIterator[QueryData, FetchResult]) func() t
 	}
 }
 
-func fetchData(client *http.Client, profile *config.Profile, query string, startTime string, endTime string) (data QueryData, res FetchResult) {
-	data = QueryData{}
-	res = fetchErr
-
-	queryTemplate := `{
-    "query": "%s",
-    "startTime": "%s",
-    "endTime": "%s"
+func fetchData(client *http.Client, profile *config.Profile, query string, startTime string, endTime string) (data QueryData, err error) {
+	finalQuery, err := json.Marshal(map[string]string{
+		"query":     query,
+		"startTime": startTime,
+		"endTime":   endTime,
+	})
+	if err != nil {
+		return data, err
 	}
-	`
-
-	finalQuery := fmt.Sprintf(queryTemplate, query, startTime, endTime)
 
 	endpoint := fmt.Sprintf("%s/%s", profile.URL, "api/v1/query?fields=true")
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer([]byte(finalQuery)))
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(finalQuery))
 	if err != nil {
-		return
+		return data, err
 	}
 	req.SetBasicAuth(profile.Username, profile.Password)
 	req.Header.Add("Content-Type", "application/json")
 	resp, err := client.Do(req)
 	if err != nil {
-		return
+		return data, err
 	}
-
-	err = json.NewDecoder(resp.Body).Decode(&data)
 	defer resp.Body.Close()
-	if err != nil {
-		return
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		message := strings.TrimSpace(string(body))
+		if message == "" {
+			return data, errors.New(resp.Status)
+		}
+		return data, fmt.Errorf("%s: %s", resp.Status, message)
 	}
 
-	res = fetchOk
-	return
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return data, fmt.Errorf("invalid response: %w", err)
+	}
+	return data, nil
 }
 
 func (m *QueryModel) UpdateTable(data FetchData) {
@@ -601,6 +685,24 @@ func (m *QueryModel) UpdateTable(data FetchData) {
 
 	m.table = m.table.WithColumns(columns)
 	m.table = m.table.WithRows(rows)
+	m.schema = data.schema
+}
+
+// exportTable saves the rows loaded in the table and reports the file in the status bar
+func (m *QueryModel) exportTable() {
+	rows := m.table.GetVisibleRows()
+	if len(m.schema) == 0 || len(rows) == 0 {
+		m.status.Error = "no rows to export"
+		return
+	}
+
+	name, err := exportRows(m.schema, rows, time.Now())
+	if err != nil {
+		m.status.Error = "export failed: " + err.Error()
+		return
+	}
+	m.status.Error = ""
+	m.status.Info = fmt.Sprintf("saved %d rows to %s", len(rows), name)
 }
 
 func inferWidthForColumns(column string, data *[]map[string]interface{}, maxRecords int, maxWidth int) (width int) {
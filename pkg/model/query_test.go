@@ -0,0 +1,84 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"pb/pkg/config"
+)
+
+func TestHasCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []map[string]interface{}
+		want    bool
+	}{
+		{"no records", nil, false},
+		{"empty record", []map[string]interface{}{{}}, false},
+		{"missing count", []map[string]interface{}{{"total": float64(3)}}, false},
+		{"null count", []map[string]interface{}{{"count": nil}}, false},
+		{"zero count", []map[string]interface{}{{"count": float64(0)}}, false},
+		{"float count", []map[string]interface{}{{"count": float64(3)}}, true},
+		{"int count", []map[string]interface{}{{"count": 3}}, true},
+		{"int64 count", []map[string]interface{}{{"count": int64(3)}}, true},
+		{"number count", []map[string]interface{}{{"count": json.Number("3")}}, true},
+		{"string count", []map[string]interface{}{{"count": "3"}}, true},
+		{"non numeric string", []map[string]interface{}{{"count": "many"}}, false},
+		{"object count", []map[string]interface{}{{"count": map[string]interface{}{"value": 3}}}, false},
+	}
+	for _, test := range tests {
+		if got := hasCount(QueryData{Records: test.records}); got != test.want {
+			t.Errorf("%s: hasCount = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		granularity string
+		want        time.Duration
+	}{
+		{"", time.Minute},
+		{"minute", time.Minute},
+		{"Hour", time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseWindow(tt.granularity)
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %s (%v), want %s", tt.granularity, got, err, tt.want)
+		}
+	}
+	if _, err := ParseWindow("day"); err == nil {
+		t.Error("expected an unknown window to be rejected")
+	}
+}
+
+func TestNewQueryModelWindow(t *testing.T) {
+	end := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	start := end.Add(-3 * time.Hour)
+
+	m := NewQueryModel(config.Profile{}, "select * from backend", start, end, time.Hour)
+	if m.window != time.Hour {
+		t.Errorf("expected an hour window, got %s", m.window)
+	}
+	m = NewQueryModel(config.Profile{}, "select * from backend", start, end, 0)
+	if m.window != time.Minute {
+		t.Errorf("expected the window to default to a minute, got %s", m.window)
+	}
+}
@@ -16,7 +16,6 @@
 package model
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,17 +24,18 @@ import (
 	"time"
 
 	"pb/pkg/config"
+	internalHTTP "pb/pkg/http"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 const (
 	applyQueryButton = "a"
-	backButton       = "b"
+	copyQueryButton  = "c"
 	confirmDelete    = "y"
 	cancelDelete     = "n"
 )
@@ -133,26 +133,15 @@ func (d itemDelegate) ShortHelp() []key.Binding {
 			key.WithHelp(applyQueryButton, "apply"),
 		),
 		key.NewBinding(
-			key.WithKeys(backButton),
-			key.WithHelp(backButton, "back"),
+			key.WithKeys(copyQueryButton),
+			key.WithHelp(copyQueryButton, "copy sql"),
 		),
 	}
 }
 
-// Implement FullHelp to show only "apply" and "back" key bindings.
+// Implement FullHelp to show only "apply" and "copy" key bindings.
 func (d itemDelegate) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{
-			key.NewBinding(
-				key.WithKeys(applyQueryButton),
-				key.WithHelp(applyQueryButton, "apply"),
-			),
-			key.NewBinding(
-				key.WithKeys(backButton),
-				key.WithHelp(backButton, "back"),
-			),
-		},
-	}
+	return [][]key.Binding{d.ShortHelp()}
 }
 
 var (
@@ -169,106 +158,64 @@ func (i Item) Description() string {
 	return fmt.Sprintf("From:%s To:%s", i.from, i.to)
 }
 
-func (i Item) FilterValue() string  { return i.title }
+// FilterValue matches the search in the list against the title and the stream
+func (i Item) FilterValue() string  { return i.title + " " + i.stream }
 func (i Item) SavedQueryID() string { return i.id }
 func (i Item) Stream() string       { return i.desc }
 func (i Item) StartTime() string    { return i.from }
 func (i Item) EndTime() string      { return i.to }
 
+// SQL returns the saved query. desc holds it JSON encoded.
+func (i Item) SQL() string {
+	var query string
+	if err := json.Unmarshal([]byte(i.desc), &query); err != nil {
+		return i.desc
+	}
+	return query
+}
+
 type modelSavedQueries struct {
-	list          list.Model
-	commandOutput string
-	viewport      viewport.Model
-	queryExecuted bool // New field to track query execution
+	list list.Model
 }
 
 func (m modelSavedQueries) Init() tea.Cmd {
 	return nil
 }
 
-// Define a message type for command results
-type commandResultMsg string
-
 func (m modelSavedQueries) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// while typing a search every key goes to the list
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
 
-		case "a", "enter":
-			// Only execute if a query hasn't already been run
-			if m.queryExecuted {
-				return m, nil // Skip execution if already executed
+		case copyQueryButton:
+			selected, ok := m.list.SelectedItem().(Item)
+			if !ok {
+				return m, nil
 			}
-			selectedQueryApply := m.list.SelectedItem().(Item)
-			m.queryExecuted = true // Mark as executed
-
-			cmd := func() tea.Msg {
-				// Load user profile configuration
-				userConfig, err := config.ReadConfigFromFile()
-				if err != nil {
-					return commandResultMsg(fmt.Sprintf("Error: %s", err))
-				}
-
-				profile, profileExists := userConfig.Profiles[userConfig.DefaultProfile]
-				if !profileExists {
-					return commandResultMsg("Error: Profile not found")
-				}
-
-				// Clean the query string
-				cleanedQuery := strings.TrimSpace(strings.ReplaceAll(selectedQueryApply.desc, `\`, ""))
-				cleanedQuery = strings.ReplaceAll(cleanedQuery, `"`, "")
-
-				// Log the command for debugging
-				fmt.Printf("Executing command: pb query run %s\n", cleanedQuery)
-
-				// Prepare HTTP client
-				client := &http.Client{Timeout: 60 * time.Second}
-
-				// Determine query time range
-				startTime := selectedQueryApply.StartTime()
-				endTime := selectedQueryApply.EndTime()
-
-				// If start and end times are not set, use a default range
-				if startTime == "" && endTime == "" {
-					startTime = "10m"
-					endTime = "now"
-				}
-
-				// Run the query
-				data, err := RunQuery(client, &profile, cleanedQuery, startTime, endTime)
-				if err != nil {
-					return commandResultMsg(fmt.Sprintf("Error: %s", err))
-				}
-				return commandResultMsg(data)
+			if err := clipboard.WriteAll(selected.SQL()); err != nil {
+				return m, m.list.NewStatusMessage(fmt.Sprintf("failed to copy query: %s", err))
 			}
-			return m, cmd
-
-		case "b": // 'b' to go back to the saved query list
-			m.commandOutput = ""      // Clear the command output
-			m.viewport.SetContent("") // Clear viewport content
-			m.viewport.GotoTop()      // Reset viewport to the top
-			m.queryExecuted = false   // Reset the execution flag to allow a new query
-			return m, nil
+			return m, m.list.NewStatusMessage(fmt.Sprintf("copied query %s", selected.title))
 
-		case "down", "j":
-			m.viewport.LineDown(1) // Scroll down in the viewport
-
-		case "up", "k":
-			m.viewport.LineUp(1) // Scroll up in the viewport
+		case applyQueryButton, "enter":
+			selected, ok := m.list.SelectedItem().(Item)
+			if !ok {
+				return m, nil
+			}
+			// the caller opens the query once the list is closed
+			selectedQueryApply = selected
+			return m, tea.Quit
 		}
 
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
 		m.list.SetSize(msg.Width-h, msg.Height-v)
-		m.viewport.Width = msg.Width - h
-		m.viewport.Height = msg.Height - v
-
-	case commandResultMsg:
-		m.commandOutput = string(msg)
-		m.viewport.SetContent(m.commandOutput) // Update viewport content with command output
-		return m, nil
 	}
 
 	// Update the list and return
@@ -277,9 +224,6 @@ func (m modelSavedQueries) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 func (m modelSavedQueries) View() string {
-	if m.commandOutput != "" {
-		return m.viewport.View()
-	}
 	return m.list.View()
 }
 
@@ -294,13 +238,16 @@ func SavedQueriesMenu() *tea.Program {
 		userProfile = profile
 	}
 
-	client := &http.Client{
-		Timeout: time.Second * 60,
-	}
-	userSavedQueries := fetchFilters(client, &userProfile)
+	client := internalHTTP.DefaultClient(&userProfile)
+	userSavedQueries := fetchFilters(&client.Client, &userProfile)
 
 	m := modelSavedQueries{list: list.New(userSavedQueries, itemDelegate{}, 0, 0)}
 	m.list.Title = fmt.Sprintf("Saved Queries for User: %s", userProfile.Username)
+	m.list.SetFilteringEnabled(true)
+	m.list.SetShowPagination(true)
+	m.list.Paginator.ActiveDot = "● "
+	m.list.Paginator.InactiveDot = "○ "
+	m.list.StatusMessageLifetime = 2 * time.Second
 
 	return tea.NewProgram(m, tea.WithAltScreen())
 }
@@ -367,51 +314,3 @@ func QueryToApply() Item {
 func QueryToDelete() Item {
 	return selectedQueryDelete
 }
-
-func RunQuery(client *http.Client, profile *config.Profile, query string, startTime string, endTime string) (string, error) {
-	queryTemplate := `{
-		"query": "%s",
-		"startTime": "%s",
-		"endTime": "%s"
-	}`
-
-	finalQuery := fmt.Sprintf(queryTemplate, query, startTime, endTime)
-
-	endpoint := fmt.Sprintf("%s/%s", profile.URL, "api/v1/query")
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer([]byte(finalQuery)))
-	if err != nil {
-		return "", err
-	}
-	req.SetBasicAuth(profile.Username, profile.Password)
-	req.Header.Add("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		var jsonResponse []map[string]interface{}
-
-		// Read and parse the JSON response
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-
-		// Decode JSON into a map
-		if err := json.Unmarshal(body, &jsonResponse); err != nil {
-			return "", err
-		}
-
-		// Pretty-print the JSON response
-		jsonData, err := json.MarshalIndent(jsonResponse, "", "  ")
-		if err != nil {
-			return "", err
-		}
-		return string(jsonData), nil
-	}
-
-	return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-}
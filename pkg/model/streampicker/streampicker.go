@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package streampicker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"pb/pkg/model/defaultprofile"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	focusTitleStyle   = lipgloss.NewStyle().Foreground(defaultprofile.FocusPrimary)
+	focusedOuterStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).BorderForeground(defaultprofile.FocusPrimary)
+
+	standardTitleStyle = lipgloss.NewStyle().Foreground(defaultprofile.StandardPrimary).PaddingLeft(1)
+)
+
+type item string
+
+func (i item) FilterValue() string { return string(i) }
+
+type itemDelegate struct{}
+
+func (d itemDelegate) Height() int                             { return 1 }
+func (d itemDelegate) Spacing() int                            { return 0 }
+func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	name, _ := listItem.(item)
+
+	if index == m.Index() {
+		fmt.Fprint(w, focusedOuterStyle.Render(focusTitleStyle.Render(string(name))))
+		return
+	}
+	fmt.Fprint(w, standardTitleStyle.Render(string(name)))
+}
+
+// Model for selecting a stream. Type to filter the streams, enter picks the
+// highlighted one.
+type Model struct {
+	list    list.Model
+	Choice  string
+	Success bool
+}
+
+func New(streams []string) Model {
+	names := append([]string(nil), streams...)
+	sort.Strings(names)
+
+	items := make([]list.Item, len(names))
+	for idx, name := range names {
+		items[idx] = item(name)
+	}
+
+	list := list.New(items, itemDelegate{}, 80, 19)
+	list.Title = "Select a stream"
+	list.SetShowStatusBar(false)
+
+	list.Styles.Title = list.Styles.Title.MarginLeft(1)
+	list.Styles.PaginationStyle = list.Styles.PaginationStyle.MarginLeft(1).Padding(0)
+	list.Styles.HelpStyle = list.Styles.HelpStyle.MarginLeft(1).Padding(0)
+
+	list.Paginator.ActiveDot = "● "
+	list.Paginator.InactiveDot = "○ "
+
+	list.KeyMap.ShowFullHelp.SetEnabled(false)
+	list.KeyMap.CloseFullHelp.SetEnabled(false)
+
+	list.SetFilteringEnabled(true)
+
+	return Model{list: list}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, min(msg.Height, 19))
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		default:
+			if msg.Type == tea.KeyEnter && m.list.FilterState() != list.Filtering {
+				if selected := m.list.SelectedItem(); selected != nil {
+					m.Success = true
+					m.Choice = selected.FilterValue()
+				}
+				return m, tea.Quit
+			}
+			m.list, cmd = m.list.Update(msg)
+		}
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	return m.list.View()
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package timerange resolves named calendar windows such as "today" into
+// concrete UTC time boundaries.
+package timerange
+
+import (
+	"strings"
+	"time"
+)
+
+// Layout is the RFC3339 layout the server expects for absolute times
+const Layout = "2006-01-02T15:04:05.000Z"
+
+// Names of the supported windows
+const (
+	Today     = "today"
+	Yesterday = "yesterday"
+	ThisWeek  = "this-week"
+	Last24h   = "last-24h"
+)
+
+// Window returns the [start, end) boundaries of the named window relative to
+// now. Calendar windows start at midnight UTC, weeks start on Monday. ok is
+// false if name is not a known window.
+func Window(name string, now time.Time) (start, end time.Time, ok bool) {
+	return WindowIn(name, now, time.UTC)
+}
+
+// WindowIn is Window with calendar windows starting at midnight in loc. The
+// boundaries are returned in UTC. Days that change to or from daylight saving
+// time are 23 or 25 hours long.
+func WindowIn(name string, now time.Time, loc *time.Location) (start, end time.Time, ok bool) {
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch strings.ToLower(name) {
+	case Today:
+		return midnight.UTC(), midnight.AddDate(0, 0, 1).UTC(), true
+	case Yesterday:
+		return midnight.AddDate(0, 0, -1).UTC(), midnight.UTC(), true
+	case ThisWeek:
+		// time.Sunday is 0, shift so that Monday is the first day
+		offset := (int(now.Weekday()) + 6) % 7
+		monday := midnight.AddDate(0, 0, -offset)
+		return monday.UTC(), monday.AddDate(0, 0, 7).UTC(), true
+	case Last24h:
+		return now.Add(-24 * time.Hour).UTC(), now.UTC(), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// Resolve converts a --from/--to value into what the server accepts. Named
+// windows become the start (or end, if isEnd is set) of the window formatted
+// with Layout; any other value is returned unchanged.
+func Resolve(value string, now time.Time, isEnd bool) string {
+	return ResolveIn(value, now, isEnd, time.UTC)
+}
+
+// localLayouts are the absolute times without a zone that ResolveIn reads as
+// wall clock times in its location
+var localLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// ResolveIn is Resolve with named windows computed in loc, and absolute times
+// without a zone, such as 2024-03-10T09:00:00, taken as wall clock times in
+// loc. Both are converted to UTC. Durations and times with a zone are
+// returned unchanged, as is every value other than a named window when loc
+// is UTC.
+func ResolveIn(value string, now time.Time, isEnd bool, loc *time.Location) string {
+	start, end, ok := WindowIn(value, now, loc)
+	if ok {
+		if isEnd {
+			return end.Format(Layout)
+		}
+		return start.Format(Layout)
+	}
+	if loc == time.UTC {
+		return value
+	}
+	for _, layout := range localLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return parsed.UTC().Format(Layout)
+		}
+	}
+	return value
+}
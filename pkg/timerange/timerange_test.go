@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Parseable, Inc
+//
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package timerange
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("invalid time %s: %s", value, err)
+	}
+	return parsed
+}
+
+func TestWindowBoundaries(t *testing.T) {
+	// New York switches to daylight saving time at 2024-03-10 02:00 local time
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		window     string
+		now        time.Time
+		start, end string
+	}{
+		{"today", Today, mustParse(t, "2024-06-15T13:45:00Z"), "2024-06-15T00:00:00Z", "2024-06-16T00:00:00Z"},
+		{"yesterday across month edge", Yesterday, mustParse(t, "2024-03-01T08:00:00Z"), "2024-02-29T00:00:00Z", "2024-03-01T00:00:00Z"},
+		{"today at end of year", Today, mustParse(t, "2024-12-31T23:59:59Z"), "2024-12-31T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"this-week on sunday", ThisWeek, mustParse(t, "2024-06-16T10:00:00Z"), "2024-06-10T00:00:00Z", "2024-06-17T00:00:00Z"},
+		{"this-week across month edge", ThisWeek, mustParse(t, "2024-10-02T10:00:00Z"), "2024-09-30T00:00:00Z", "2024-10-07T00:00:00Z"},
+		{"last-24h", Last24h, mustParse(t, "2024-06-15T13:45:00Z"), "2024-06-14T13:45:00Z", "2024-06-15T13:45:00Z"},
+		// local time is still the 9th, but the UTC day is the 10th
+		{"today across DST with local now", Today, time.Date(2024, 3, 9, 23, 30, 0, 0, newYork), "2024-03-10T00:00:00Z", "2024-03-11T00:00:00Z"},
+		{"last-24h across DST with local now", Last24h, time.Date(2024, 3, 10, 12, 0, 0, 0, newYork), "2024-03-09T16:00:00Z", "2024-03-10T16:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, ok := Window(test.window, test.now)
+			if !ok {
+				t.Fatalf("window %s not recognized", test.window)
+			}
+			if !start.Equal(mustParse(t, test.start)) {
+				t.Fatalf("start does not match, expected %s, actual %s", test.start, start.Format(time.RFC3339))
+			}
+			if !end.Equal(mustParse(t, test.end)) {
+				t.Fatalf("end does not match, expected %s, actual %s", test.end, end.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestResolvePassesThroughOtherValues(t *testing.T) {
+	now := mustParse(t, "2024-06-15T13:45:00Z")
+	for _, value := range []string{"10m", "now", "2024-01-01T01:40:00.000Z"} {
+		if resolved := Resolve(value, now, false); resolved != value {
+			t.Fatalf("expected %s to be unchanged, actual %s", value, resolved)
+		}
+	}
+
+	if resolved := Resolve(Yesterday, now, true); resolved != "2024-06-15T00:00:00.000Z" {
+		t.Fatalf("unexpected end of yesterday: %s", resolved)
+	}
+}
+
+func TestResolveInZones(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err)
+	}
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		now   string
+		isEnd bool
+		loc   *time.Location
+		want  string
+	}{
+		{"today in kolkata", Today, "2024-06-15T20:00:00Z", false, kolkata, "2024-06-15T18:30:00.000Z"},
+		{"today in kolkata ends next local midnight", Today, "2024-06-15T20:00:00Z", true, kolkata, "2024-06-16T18:30:00.000Z"},
+		// the day New York switches to daylight saving time is 23 hours long
+		{"today starts before DST switch", Today, "2024-03-10T15:00:00Z", false, newYork, "2024-03-10T05:00:00.000Z"},
+		{"today ends after DST switch", Today, "2024-03-10T15:00:00Z", true, newYork, "2024-03-11T04:00:00.000Z"},
+		{"yesterday ends before DST switch", Yesterday, "2024-03-10T15:00:00Z", true, newYork, "2024-03-10T05:00:00.000Z"},
+		{"wall clock time in winter", "2024-01-15T09:00:00", "2024-06-15T00:00:00Z", false, newYork, "2024-01-15T14:00:00.000Z"},
+		{"wall clock time in summer", "2024-07-15 09:00", "2024-06-15T00:00:00Z", false, newYork, "2024-07-15T13:00:00.000Z"},
+		{"time with zone is unchanged", "2024-07-15T09:00:00Z", "2024-06-15T00:00:00Z", false, newYork, "2024-07-15T09:00:00Z"},
+		{"duration is unchanged", "10m", "2024-06-15T00:00:00Z", false, kolkata, "10m"},
+		{"wall clock time in UTC is unchanged", "2024-07-15T09:00:00", "2024-06-15T00:00:00Z", false, time.UTC, "2024-07-15T09:00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ResolveIn(test.value, mustParse(t, test.now), test.isEnd, test.loc); got != test.want {
+				t.Fatalf("ResolveIn(%s) = %s, want %s", test.value, got, test.want)
+			}
+		})
+	}
+}